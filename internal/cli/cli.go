@@ -0,0 +1,268 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package cli implements the switcher's headless commands, run when the
+// executable is started with arguments instead of showing the window.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/config"
+	"github.com/fanis/claude-code-switcher/internal/notes"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// command is a headless subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(env *env, args []string) error
+}
+
+// env is what every command runs with
+type env struct {
+	stdout, stderr io.Writer
+	cfg            *config.Config
+	hide           *projects.HideRules // Compiled from cfg when first needed
+	notes          *notes.Store        // Loaded when first needed
+}
+
+// commands lists the subcommands, in the order help shows them
+var commands = []command{
+	{"list", "List projects by recency, frecency or name", runList},
+	{"usage", "Show token usage and estimated cost per project, model or session", runUsage},
+	{"search", "Find sessions by the words used in their messages", runSearch},
+	{"prune", "Move the data of projects whose directory is gone to the trash", runPrune},
+	{"trash", "List, restore or empty pruned project data", runTrash},
+	{"relocate", "Move the history of a moved project to its new directory", runRelocate},
+	{"alias", "Show or set the name a project is listed under", runAlias},
+	{"tag", "List tags, or add or remove a tag on projects", runTag},
+	{"hide", "Hide projects from the list, or show them again", runHide},
+	{"note", "Show or set the note on a project", runNote},
+	{"export", "Write a session as Markdown or HTML", runExport},
+	{"doctor", "Show where each project was read from and which files failed to parse", runDoctor},
+}
+
+// Run executes the command named by args[0] and returns the process exit
+// code: 0 on success, 1 if the command failed and 2 for a usage error.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printHelp(stdout)
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		// A missing or broken config falls back to defaults
+		cfg, _ := config.Load()
+		if err := cmd.run(&env{stdout: stdout, stderr: stderr, cfg: cfg}, args[1:]); err != nil {
+			switch err {
+			case errHelp:
+				return 0
+			case errUsage:
+				return 2
+			}
+			fmt.Fprintf(stderr, "%s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	printHelp(stderr)
+	return 2
+}
+
+// errUsage reports invalid arguments; the flag package has already printed why
+var errUsage = fmt.Errorf("invalid arguments")
+
+// errHelp reports that a command only printed its help
+var errHelp = fmt.Errorf("help requested")
+
+// parseFlags parses a command's flags, mapping failures to errUsage and -h
+// to errHelp
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return errHelp
+		}
+		return errUsage
+	}
+	return nil
+}
+
+func printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: claude-code-switcher [command] [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the project switcher window opens.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.name))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'claude-code-switcher <command> -h' for the options of a command.")
+}
+
+// loadProjects loads the project list the way the window does, without the
+// git metadata the commands don't need
+func (e *env) loadProjects() ([]projects.Project, error) {
+	opts := projects.DefaultLoadOptions()
+	opts.Roots = projects.DefaultRoots(e.cfg.ClaudeDirs...)
+	opts.Git = false
+	opts.Pinned = e.cfg.Pinned
+	list, err := projects.LoadProjectsContext(context.Background(), opts)
+	e.applyLabels(list)
+	return list, err
+}
+
+// applyLabels sets the aliases, tags and hidden state from the config, and
+// the notes, on the projects
+func (e *env) applyLabels(list []projects.Project) {
+	for i := range list {
+		list[i].Alias = e.cfg.Alias(list[i].Path)
+		list[i].Tags = e.cfg.ProjectTags(list[i].Path)
+	}
+	e.hideRules().Apply(list)
+	e.noteStore().Apply(list)
+}
+
+// noteStore returns the project notes, read on first use
+func (e *env) noteStore() *notes.Store {
+	if e.notes == nil {
+		e.notes = notes.Load(cachePath(notes.FileName))
+	}
+	return e.notes
+}
+
+// hideRules returns the rules hiding projects, compiled from the config on
+// first use. Invalid patterns are reported and skipped.
+func (e *env) hideRules() *projects.HideRules {
+	if e.hide == nil {
+		var err error
+		if e.hide, err = projects.NewHideRules(e.cfg.IgnorePatterns, e.cfg.Hidden); err != nil {
+			fmt.Fprintln(e.stderr, err)
+		}
+	}
+	return e.hide
+}
+
+// visible drops the hidden projects, unless they were asked for
+func visible(list []projects.Project, includeHidden bool) []projects.Project {
+	if includeHidden {
+		return list
+	}
+	return projects.Visible(list)
+}
+
+// scanOptions returns where the config says to look for projects on disk
+func (e *env) scanOptions() projects.ScanOptions {
+	return projects.ScanOptions{
+		Roots:  e.cfg.ScanRoots,
+		Depth:  e.cfg.ScanDepth,
+		Ignore: e.cfg.ScanIgnore,
+	}
+}
+
+// cachePath returns the path of a cache file in the switcher's directory, or
+// "" to run without caching
+func cachePath(name string) string {
+	dir, err := config.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// matchProjects returns the projects whose name, alias, path or tags contain
+// any of the given patterns, case-insensitively. Patterns such as tag:oss are
+// qualifiers every project must pass. No patterns match everything. A
+// qualifier naming an unknown feature is an error.
+func matchProjects(list []projects.Project, patterns []string) ([]projects.Project, error) {
+	var filter projects.Filter
+	var words []string
+	for _, pattern := range patterns {
+		f, rest, err := projects.ParseFilter(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if rest == "" && !f.IsEmpty() {
+			filter.Tags = append(filter.Tags, f.Tags...)
+			filter.Has = append(filter.Has, f.Has...)
+			filter.HasNot = append(filter.HasNot, f.HasNot...)
+			continue
+		}
+		words = append(words, pattern)
+	}
+
+	var matched []projects.Project
+	for _, p := range list {
+		if !filter.Match(p) {
+			continue
+		}
+		if len(words) == 0 {
+			matched = append(matched, p)
+			continue
+		}
+		haystack := strings.ToLower(p.SearchText())
+		for _, word := range words {
+			if strings.Contains(haystack, strings.ToLower(word)) {
+				matched = append(matched, p)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// findProject returns the one project accepted by keep whose name, alias,
+// path or tags contain pattern, describing the projects looked for as what
+// in errors. An exact path wins over partial matches.
+func findProject(list []projects.Project, pattern, what string, keep func(projects.Project) bool) (projects.Project, error) {
+	candidates, err := matchProjects(list, []string{pattern})
+	if err != nil {
+		return projects.Project{}, err
+	}
+	var matched []projects.Project
+	for _, p := range candidates {
+		if !keep(p) {
+			continue
+		}
+		if strings.EqualFold(p.Path, pattern) {
+			return p, nil
+		}
+		matched = append(matched, p)
+	}
+	switch len(matched) {
+	case 0:
+		return projects.Project{}, fmt.Errorf("no %s matches %q", what, pattern)
+	case 1:
+		return matched[0], nil
+	}
+	paths := make([]string, len(matched))
+	for i, p := range matched {
+		paths[i] = p.Path
+	}
+	return projects.Project{}, fmt.Errorf("%q matches several projects, give the full path of one of: %s",
+		pattern, strings.Join(paths, ", "))
+}
+
+// plural formats a count with a noun, e.g. "1 session" or "3 sessions"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
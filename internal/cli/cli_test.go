@@ -0,0 +1,574 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupRoot points the commands at a temporary Claude data root and home
+// directory, and returns the root
+func setupRoot(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	root := filepath.Join(home, "claude")
+	t.Setenv("CLAUDE_CONFIG_DIR", root)
+	return root
+}
+
+// writeTranscript adds a session file to the project at projectPath
+func writeTranscript(t *testing.T, root, projectPath, name, content string) {
+	t.Helper()
+	dir := filepath.Join(root, "projects", strings.NewReplacer("/", "-", ".", "-").Replace(projectPath))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	index := fmt.Sprintf(`{"version":1,"entries":[],"originalPath":%q}`, projectPath)
+	os.WriteFile(filepath.Join(dir, "sessions-index.json"), []byte(index), 0644)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+}
+
+// run executes a command and returns its exit code and output
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func usageLine(session, id, model string, in, out int) string {
+	return fmt.Sprintf(`{"type":"assistant","sessionId":%q,"timestamp":"2026-10-05T10:00:00Z","requestId":"r%s","message":{"id":%q,"model":%q,"usage":{"input_tokens":%d,"output_tokens":%d}}}`+"\n",
+		session, id, id, model, in, out)
+}
+
+func TestUsageCommand(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/big", "s1.jsonl",
+		usageLine("s1", "m1", "claude-opus-4-1", 1000000, 0)+usageLine("s1", "m2", "claude-sonnet-4-5", 0, 1000000))
+	writeTranscript(t, root, "/work/small", "s2.jsonl", usageLine("s2", "m3", "claude-sonnet-4-5", 10, 10))
+
+	code, out, errOut := run("usage", "--json")
+	if code != 0 {
+		t.Fatalf("usage exited %d: %s", code, errOut)
+	}
+	var report struct {
+		Rows  []usageRow
+		Total usageRow
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].Path != "/work/big" {
+		t.Fatalf("rows = %+v, want /work/big first", report.Rows)
+	}
+	// $15 of opus input and $15 of sonnet output
+	if report.Rows[0].Cost < 29.99 || report.Rows[0].Cost > 30.01 {
+		t.Errorf("/work/big cost = %v, want 30", report.Rows[0].Cost)
+	}
+	if report.Total.Tokens.Total() != 2000020 {
+		t.Errorf("total tokens = %d, want 2000020", report.Total.Tokens.Total())
+	}
+
+	code, out, _ = run("usage", "--by", "model", "small")
+	if code != 0 || !strings.Contains(out, "claude-sonnet-4-5") || strings.Contains(out, "claude-opus") {
+		t.Errorf("usage --by model small = %d:\n%s", code, out)
+	}
+
+	// A project broken down by model, and the model each session ended on
+	code, out, errOut = run("usage", "--json", "--models", "big")
+	if code != 0 {
+		t.Fatalf("usage --models exited %d: %s", code, errOut)
+	}
+	report.Rows = nil
+	json.Unmarshal([]byte(out), &report)
+	if len(report.Rows) != 2 || report.Rows[0].Model != "claude-opus-4-1" || report.Rows[1].Model != "claude-sonnet-4-5" {
+		t.Fatalf("usage --models big rows = %+v, want opus then sonnet", report.Rows)
+	}
+	for _, row := range report.Rows {
+		if row.Name != "big" || row.Tokens.Messages != 1 || row.LastModel != "claude-sonnet-4-5" {
+			t.Errorf("usage --models big row = %+v", row)
+		}
+	}
+	code, out, _ = run("usage", "--by", "session")
+	if code != 0 || !strings.Contains(out, "Last model") || !strings.Contains(out, "claude-sonnet-4-5") {
+		t.Errorf("usage --by session = %d:\n%s", code, out)
+	}
+
+	code, out, _ = run("usage", "--month", "2026-09")
+	if code != 0 || !strings.Contains(out, "Total") || strings.Contains(out, "big") {
+		t.Errorf("usage --month 2026-09 = %d:\n%s", code, out)
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/app", "s1.jsonl",
+		`{"type":"user","sessionId":"s1","timestamp":"2026-10-05T10:00:00Z","message":{"role":"user","content":"the migration with the flaky index"}}`+"\n")
+	writeTranscript(t, root, "/work/lib", "s2.jsonl",
+		`{"type":"user","sessionId":"s2","timestamp":"2026-10-06T10:00:00Z","message":{"role":"user","content":"an unrelated migration"}}`+"\n")
+
+	code, out, errOut := run("search", "flaky", "migr")
+	if code != 0 {
+		t.Fatalf("search exited %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "claude --resume s1") || strings.Contains(out, "s2") {
+		t.Errorf("search flaky migr =\n%s\nwant only s1", out)
+	}
+
+	code, out, _ = run("search", "--json", "--project", "lib", "migration")
+	var results []searchResult
+	if err := json.Unmarshal([]byte(out), &results); err != nil || code != 0 {
+		t.Fatalf("search --json = %d, %v\n%s", code, err, out)
+	}
+	if len(results) != 1 || results[0].Session != "s2" || results[0].Path != "/work/lib" {
+		t.Errorf("search --project lib = %+v, want s2", results)
+	}
+
+	if code, _, _ := run("search"); code != 2 {
+		t.Errorf("search without words exited %d, want 2", code)
+	}
+}
+
+func TestPruneAndRestore(t *testing.T) {
+	root := setupRoot(t)
+	kept := t.TempDir()
+	writeTranscript(t, root, kept, "s1.jsonl", "{}\n")
+	writeTranscript(t, root, "/work/deleted-repo", "s2.jsonl", "{}\n")
+	dataDir := filepath.Join(root, "projects", "-work-deleted-repo")
+
+	code, out, _ := run("prune", "--dry-run")
+	if code != 0 || !strings.Contains(out, "/work/deleted-repo") || strings.Contains(out, kept) {
+		t.Fatalf("prune --dry-run = %d:\n%s", code, out)
+	}
+	if _, err := os.Stat(dataDir); err != nil {
+		t.Fatalf("dry run moved the data: %v", err)
+	}
+
+	if code, out, errOut := run("prune"); code != 0 || !strings.Contains(out, "Moved 1 folder") {
+		t.Fatalf("prune = %d:\n%s%s", code, out, errOut)
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Fatalf("data still in place after prune: %v", err)
+	}
+	if _, out, _ := run("trash"); !strings.Contains(out, "/work/deleted-repo") {
+		t.Errorf("trash list =\n%s", out)
+	}
+
+	if code, out, errOut := run("trash", "restore", "deleted-repo"); code != 0 {
+		t.Fatalf("trash restore = %d:\n%s%s", code, out, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "s2.jsonl")); err != nil {
+		t.Errorf("data not restored: %v", err)
+	}
+	if _, out, _ := run("trash"); !strings.Contains(out, "empty") {
+		t.Errorf("trash after restore =\n%s", out)
+	}
+}
+
+func TestRelocateCommand(t *testing.T) {
+	root := setupRoot(t)
+	src := t.TempDir()
+	newPath := filepath.Join(src, "my-app")
+	os.MkdirAll(newPath, 0755)
+	writeTranscript(t, root, "/work/old/my-app", "s1.jsonl", "{}\n")
+	config := fmt.Sprintf(`{"scan_roots":[%q]}`, src)
+	os.MkdirAll(filepath.Join(os.Getenv("HOME"), ".claude-code-switcher"), 0755)
+	os.WriteFile(filepath.Join(os.Getenv("HOME"), ".claude-code-switcher", "config.json"), []byte(config), 0644)
+
+	run("note", "my-app", "don't run migrations here")
+
+	code, out, errOut := run("relocate", "my-app")
+	if code != 0 || !strings.Contains(out, newPath+"  (same name)") {
+		t.Fatalf("relocate my-app = %d:\n%s%s", code, out, errOut)
+	}
+
+	if code, out, errOut := run("relocate", "my-app", newPath); code != 0 {
+		t.Fatalf("relocate my-app %s = %d:\n%s%s", newPath, code, out, errOut)
+	}
+	moved := filepath.Join(root, "projects", strings.NewReplacer("/", "-", ".", "-").Replace(newPath), "s1.jsonl")
+	if _, err := os.Stat(moved); err != nil {
+		t.Errorf("session not moved: %v", err)
+	}
+	if code, _, _ := run("relocate", "my-app"); code != 1 {
+		t.Errorf("relocate of a project that is found again exited %d, want 1", code)
+	}
+	// The note follows the project
+	if _, out, _ := run("note", newPath); out != "don't run migrations here\n" {
+		t.Errorf("note after relocating = %q", out)
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/alpha", "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, "/work/beta", "s2.jsonl", usageLine("s2", "m2", "claude-sonnet-4-5", 1, 1))
+
+	code, out, errOut := run("list", "--sort", "name")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	if strings.Index(out, "/work/alpha") > strings.Index(out, "/work/beta") {
+		t.Errorf("list by name put beta first:\n%s", out)
+	}
+
+	// Launches from the switcher make beta the more frecent project
+	now := time.Now().UTC().Format(time.RFC3339)
+	history := fmt.Sprintf(`{"launches":[{"path":"/work/beta","time":%q},{"path":"/work/beta","time":%q}]}`, now, now)
+	dir := filepath.Join(os.Getenv("HOME"), ".claude-code-switcher")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "history.json"), []byte(history), 0644)
+
+	code, out, errOut = run("list", "--sort", "frecency", "--json")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(rows) != 2 || rows[0].Path != "/work/beta" || rows[0].Frecency <= rows[1].Frecency {
+		t.Errorf("rows = %+v, want /work/beta first with the higher score", rows)
+	}
+
+	if code, _, _ := run("list", "--sort", "size"); code != 1 {
+		t.Errorf("unknown sort exited %d, want 1", code)
+	}
+}
+
+func TestPinnedProjects(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/alpha", "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, "/work/beta", "s2.jsonl", usageLine("s2", "m2", "claude-sonnet-4-5", 1, 1))
+	dir := filepath.Join(os.Getenv("HOME"), ".claude-code-switcher")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"pinned":["/work/beta","/mnt/offline/gamma"]}`), 0644)
+
+	code, out, errOut := run("list", "--sort", "name", "--json")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	var paths []string
+	for _, r := range rows {
+		paths = append(paths, r.Path)
+	}
+	if got, want := strings.Join(paths, " "), "/work/beta /mnt/offline/gamma /work/alpha"; got != want || !rows[0].Pinned {
+		t.Errorf("list order = %s, want %s with the pins marked", got, want)
+	}
+
+	// Only the unpinned project with a missing directory is pruned
+	code, out, errOut = run("prune")
+	if code != 0 {
+		t.Fatalf("prune exited %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "/work/alpha") || strings.Contains(out, "/work/beta") {
+		t.Errorf("prune output:\n%s\nwant only /work/alpha", out)
+	}
+}
+
+func TestAliasAndTagCommands(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/clients/a/api", "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, "/clients/b/api", "s2.jsonl", usageLine("s2", "m2", "claude-sonnet-4-5", 1, 1))
+
+	if code, _, errOut := run("tag", "add", "client-a", "/clients/a/api", "/clients/a/web"); code != 0 {
+		t.Fatalf("tag add exited %d: %s", code, errOut)
+	}
+	// A name shared by two projects is ambiguous
+	if code, _, errOut := run("tag", "add", "oss", "api"); code != 1 || !strings.Contains(errOut, "several projects") {
+		t.Errorf("ambiguous tag add exited %d: %s", code, errOut)
+	}
+	if code, _, errOut := run("alias", "/clients/a/api", "Client A API"); code != 0 {
+		t.Fatalf("alias exited %d: %s", code, errOut)
+	}
+
+	code, out, errOut := run("list", "--json", "tag:CLIENT-A")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(rows) != 1 || rows[0].Alias != "Client A API" || len(rows[0].Tags) != 1 || rows[0].Tags[0] != "client-a" {
+		t.Fatalf("list tag:client-a = %+v, want the aliased /clients/a/api", rows)
+	}
+
+	// The alias is matched like a name
+	if _, out, _ := run("list", "client a"); !strings.Contains(out, "/clients/a/api") || strings.Contains(out, "/clients/b/api") {
+		t.Errorf("list by alias:\n%s", out)
+	}
+
+	// Usage names projects as the other commands do
+	if _, out, _ := run("usage", "client a"); !strings.Contains(out, "Client A API") {
+		t.Errorf("usage of an aliased project:\n%s", out)
+	}
+
+	_, out, _ = run("tag")
+	if !strings.Contains(out, "client-a (2 projects)") || !strings.Contains(out, "/clients/a/web") {
+		t.Errorf("tag list:\n%s", out)
+	}
+
+	run("tag", "remove", "client-a", "/clients/a/api")
+	run("alias", "--clear", "/clients/a/api")
+	code, out, _ = run("list", "--json", "tag:client-a")
+	if code != 0 || strings.Contains(out, "/clients/a/api") {
+		t.Errorf("tag still listed after removal:\n%s", out)
+	}
+	if _, out, _ := run("alias"); !strings.Contains(out, "No aliases") {
+		t.Errorf("alias list after clearing:\n%s", out)
+	}
+}
+
+func TestNoteCommand(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/api", "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, "/work/web", "s2.jsonl", usageLine("s2", "m2", "claude-sonnet-4-5", 1, 1))
+
+	if code, out, errOut := run("note", "api", "waiting", "on", "PR", "#412"); code != 0 || !strings.Contains(out, "waiting on PR #412") {
+		t.Fatalf("note exited %d: %s%s", code, out, errOut)
+	}
+	if _, out, _ := run("note", "/work/api"); out != "waiting on PR #412\n" {
+		t.Errorf("note /work/api = %q", out)
+	}
+
+	// Notes are matched by project filters and included in JSON
+	code, out, errOut := run("list", "--json", "PR #412")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(rows) != 1 || rows[0].Path != "/work/api" || rows[0].Note != "waiting on PR #412" {
+		t.Errorf("list by note = %+v", rows)
+	}
+
+	if _, out, _ := run("note"); !strings.Contains(out, "/work/api") || !strings.Contains(out, "waiting on PR #412") {
+		t.Errorf("note list:\n%s", out)
+	}
+	run("note", "--clear", "api")
+	if _, out, _ := run("note"); !strings.Contains(out, "No notes") {
+		t.Errorf("note list after clearing:\n%s", out)
+	}
+}
+
+func TestListNewRepositories(t *testing.T) {
+	root := setupRoot(t)
+	src := filepath.Join(os.Getenv("HOME"), "src")
+	fresh := filepath.Join(src, "fresh")
+	os.MkdirAll(filepath.Join(fresh, ".git"), 0755)
+	writeTranscript(t, root, "/work/known", "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	dir := filepath.Join(os.Getenv("HOME"), ".claude-code-switcher")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"scan_roots":["~/src"]}`), 0644)
+
+	// Repositories are only scanned for when asked
+	if _, out, _ := run("list"); strings.Contains(out, fresh) {
+		t.Errorf("list without --new shows %s:\n%s", fresh, out)
+	}
+
+	code, out, errOut := run("list", "--new", "--sort", "name", "--json")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(rows) != 2 || rows[0].Path != "/work/known" || rows[1].Path != fresh || !rows[1].New {
+		t.Errorf("rows = %+v, want /work/known then the new %s", rows, fresh)
+	}
+}
+
+func TestHiddenProjects(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/api", "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, "/work/api-old", "s2.jsonl", usageLine("s2", "m2", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, "/tmp/try", "s3.jsonl", usageLine("s3", "m3", "claude-sonnet-4-5", 1, 1))
+	dir := filepath.Join(os.Getenv("HOME"), ".claude-code-switcher")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"ignore_patterns":["/tmp/*"]}`), 0644)
+
+	if code, _, errOut := run("hide", "/work/api-old"); code != 0 {
+		t.Fatalf("hide exited %d: %s", code, errOut)
+	}
+	_, out, _ := run("list")
+	if !strings.Contains(out, "/work/api") || strings.Contains(out, "/work/api-old") || strings.Contains(out, "/tmp/try") {
+		t.Errorf("list shows hidden projects:\n%s", out)
+	}
+	if _, out, _ := run("usage", "--json"); strings.Contains(out, "/tmp/try") {
+		t.Errorf("usage counts a hidden project:\n%s", out)
+	}
+	// Only a run over hidden projects too may drop their cached totals
+	run("usage", "--hidden", "--json")
+	run("usage", "--json")
+	if data, _ := os.ReadFile(filepath.Join(dir, "usage-cache.json")); !strings.Contains(string(data), "s3.jsonl") {
+		t.Errorf("usage without --hidden pruned the cache of a hidden project:\n%s", data)
+	}
+
+	code, out, errOut := run("list", "--hidden", "--json")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	hidden := 0
+	for _, r := range rows {
+		if r.Hidden {
+			hidden++
+		}
+	}
+	if len(rows) != 3 || hidden != 2 {
+		t.Errorf("list --hidden = %+v, want 3 projects, 2 hidden", rows)
+	}
+
+	_, out, _ = run("hide")
+	if !strings.Contains(out, "/work/api-old") || !strings.Contains(out, "ignore pattern") {
+		t.Errorf("hide list:\n%s", out)
+	}
+
+	// A project hidden by a pattern stays hidden
+	if _, _, errOut := run("hide", "--undo", "/tmp/try"); !strings.Contains(errOut, "still hidden") {
+		t.Errorf("hide --undo of an ignored project: %s", errOut)
+	}
+	run("hide", "--undo", "/work/api-old")
+	if _, out, _ := run("list"); !strings.Contains(out, "/work/api-old") {
+		t.Errorf("project still hidden after hide --undo:\n%s", out)
+	}
+}
+
+func TestDoctorCommand(t *testing.T) {
+	root := setupRoot(t)
+	writeTranscript(t, root, "/work/api", "s1.jsonl", "garbage\n"+`{"type":"user","cwd":"/work/api","sessionId":"s1"}`+"\n")
+	// Without an index the path is read from the transcript
+	os.Remove(filepath.Join(root, "projects", "-work-api", "sessions-index.json"))
+
+	code, out, errOut := run("doctor")
+	if code != 0 {
+		t.Fatalf("doctor exited %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "1 project folder") || !strings.Contains(out, "transcript cwd") {
+		t.Errorf("doctor output lacks the root or the project's source:\n%s", out)
+	}
+	if !strings.Contains(out, "s1.jsonl:1: invalid character") {
+		t.Errorf("doctor output lacks the parse error:\n%s", out)
+	}
+
+	code, out, _ = run("doctor", "--json", "nothing-matches")
+	var report doctorReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil || code != 0 {
+		t.Fatalf("doctor --json exited %d: %v\n%s", code, err, out)
+	}
+	if len(report.Roots) != 1 || len(report.Projects) != 0 || len(report.Problems) != 0 {
+		t.Errorf("filtered report = %+v, want the root only", report)
+	}
+}
+
+func TestListContextFilters(t *testing.T) {
+	root := setupRoot(t)
+	onboarded := filepath.Join(os.Getenv("HOME"), "onboarded")
+	bare := filepath.Join(os.Getenv("HOME"), "bare")
+	os.MkdirAll(bare, 0755)
+	os.MkdirAll(onboarded, 0755)
+	os.WriteFile(filepath.Join(onboarded, "CLAUDE.md"), []byte("# Notes"), 0644)
+	os.WriteFile(filepath.Join(onboarded, ".mcp.json"), []byte(`{"mcpServers":{"jira":{}}}`), 0644)
+	writeTranscript(t, root, onboarded, "s1.jsonl", usageLine("s1", "m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, root, bare, "s2.jsonl", usageLine("s2", "m2", "claude-sonnet-4-5", 1, 1))
+
+	code, out, errOut := run("list", "--json", "--", "-has:claudemd")
+	if code != 0 {
+		t.Fatalf("list exited %d: %s", code, errOut)
+	}
+	var rows []listRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(rows) != 1 || rows[0].Path != bare {
+		t.Errorf("list -has:claudemd = %+v, want %s only", rows, bare)
+	}
+
+	_, out, _ = run("list", "--json", "has:mcp")
+	rows = nil
+	json.Unmarshal([]byte(out), &rows)
+	if len(rows) != 1 || rows[0].Context == nil || fmt.Sprint(rows[0].Context.MCPServers) != "[jira]" {
+		t.Errorf("list has:mcp = %s", out)
+	}
+
+	if code, _, errOut := run("list", "has:mpc"); code != 1 || !strings.Contains(errOut, "unknown feature in has:mpc") {
+		t.Errorf("list has:mpc exited %d: %s, want an unknown feature error", code, errOut)
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	root := setupRoot(t)
+	session := `{"type":"user","sessionId":"7f3c9e21","timestamp":"2026-10-05T10:00:00Z","message":{"role":"user","content":"Why do the <checkout> tests fail?"}}
+{"type":"assistant","sessionId":"7f3c9e21","timestamp":"2026-10-05T10:00:05Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"thinking","thinking":"Run them first."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./checkout"}}]}}
+{"type":"user","sessionId":"7f3c9e21","timestamp":"2026-10-05T10:00:09Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"FAIL: total < 0","is_error":true}]}}
+{"type":"assistant","sessionId":"7f3c9e21","timestamp":"2026-10-05T10:00:12Z","message":{"id":"m2","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"The total goes negative."}]}}
+`
+	writeTranscript(t, root, "/work/api", "7f3c9e21.jsonl", session)
+	writeTranscript(t, root, "/work/web", "7a000000.jsonl", strings.ReplaceAll(session, "7f3c9e21", "7a000000"))
+
+	code, out, errOut := run("export", "7f3")
+	if code != 0 {
+		t.Fatalf("export exited %d: %s", code, errOut)
+	}
+	for _, want := range []string{"# Why do the <checkout> tests fail?", "- Project: `/work/api`", "**Tool call** `Bash: go test ./checkout`", "FAIL: total < 0", "Run them first."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("export is missing %q:\n%s", want, out)
+		}
+	}
+
+	if code, _, errOut := run("export", "7"); code != 1 || !strings.Contains(errOut, "several sessions") {
+		t.Errorf("export of an ambiguous ID = %d, %q", code, errOut)
+	}
+
+	// The format follows the file name, and the latest session of a project
+	// is taken when none is given
+	file := filepath.Join(t.TempDir(), "session.html")
+	if code, _, errOut := run("export", "--project", "web", "--no-tool-output", "--no-thinking", "-o", file); code != 0 {
+		t.Fatalf("export -o exited %d: %s", code, errOut)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+	if !strings.Contains(html, "<title>Why do the &lt;checkout&gt; tests fail?</title>") || !strings.Contains(html, "/work/web") {
+		t.Errorf("export -o session.html:\n%s", html)
+	}
+	if strings.Contains(html, "FAIL: total") || strings.Contains(html, "Run them first.") {
+		t.Errorf("export kept tool output or thinking:\n%s", html)
+	}
+
+	if code, _, _ := run("export"); code != 2 {
+		t.Errorf("export without a session exited %d, want 2", code)
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	if code, _, errOut := run("frobnicate"); code != 2 || !strings.Contains(errOut, "unknown command") {
+		t.Errorf("Run(frobnicate) = %d, %q", code, errOut)
+	}
+	if code, _, _ := run("usage", "--by", "colour"); code != 1 {
+		t.Errorf("usage --by colour exited %d, want 1", code)
+	}
+}
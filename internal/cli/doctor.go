@@ -0,0 +1,156 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fanis/claude-code-switcher/internal/config"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// doctorReport is the JSON output of the doctor command
+type doctorReport struct {
+	Config   []string                  `json:"config,omitempty"`
+	Roots    []doctorRoot              `json:"roots"`
+	Projects []projects.ProjectSources `json:"projects"`
+	Problems []projects.Problem        `json:"problems"`
+}
+
+// doctorRoot is a Claude data root and whether its projects folder is there
+type doctorRoot struct {
+	Path    string `json:"path"`
+	Folders int    `json:"folders"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runDoctor reports how every project was found and which files couldn't be
+// read, bypassing the project cache
+func runDoctor(e *env, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher doctor [options] [project filter...]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Reads every project folder again, ignoring the cache, and reports where each")
+		fmt.Fprintln(e.stderr, "project's path and last use came from, and every file that couldn't be read")
+		fmt.Fprintln(e.stderr, "or parsed. Useful when a project is missing or listed under the wrong path.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var report doctorReport
+	if err := config.Check(); err != nil {
+		report.Config = append(report.Config, fmt.Sprintf("config ignored: %v", err))
+	}
+	if _, err := projects.NewHideRules(e.cfg.IgnorePatterns, nil); err != nil {
+		report.Config = append(report.Config, err.Error())
+	}
+
+	opts := projects.DefaultLoadOptions()
+	opts.Roots = projects.DefaultRoots(e.cfg.ClaudeDirs...)
+	opts.CachePath = ""
+	opts.Git = false
+	opts.Pinned = e.cfg.Pinned
+	for _, root := range opts.Roots {
+		r := doctorRoot{Path: root}
+		entries, err := os.ReadDir(filepath.Join(root, "projects"))
+		if err != nil {
+			r.Error = err.Error()
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				r.Folders++
+			}
+		}
+		report.Roots = append(report.Roots, r)
+	}
+
+	diag := &projects.Diagnostics{}
+	opts.Diagnostics = diag
+	if _, err := projects.LoadProjectsContext(context.Background(), opts); err != nil && !errors.Is(err, projects.ErrNoProjects) {
+		return err
+	}
+	report.Projects = diag.Projects
+	report.Problems = diag.Problems
+	if patterns := fs.Args(); len(patterns) > 0 {
+		report.Projects, report.Problems = nil, nil
+		for _, p := range diag.Projects {
+			if matchesAny(p.Path+" "+p.Dir, patterns) {
+				report.Projects = append(report.Projects, p)
+			}
+		}
+		for _, p := range diag.Problems {
+			if matchesAny(p.File, patterns) {
+				report.Problems = append(report.Problems, p)
+			}
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	for _, line := range report.Config {
+		fmt.Fprintln(e.stdout, line)
+	}
+	fmt.Fprintln(e.stdout, "Data roots:")
+	for _, r := range report.Roots {
+		if r.Error != "" {
+			fmt.Fprintf(e.stdout, "  %s: %s\n", r.Path, r.Error)
+		} else {
+			fmt.Fprintf(e.stdout, "  %s: %s\n", r.Path, plural(r.Folders, "project folder"))
+		}
+	}
+	fmt.Fprintln(e.stdout)
+
+	if len(report.Projects) > 0 {
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Project\tFound from\tData folder")
+		for _, p := range report.Projects {
+			dir := p.Dir
+			if dir == "" {
+				dir = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Path, strings.Join(p.Sources, ", "), dir)
+		}
+		tw.Flush()
+		fmt.Fprintln(e.stdout)
+	}
+
+	if len(report.Problems) == 0 {
+		fmt.Fprintln(e.stdout, "No problems found.")
+		return nil
+	}
+	fmt.Fprintf(e.stdout, "%s:\n", plural(len(report.Problems), "problem"))
+	for _, p := range report.Problems {
+		fmt.Fprintf(e.stdout, "  %s\n", p)
+	}
+	return nil
+}
+
+// matchesAny reports whether text contains any of the patterns, ignoring case
+func matchesAny(text string, patterns []string) bool {
+	text = strings.ToLower(text)
+	for _, pattern := range patterns {
+		if strings.Contains(text, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
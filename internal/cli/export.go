@@ -0,0 +1,176 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/export"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// runExport writes a session transcript as Markdown or HTML
+func runExport(e *env, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	format := fs.String("format", "", "write `markdown` or html; by default taken from the -o extension, else markdown")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	noToolOutput := fs.Bool("no-tool-output", false, "leave out what tools returned, keeping the calls")
+	noThinking := fs.Bool("no-thinking", false, "leave out thinking blocks")
+	project := fs.String("project", "", "only look in projects whose name or path contains `filter`")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher export [options] [session]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Writes the prompts, responses, tool calls and their output of a session.")
+		fmt.Fprintln(e.stderr, "The session is given by its ID or the start of it. Without one, the most")
+		fmt.Fprintln(e.stderr, "recent session of the project given with -project is written.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 0 && *project == "") {
+		fs.Usage()
+		return errUsage
+	}
+
+	if *format == "" {
+		*format = export.FormatMarkdown
+		if ext := strings.ToLower(filepath.Ext(*output)); ext == ".html" || ext == ".htm" {
+			*format = export.FormatHTML
+		}
+	}
+	if *format != export.FormatMarkdown && *format != export.FormatHTML {
+		return fmt.Errorf("unknown format %q, want %s or %s", *format, export.FormatMarkdown, export.FormatHTML)
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	var p projects.Project
+	var s projects.Session
+	if fs.NArg() == 1 {
+		if *project != "" {
+			if list, err = matchProjects(list, []string{*project}); err != nil {
+				return err
+			}
+		}
+		p, s, err = findSession(list, fs.Arg(0))
+	} else {
+		p, s, err = latestSession(list, *project)
+	}
+	if err != nil {
+		return err
+	}
+
+	t, err := export.Load(p, s)
+	if err != nil {
+		return fmt.Errorf("failed to read session %s: %w", s.ID, err)
+	}
+	opts := export.Options{NoToolOutput: *noToolOutput, NoThinking: *noThinking}
+	if *output == "" {
+		return export.Write(e.stdout, *format, t, opts)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, *format, t, opts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Wrote %s (%s) to %s\n", s.ID, plural(len(t.Turns), "message"), *output)
+	return nil
+}
+
+// findSession returns the session whose ID is or starts with id, and its
+// project. Only the file names are looked at until one session is left, so
+// the transcripts of other projects aren't read.
+func findSession(list []projects.Project, id string) (projects.Project, projects.Session, error) {
+	type match struct {
+		project projects.Project
+		id      string
+	}
+	var matches []match
+	var exact *match
+	for _, p := range list {
+		dirs, err := p.DataDirs()
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			files, _ := filepath.Glob(filepath.Join(dir, escapeGlob(id)+"*.jsonl"))
+			for _, file := range files {
+				name := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+				if strings.HasPrefix(name, "agent-") {
+					continue
+				}
+				if name == id {
+					exact = &match{p, name}
+				}
+				matches = append(matches, match{p, name})
+			}
+		}
+	}
+
+	if exact != nil {
+		matches = []match{*exact}
+	}
+	switch len(matches) {
+	case 0:
+		return projects.Project{}, projects.Session{}, fmt.Errorf("no session matches %q", id)
+	case 1:
+	default:
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = m.id
+		}
+		return projects.Project{}, projects.Session{}, fmt.Errorf("%q matches several sessions, give more of the ID: %s",
+			id, strings.Join(ids, ", "))
+	}
+
+	m := matches[0]
+	sessions, err := projects.LoadSessions(m.project)
+	if err != nil {
+		return projects.Project{}, projects.Session{}, err
+	}
+	for _, s := range sessions {
+		if s.ID == m.id {
+			return m.project, s, nil
+		}
+	}
+	return projects.Project{}, projects.Session{}, fmt.Errorf("session %s of %s has no messages", m.id, m.project.Path)
+}
+
+// latestSession returns the most recent session of the one project matching
+// pattern
+func latestSession(list []projects.Project, pattern string) (projects.Project, projects.Session, error) {
+	p, err := findProject(list, pattern, "project", func(projects.Project) bool { return true })
+	if err != nil {
+		return projects.Project{}, projects.Session{}, err
+	}
+	sessions, err := projects.LoadSessions(p)
+	if err != nil {
+		return projects.Project{}, projects.Session{}, err
+	}
+	if len(sessions) == 0 {
+		return projects.Project{}, projects.Session{}, fmt.Errorf("%s has no sessions", p.Path)
+	}
+	return p, sessions[0], nil
+}
+
+// escapeGlob escapes the characters filepath.Match treats specially
+func escapeGlob(s string) string {
+	return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(s)
+}
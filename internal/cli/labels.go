@@ -0,0 +1,355 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/config"
+	"github.com/fanis/claude-code-switcher/internal/notes"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// runAlias shows, sets or removes the names projects are listed under
+func runAlias(e *env, args []string) error {
+	fs := flag.NewFlagSet("alias", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	remove := fs.Bool("clear", false, "remove the alias of the project")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher alias [project [alias]]")
+		fmt.Fprintln(e.stderr, "       claude-code-switcher alias --clear <project>")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "An alias is shown instead of the project's folder name and is matched by")
+		fmt.Fprintln(e.stderr, "the search. Without arguments, lists every alias.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 2 || (*remove && fs.NArg() != 1) {
+		fs.Usage()
+		return errUsage
+	}
+
+	if fs.NArg() == 0 {
+		if len(e.cfg.Aliases) == 0 {
+			fmt.Fprintln(e.stdout, "No aliases set.")
+			return nil
+		}
+		paths := make([]string, 0, len(e.cfg.Aliases))
+		for path := range e.cfg.Aliases {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			return strings.ToLower(e.cfg.Aliases[paths[i]]) < strings.ToLower(e.cfg.Aliases[paths[j]])
+		})
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Alias\tPath")
+		for _, path := range paths {
+			fmt.Fprintf(tw, "%s\t%s\n", e.cfg.Aliases[path], path)
+		}
+		return tw.Flush()
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	path, err := resolveProjectPath(list, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	switch {
+	case *remove:
+		e.cfg.SetAlias(path, "")
+		fmt.Fprintf(e.stdout, "Removed the alias of %s\n", path)
+	case fs.NArg() == 2:
+		e.cfg.SetAlias(path, fs.Arg(1))
+		fmt.Fprintf(e.stdout, "%s is now shown as %s\n", path, e.cfg.Alias(path))
+	default:
+		if alias := e.cfg.Alias(path); alias != "" {
+			fmt.Fprintln(e.stdout, alias)
+		} else {
+			fmt.Fprintf(e.stdout, "%s has no alias.\n", path)
+		}
+		return nil
+	}
+	return config.Save(e.cfg)
+}
+
+// runNote shows, sets or removes the free-text note on a project
+func runNote(e *env, args []string) error {
+	fs := flag.NewFlagSet("note", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	remove := fs.Bool("clear", false, "remove the note on the project")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher note [project [text...]]")
+		fmt.Fprintln(e.stderr, "       claude-code-switcher note --clear <project>")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "A note is a line of free text shown under the project in the list and")
+		fmt.Fprintln(e.stderr, "matched by the search, such as \"waiting on PR #412\". Without arguments,")
+		fmt.Fprintln(e.stderr, "lists every note.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *remove && fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	store := e.noteStore()
+	if fs.NArg() == 0 {
+		if len(store.Notes) == 0 {
+			fmt.Fprintln(e.stdout, "No notes set.")
+			return nil
+		}
+		list := make([]notes.Note, 0, len(store.Notes))
+		for _, note := range store.Notes {
+			list = append(list, note)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Path\tNote")
+		for _, note := range list {
+			fmt.Fprintf(tw, "%s\t%s\n", note.Path, note.Text)
+		}
+		return tw.Flush()
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	path, err := resolveProjectPath(list, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	switch {
+	case *remove:
+		store.Set(path, "", time.Now())
+		fmt.Fprintf(e.stdout, "Removed the note on %s\n", path)
+	case fs.NArg() > 1:
+		text := store.Set(path, strings.Join(fs.Args()[1:], " "), time.Now())
+		if text == "" {
+			fmt.Fprintf(e.stdout, "Removed the note on %s\n", path)
+		} else {
+			fmt.Fprintf(e.stdout, "Noted on %s: %s\n", path, text)
+		}
+	default:
+		if note := store.Get(path); note != "" {
+			fmt.Fprintln(e.stdout, note)
+		} else {
+			fmt.Fprintf(e.stdout, "%s has no note.\n", path)
+		}
+		return nil
+	}
+	return store.Save()
+}
+
+// runTag lists tags, or adds or removes a tag on projects
+func runTag(e *env, args []string) error {
+	fs := flag.NewFlagSet("tag", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher tag [list]")
+		fmt.Fprintln(e.stderr, "       claude-code-switcher tag add <tag> <project>...")
+		fmt.Fprintln(e.stderr, "       claude-code-switcher tag remove <tag> <project>...")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Tags are free-form labels matched by the search; tag:<name> in a search or")
+		fmt.Fprintln(e.stderr, "project filter keeps only the projects with that tag. Projects are given by")
+		fmt.Fprintln(e.stderr, "name or path; an absolute path may name a project with no sessions yet.")
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	action := "list"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+	switch action {
+	case "list":
+		if fs.NArg() > 1 {
+			break
+		}
+		printTags(e)
+		return nil
+
+	case "add", "remove":
+		if fs.NArg() < 3 {
+			break
+		}
+		tags := config.ParseTags(fs.Arg(1))
+		if len(tags) != 1 {
+			return fmt.Errorf("%q is not a single tag", fs.Arg(1))
+		}
+		tag := tags[0]
+
+		list, err := e.loadProjects()
+		if err != nil {
+			return err
+		}
+		var paths []string
+		for _, arg := range fs.Args()[2:] {
+			path, err := resolveProjectPath(list, arg)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+		}
+		for _, path := range paths {
+			current := e.cfg.ProjectTags(path)
+			if action == "add" {
+				e.cfg.SetTags(path, append(current, tag))
+				fmt.Fprintf(e.stdout, "Tagged %s with %s\n", path, tag)
+				continue
+			}
+			var kept []string
+			for _, t := range current {
+				if !strings.EqualFold(t, tag) {
+					kept = append(kept, t)
+				}
+			}
+			e.cfg.SetTags(path, kept)
+			fmt.Fprintf(e.stdout, "Removed %s from %s\n", tag, path)
+		}
+		return config.Save(e.cfg)
+	}
+
+	fs.Usage()
+	return errUsage
+}
+
+// runHide hides projects from the list, shows them again, or lists what is
+// hidden
+func runHide(e *env, args []string) error {
+	fs := flag.NewFlagSet("hide", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	undo := fs.Bool("undo", false, "show the projects again")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher hide [--undo] [project...]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Hidden projects are left out of the window's list, and of the list, usage,")
+		fmt.Fprintln(e.stderr, "search and prune commands unless those are given --hidden. Projects can also")
+		fmt.Fprintln(e.stderr, "be hidden by ignore_patterns in the config. Without projects, lists what is")
+		fmt.Fprintln(e.stderr, "hidden.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *undo && fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Project\tHidden by")
+		count := 0
+		for _, p := range list {
+			if !p.Hidden {
+				continue
+			}
+			reason := "ignore pattern"
+			if e.cfg.IsHidden(p.Path) {
+				reason = "hide"
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", p.Path, reason)
+			count++
+		}
+		if count == 0 {
+			fmt.Fprintln(e.stdout, "No projects are hidden.")
+			return nil
+		}
+		return tw.Flush()
+	}
+
+	var paths []string
+	for _, arg := range fs.Args() {
+		path, err := resolveProjectPath(list, arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		switch {
+		case *undo && e.cfg.IsHidden(path):
+			e.cfg.ToggleHidden(path)
+			fmt.Fprintf(e.stdout, "Showing %s\n", path)
+		case !*undo && !e.cfg.IsHidden(path):
+			e.cfg.ToggleHidden(path)
+			fmt.Fprintf(e.stdout, "Hid %s\n", path)
+		}
+		e.hide = nil
+		if *undo && e.hideRules().Hides(path) {
+			fmt.Fprintf(e.stderr, "%s is still hidden by an ignore pattern\n", path)
+		}
+	}
+	return config.Save(e.cfg)
+}
+
+// printTags lists every tag with the projects that have it
+func printTags(e *env) {
+	byTag := make(map[string][]string)
+	var names []string
+	for path, tags := range e.cfg.Tags {
+		for _, tag := range tags {
+			key := strings.ToLower(tag)
+			if _, ok := byTag[key]; !ok {
+				names = append(names, tag)
+			}
+			byTag[key] = append(byTag[key], path)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(e.stdout, "No tags set.")
+		return
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	for _, name := range names {
+		paths := byTag[strings.ToLower(name)]
+		sort.Strings(paths)
+		fmt.Fprintf(e.stdout, "%s (%s)\n", name, plural(len(paths), "project"))
+		for _, path := range paths {
+			fmt.Fprintf(e.stdout, "  %s\n", path)
+		}
+	}
+}
+
+// resolveProjectPath returns the path of the one project matching arg. An
+// absolute path that no project has yet is taken as it is, so settings can
+// be prepared for a project before its first session.
+func resolveProjectPath(list []projects.Project, arg string) (string, error) {
+	p, err := findProject(list, arg, "project", func(projects.Project) bool { return true })
+	if err == nil {
+		return p.Path, nil
+	}
+	if filepath.IsAbs(arg) {
+		// A path is never a qualifier, so matching it can't fail
+		if matched, _ := matchProjects(list, []string{arg}); len(matched) == 0 {
+			return filepath.Clean(arg), nil
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/frecency"
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// listRow is one project in the JSON output of the list command
+type listRow struct {
+	Name     string    `json:"name"`
+	Alias    string    `json:"alias,omitempty"`
+	Path     string    `json:"path"`
+	Tags     []string  `json:"tags,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	LastUsed time.Time `json:"lastUsed"`
+	Frecency float64   `json:"frecency,omitempty"`
+	Pinned   bool      `json:"pinned,omitempty"`
+	New      bool      `json:"new,omitempty"`
+	Hidden   bool      `json:"hidden,omitempty"`
+	// Context is the Claude Code configuration in the project directory
+	Context *projects.Context `json:"context,omitempty"`
+	// Config is the project's entry in Claude Code's .claude.json
+	Config *projects.ProjectConfig `json:"config,omitempty"`
+}
+
+// runList prints the projects in the order the window would show them
+func runList(e *env, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	sortBy := fs.String("sort", "recent", "order by `recent`, frecency or name")
+	top := fs.Int("top", 0, "show only the first `n` projects")
+	withHidden := fs.Bool("hidden", false, "include hidden projects")
+	withNew := fs.Bool("new", false, "also list git repositories under scan_roots not yet opened in Claude Code")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher list [options] [project filter...]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Lists the projects Claude Code has been used in. Frecency ranks projects by")
+		fmt.Fprintln(e.stderr, "how often and how recently they were used, with older use counting less.")
+		fmt.Fprintln(e.stderr, "Pinned projects, marked *, come first in every order, and new repositories")
+		fmt.Fprintln(e.stderr, "come last.")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Filters can include tag:<tag>, has:<feature> and -has:<feature>, where a feature")
+		fmt.Fprintln(e.stderr, "is claudemd, settings, mcp, commands or agents. Put -- before a filter starting")
+		fmt.Fprintln(e.stderr, "with -, e.g. list -- -has:claudemd.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *sortBy != "recent" && *sortBy != "frecency" && *sortBy != "name" {
+		return fmt.Errorf("unknown sort %q, want recent, frecency or name", *sortBy)
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	if *withNew {
+		found := projects.Discover(context.Background(), e.scanOptions(), list)
+		e.applyLabels(found)
+		list = append(list, found...)
+	}
+	list, err = matchProjects(visible(list, *withHidden), fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var scores map[string]float64
+	switch *sortBy {
+	case "frecency":
+		history := frecency.LoadHistory(cachePath(frecency.HistoryFileName))
+		scores = frecency.Scores(list, history, time.Now(), frecency.HalfLife(e.cfg.FrecencyHalfLifeDays))
+		frecency.Sort(list, scores)
+	case "name":
+		projects.SortByName(list)
+	default:
+		projects.SortByLastUsed(list)
+	}
+	projects.NewLast(list)
+	projects.PinFirst(list, e.cfg.IsPinned)
+	if *top > 0 && len(list) > *top {
+		list = list[:*top]
+	}
+
+	if *asJSON {
+		rows := make([]listRow, 0, len(list))
+		for _, p := range list {
+			rows = append(rows, listRow{
+				Name:     p.Name,
+				Alias:    p.Alias,
+				Path:     p.Path,
+				Tags:     p.Tags,
+				Note:     p.Note,
+				LastUsed: p.LastUsed,
+				Frecency: scores[fsutil.PathKey(p.Path)],
+				Pinned:   e.cfg.IsPinned(p.Path),
+				New:      p.New,
+				Hidden:   p.Hidden,
+				Context:  p.Context,
+				Config:   p.Config,
+			})
+		}
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(e.stdout, "No projects found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	if scores != nil {
+		fmt.Fprintln(tw, "Project\tLast used\tFrecency\tPath")
+	} else {
+		fmt.Fprintln(tw, "Project\tLast used\tPath")
+	}
+	for _, p := range list {
+		name := p.DisplayName()
+		if len(p.Tags) > 0 {
+			name += " #" + strings.Join(p.Tags, " #")
+		}
+		if p.Hidden {
+			name += " (hidden)"
+		}
+		if e.cfg.IsPinned(p.Path) {
+			name = "* " + name
+		}
+		lastUsed := "never"
+		if p.New {
+			lastUsed = "new"
+		} else if !p.LastUsed.IsZero() {
+			lastUsed = p.LastUsed.Local().Format("2006-01-02 15:04")
+		}
+		if scores != nil {
+			fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\n", name, lastUsed, scores[fsutil.PathKey(p.Path)], p.Path)
+		} else {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", name, lastUsed, p.Path)
+		}
+	}
+	return tw.Flush()
+}
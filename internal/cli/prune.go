@@ -0,0 +1,197 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+	"github.com/fanis/claude-code-switcher/internal/trash"
+)
+
+// runPrune moves the data of projects whose directory is gone to the trash
+func runPrune(e *env, args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	dryRun := fs.Bool("dry-run", false, "only list what would be moved to the trash")
+	withHidden := fs.Bool("hidden", false, "include hidden projects")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher prune [options] [project filter...]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Moves the Claude Code data of projects whose directory no longer exists")
+		fmt.Fprintln(e.stderr, "to the switcher's trash, where it can be restored until it expires.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	tr, err := e.openTrash()
+	if err != nil {
+		return err
+	}
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	// Pinned projects are kept, even when their directory is gone for good
+	matched, err := matchProjects(visible(list, *withHidden), fs.Args())
+	if err != nil {
+		return err
+	}
+	var candidates []projects.Project
+	for _, p := range matched {
+		if !e.cfg.IsPinned(p.Path) {
+			candidates = append(candidates, p)
+		}
+	}
+	orphans := projects.Orphans(candidates)
+	if len(orphans) == 0 {
+		fmt.Fprintln(e.stdout, "No orphaned project data found.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Project\tSessions\tSize\tData folder")
+	var total int64
+	for _, o := range orphans {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", o.Project.Path, o.Sessions, trash.FormatSize(o.Size), o.Dir)
+		total += o.Size
+	}
+	tw.Flush()
+	fmt.Fprintln(e.stdout)
+
+	if *dryRun {
+		fmt.Fprintf(e.stdout, "%s in %s would be moved to the trash.\n", trash.FormatSize(total), plural(len(orphans), "folder"))
+		return nil
+	}
+	moved, err := tr.Prune(orphans)
+	fmt.Fprintf(e.stdout, "Moved %s to the trash. Trashed data is deleted after %s; restore with\n",
+		plural(len(moved), "folder"), plural(int(trash.Retention(e.cfg.TrashRetentionDays).Hours()/24), "day"))
+	fmt.Fprintln(e.stdout, "'claude-code-switcher trash restore <id or project>'.")
+	return err
+}
+
+// runTrash lists, restores or empties the trash
+func runTrash(e *env, args []string) error {
+	fs := flag.NewFlagSet("trash", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher trash [list]")
+		fmt.Fprintln(e.stderr, "       claude-code-switcher trash restore <id or project>...")
+		fmt.Fprintln(e.stderr, "       claude-code-switcher trash empty")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Manages the project data moved to the trash by prune. Folders are deleted")
+		fmt.Fprintln(e.stderr, "for good once they are older than trash_retention_days (default 30).")
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	tr, err := e.openTrash()
+	if err != nil {
+		return err
+	}
+	action := "list"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+	switch action {
+	case "list":
+		entries, err := tr.List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(e.stdout, "The trash is empty.")
+			return nil
+		}
+		printTrash(e.stdout, entries, trash.Retention(e.cfg.TrashRetentionDays))
+		return nil
+
+	case "restore":
+		if fs.NArg() < 2 {
+			fs.Usage()
+			return errUsage
+		}
+		entries, err := tr.List()
+		if err != nil {
+			return err
+		}
+		for _, arg := range fs.Args()[1:] {
+			entry, err := findTrashEntry(entries, arg)
+			if err != nil {
+				return err
+			}
+			if _, err := tr.Restore(entry.ID); err != nil {
+				return err
+			}
+			fmt.Fprintf(e.stdout, "Restored %s to %s\n", entry.ProjectPath, entry.OriginalDir)
+		}
+		return nil
+
+	case "empty":
+		purged, err := tr.Purge(time.Now().Add(time.Hour))
+		fmt.Fprintf(e.stdout, "Deleted %s.\n", plural(len(purged), "folder"))
+		return err
+	}
+
+	fs.Usage()
+	return errUsage
+}
+
+// openTrash opens the switcher's trash, deleting what has expired
+func (e *env) openTrash() (*trash.Trash, error) {
+	dir := cachePath(trash.DirName)
+	if dir == "" {
+		return nil, fmt.Errorf("can't locate the switcher's directory")
+	}
+	tr := trash.Open(dir)
+	if _, err := tr.Purge(time.Now().Add(-trash.Retention(e.cfg.TrashRetentionDays))); err != nil {
+		fmt.Fprintf(e.stderr, "failed to delete expired trash: %v\n", err)
+	}
+	return tr, nil
+}
+
+// findTrashEntry returns the entry with the given ID, or the only one whose
+// project path contains it
+func findTrashEntry(entries []trash.Entry, arg string) (trash.Entry, error) {
+	var matched []trash.Entry
+	for _, entry := range entries {
+		if entry.ID == arg {
+			return entry, nil
+		}
+		if strings.Contains(strings.ToLower(entry.ProjectPath), strings.ToLower(arg)) {
+			matched = append(matched, entry)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return trash.Entry{}, fmt.Errorf("nothing in the trash matches %q", arg)
+	case 1:
+		return matched[0], nil
+	}
+	ids := make([]string, len(matched))
+	for i, entry := range matched {
+		ids[i] = entry.ID
+	}
+	return trash.Entry{}, fmt.Errorf("%q matches several folders, use one of their IDs: %s", arg, strings.Join(ids, ", "))
+}
+
+// printTrash writes the trash entries as a table
+func printTrash(w io.Writer, entries []trash.Entry, retention time.Duration) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tProject\tSessions\tSize\tExpires")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", entry.ID, entry.ProjectPath, entry.Sessions,
+			trash.FormatSize(entry.Size), entry.TrashedAt.Add(retention).Local().Format("2006-01-02"))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/fanis/claude-code-switcher/internal/config"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// runRelocate moves the history of a project whose directory has moved to
+// its new location, or suggests where it may have gone
+func runRelocate(e *env, args []string) error {
+	fs := flag.NewFlagSet("relocate", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher relocate <project> [new path]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Moves the Claude Code history of a project whose directory no longer exists")
+		fmt.Fprintln(e.stderr, "to the directory it was moved to, so its sessions can be resumed there.")
+		fmt.Fprintln(e.stderr, "Without a new path, lists folders under scan_roots with the same name or")
+		fmt.Fprintln(e.stderr, "git origin.")
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	p, err := findMissingProject(list, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 1 {
+		candidates := projects.FindMoved(p, e.scanOptions())
+		if len(candidates) == 0 {
+			fmt.Fprintf(e.stdout, "No folder found that %s may have moved to.\n", p.Path)
+			if len(e.cfg.ScanRoots) == 0 {
+				fmt.Fprintln(e.stdout, "Add the folders you keep projects in to scan_roots in the config to search them.")
+			}
+			return nil
+		}
+		fmt.Fprintf(e.stdout, "%s may have moved to:\n", p.Path)
+		for _, c := range candidates {
+			fmt.Fprintf(e.stdout, "  %s  (%s)\n", c.Path, c.Reason())
+		}
+		fmt.Fprintln(e.stdout)
+		fmt.Fprintln(e.stdout, "Run 'claude-code-switcher relocate <project> <new path>' to move its history.")
+		return nil
+	}
+
+	var diag projects.Diagnostics
+	moved, err := projects.Relocate(p, fs.Arg(1), &diag)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Moved the history of %s to %s\n", p.Path, moved.Path)
+	for _, problem := range diag.Problems {
+		fmt.Fprintf(e.stderr, "Warning: %s\n", problem)
+	}
+	// Pins, aliases, tags and the note follow the project
+	e.cfg.MoveProject(p.Path, moved.Path)
+	store := e.noteStore()
+	store.Move(p.Path, moved.Path)
+	if err := store.Save(); err != nil {
+		return err
+	}
+	return config.Save(e.cfg)
+}
+
+// findMissingProject returns the one project whose directory is missing and
+// whose name or path matches pattern. An exact path wins over partial matches.
+func findMissingProject(list []projects.Project, pattern string) (projects.Project, error) {
+	return findProject(list, pattern, "project with a missing directory", func(p projects.Project) bool {
+		return p.PathState != projects.PathFound
+	})
+}
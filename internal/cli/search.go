@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/search"
+)
+
+// searchResult is one session in the JSON output of the search command
+type searchResult struct {
+	Project string    `json:"project"`
+	Path    string    `json:"path"`
+	Session string    `json:"session"`
+	Time    time.Time `json:"time"`
+	Matches int       `json:"matches"`
+	Snippet string    `json:"snippet"`
+}
+
+// runSearch finds sessions whose messages contain every word of the query
+func runSearch(e *env, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	limit := fs.Int("limit", 20, "show at most `n` sessions, 0 for all")
+	project := fs.String("project", "", "only search projects whose name or path contains `filter`")
+	withHidden := fs.Bool("hidden", false, "include hidden projects")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher search [options] <words...>")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Finds sessions with a message containing every word, most recent first.")
+		fmt.Fprintln(e.stderr, "The last word also matches longer words it is the start of.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	query := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+
+	// The index always covers every project, so a filtered search doesn't
+	// forget the others
+	ix := search.Load(cachePath(search.IndexFileName))
+	ix.Update(list)
+	if err := ix.Save(); err != nil {
+		fmt.Fprintf(e.stderr, "search: failed to save index: %v\n", err)
+	}
+
+	list = visible(list, *withHidden)
+	if *project != "" {
+		if list, err = matchProjects(list, []string{*project}); err != nil {
+			return err
+		}
+	}
+	hits := ix.Search(list, query, *limit)
+
+	if *asJSON {
+		results := make([]searchResult, 0, len(hits))
+		for _, h := range hits {
+			results = append(results, searchResult{
+				Project: h.Project.Name,
+				Path:    h.Project.Path,
+				Session: h.SessionID,
+				Time:    h.Time,
+				Matches: h.Matches,
+				Snippet: h.Snippet,
+			})
+		}
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(hits) == 0 {
+		fmt.Fprintln(e.stdout, "No matching sessions.")
+		return nil
+	}
+	for i, h := range hits {
+		if i > 0 {
+			fmt.Fprintln(e.stdout)
+		}
+		fmt.Fprintf(e.stdout, "%s  %s  (%s)\n", h.Time.Local().Format("2006-01-02 15:04"), h.Project.Name, plural(h.Matches, "message"))
+		fmt.Fprintf(e.stdout, "  %s\n", h.Snippet)
+		fmt.Fprintf(e.stdout, "  cd %q && claude --resume %s\n", h.Project.Path, h.SessionID)
+	}
+	return nil
+}
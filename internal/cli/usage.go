@@ -0,0 +1,255 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/usage"
+)
+
+// usageRow is one line of the usage report
+type usageRow struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+	// Model is set on the rows of a project or session broken down by model
+	Model  string       `json:"model,omitempty"`
+	Tokens usage.Tokens `json:"tokens"`
+	Cost   float64      `json:"cost"`
+	// LastModel is the model that answered last in the project or session
+	LastModel string `json:"lastModel,omitempty"`
+
+	// The project or session a row of a breakdown belongs to, which rows are
+	// sorted by first
+	group       int
+	groupTokens int64
+	groupCost   float64
+}
+
+// runUsage prints token usage and cost, grouped by project, model or session
+func runUsage(e *env, args []string) error {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	by := fs.String("by", "project", "group by `project`, model or session")
+	sortBy := fs.String("sort", "tokens", "order by `tokens` or cost")
+	since := fs.String("since", "", "only count requests on or after `date` (YYYY-MM-DD, UTC)")
+	until := fs.String("until", "", "only count requests before `date` (YYYY-MM-DD, UTC)")
+	month := fs.String("month", "", "only count requests in `month` (YYYY-MM, UTC), e.g. for a monthly report")
+	byModel := fs.Bool("models", false, "break each project or session down by model")
+	top := fs.Int("top", 0, "show only the first `n` rows")
+	withHidden := fs.Bool("hidden", false, "include hidden projects")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: claude-code-switcher usage [options] [project filter...]")
+		fmt.Fprintln(e.stderr)
+		fmt.Fprintln(e.stderr, "Totals the tokens recorded in Claude Code transcripts. Filters match")
+		fmt.Fprintln(e.stderr, "project names or paths. Costs are estimates from the pricing table.")
+		fmt.Fprintln(e.stderr)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(*since, *until, *month)
+	if err != nil {
+		return err
+	}
+	if *by != "project" && *by != "model" && *by != "session" {
+		return fmt.Errorf("unknown grouping %q, want project, model or session", *by)
+	}
+	if *sortBy != "tokens" && *sortBy != "cost" {
+		return fmt.Errorf("unknown sort %q, want tokens or cost", *sortBy)
+	}
+
+	list, err := e.loadProjects()
+	if err != nil {
+		return err
+	}
+	list, err = matchProjects(visible(list, *withHidden), fs.Args())
+	if err != nil {
+		return err
+	}
+
+	cache := usage.LoadCache(cachePath(usage.CacheFileName))
+	reports := usage.ForProjects(list, cache, filter)
+	// Only a scan of every project, hidden ones included, knows which cached
+	// files are gone
+	cache.Save(len(fs.Args()) == 0 && *withHidden)
+
+	pricing := usage.DefaultPricing().With(e.cfg.Pricing)
+	rows, unpriced := usageRows(reports, *by, *byModel, pricing)
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.group != b.group {
+			if *sortBy == "cost" && a.groupCost != b.groupCost {
+				return a.groupCost > b.groupCost
+			}
+			if *sortBy != "cost" && a.groupTokens != b.groupTokens {
+				return a.groupTokens > b.groupTokens
+			}
+			return a.group < b.group
+		}
+		if *sortBy == "cost" {
+			return a.Cost > b.Cost
+		}
+		return a.Tokens.Total() > b.Tokens.Total()
+	})
+	var total usageRow
+	total.Name = "Total"
+	for _, row := range rows {
+		total.Tokens.Add(row.Tokens)
+		total.Cost += row.Cost
+	}
+	if *top > 0 && len(rows) > *top {
+		rows = rows[:*top]
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Rows     []usageRow `json:"rows"`
+			Total    usageRow   `json:"total"`
+			Unpriced []string   `json:"unpriced,omitempty"`
+		}{rows, total, unpriced})
+	}
+
+	printUsageTable(e.stdout, *by, *byModel, rows, total)
+	if len(unpriced) > 0 {
+		fmt.Fprintf(e.stdout, "\nNo price configured for: %s (counted as $0)\n", strings.Join(unpriced, ", "))
+	}
+	return nil
+}
+
+// parseFilter builds a time filter from the --since, --until and --month flags
+func parseFilter(since, until, month string) (usage.Filter, error) {
+	var filter usage.Filter
+	if month != "" {
+		if since != "" || until != "" {
+			return filter, fmt.Errorf("--month can't be combined with --since or --until")
+		}
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return filter, fmt.Errorf("invalid month %q, want YYYY-MM", month)
+		}
+		return usage.Filter{Since: start, Until: start.AddDate(0, 1, 0)}, nil
+	}
+	var err error
+	if since != "" {
+		if filter.Since, err = time.Parse("2006-01-02", since); err != nil {
+			return filter, fmt.Errorf("invalid date %q, want YYYY-MM-DD", since)
+		}
+	}
+	if until != "" {
+		if filter.Until, err = time.Parse("2006-01-02", until); err != nil {
+			return filter, fmt.Errorf("invalid date %q, want YYYY-MM-DD", until)
+		}
+	}
+	return filter, nil
+}
+
+// usageRows flattens project reports into rows for the chosen grouping,
+// dropping rows without usage, with a row per model of each project or
+// session if byModel is set. It also returns the models without a price.
+func usageRows(reports []usage.ProjectUsage, by string, byModel bool, pricing usage.Pricing) ([]usageRow, []string) {
+	var rows []usageRow
+	unpricedSet := make(map[string]bool)
+	group := 0
+	add := func(name, path, lastModel string, u usage.Usage) {
+		group++
+		cost, unpriced := u.Cost(pricing)
+		for _, model := range unpriced {
+			unpricedSet[model] = true
+		}
+		row := usageRow{Name: name, Path: path, Tokens: u.Total(), Cost: cost, LastModel: lastModel,
+			group: group, groupTokens: u.Total().Total(), groupCost: cost}
+		if !byModel || by == "model" {
+			rows = append(rows, row)
+			return
+		}
+		for _, model := range u.Models() {
+			row.Model = model
+			row.Tokens = u[model]
+			row.Cost, _ = usage.Usage{model: u[model]}.Cost(pricing)
+			rows = append(rows, row)
+		}
+	}
+
+	switch by {
+	case "model":
+		models := make(usage.Usage)
+		for _, r := range reports {
+			models.Add(r.Usage)
+		}
+		for _, model := range models.Models() {
+			add(model, "", "", usage.Usage{model: models[model]})
+		}
+	case "session":
+		for _, r := range reports {
+			for _, s := range r.Sessions {
+				add(s.ID, r.Project.Path, s.LastModel, s.Usage)
+			}
+		}
+	default:
+		for _, r := range reports {
+			if len(r.Usage) > 0 {
+				add(r.Project.DisplayName(), r.Project.Path, r.LastModel, r.Usage)
+			}
+		}
+	}
+
+	var unpriced []string
+	for model := range unpricedSet {
+		unpriced = append(unpriced, model)
+	}
+	sort.Strings(unpriced)
+	return rows, unpriced
+}
+
+// printUsageTable writes the rows as an aligned table with a total line.
+// A breakdown by model names the model instead of the last one used.
+func printUsageTable(w io.Writer, by string, byModel bool, rows []usageRow, total usageRow) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := strings.ToUpper(by[:1]) + by[1:]
+	modelHeader := "\tLast model"
+	switch {
+	case by == "model":
+		modelHeader = ""
+	case byModel:
+		modelHeader = "\tModel"
+	}
+	fmt.Fprintf(tw, "%s\tMessages\tTotal\tInput\tOutput\tCache read\tCache write\tCost%s\n", header, modelHeader)
+	line := func(row usageRow) {
+		name := row.Name
+		if by == "session" && row.Path != "" {
+			name += " (" + row.Path + ")"
+		}
+		model := ""
+		switch {
+		case by == "model":
+		case byModel:
+			model = "\t" + row.Model
+		default:
+			model = "\t" + row.LastModel
+		}
+		t := row.Tokens
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t$%.2f%s\n", name, t.Messages,
+			usage.FormatTokens(t.Total()), usage.FormatTokens(t.Input), usage.FormatTokens(t.Output),
+			usage.FormatTokens(t.CacheRead), usage.FormatTokens(t.CacheCreation), row.Cost, model)
+	}
+	for _, row := range rows {
+		line(row)
+	}
+	line(total)
+	tw.Flush()
+}
@@ -5,8 +5,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
 )
 
 // Config holds application settings persisted to disk.
@@ -18,9 +23,206 @@ type Config struct {
 	PendingVersion     string `json:"pending_version"`
 	PendingURL         string `json:"pending_url"`
 	Terminal           string `json:"terminal"`
+	// ClaudeDirs lists extra Claude data roots (CLAUDE_CONFIG_DIR values) to
+	// load projects from, in addition to the default one
+	ClaudeDirs []string `json:"claude_dirs,omitempty"`
+	// Pricing overrides the built-in model prices used for cost estimates,
+	// keyed by model name prefix
+	Pricing map[string]Price `json:"pricing,omitempty"`
+	// ScanRoots are folders searched for git repositories not yet opened in
+	// Claude Code, and for the new location of a project whose directory
+	// has moved
+	ScanRoots []string `json:"scan_roots,omitempty"`
+	// ScanDepth is how many folder levels below a scan root are searched;
+	// 0 means the default of 4
+	ScanDepth int `json:"scan_depth,omitempty"`
+	// ScanIgnore holds glob patterns of folders under the scan roots to
+	// skip, matched against the folder name and its full path
+	ScanIgnore []string `json:"scan_ignore,omitempty"`
+	// TrashRetentionDays is how long pruned project data is kept in the
+	// trash before it is deleted; 0 means the default of 30 days
+	TrashRetentionDays int `json:"trash_retention_days,omitempty"`
+	// FrecencyHalfLifeDays is how many days it takes a use of a project to
+	// count half as much in the frecency ranking; 0 means the default of 14
+	FrecencyHalfLifeDays float64 `json:"frecency_half_life_days,omitempty"`
+	// Pinned lists the paths of projects kept at the top of the list
+	Pinned []string `json:"pinned,omitempty"`
+	// Aliases are names shown instead of a project's folder name, keyed by
+	// project path
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Tags are free-form labels of projects, keyed by project path
+	Tags map[string][]string `json:"tags,omitempty"`
+	// IgnorePatterns hide the projects whose path matches them: globs, or
+	// regular expressions when prefixed with re:
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+	// Hidden lists the paths of projects hidden one by one
+	Hidden []string `json:"hidden,omitempty"`
+}
+
+// Price is what a model costs, in US dollars per million tokens
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// IsPinned reports whether the project at path is pinned
+func (c *Config) IsPinned(path string) bool {
+	return containsPath(c.Pinned, path)
+}
+
+// TogglePin pins the project at path, or unpins it if it was pinned, and
+// reports whether it is pinned now. The config isn't saved.
+func (c *Config) TogglePin(path string) bool {
+	return togglePath(&c.Pinned, path)
+}
+
+// IsHidden reports whether the project at path was hidden by itself, not
+// counting the ignore patterns
+func (c *Config) IsHidden(path string) bool {
+	return containsPath(c.Hidden, path)
 }
 
-func configDir() (string, error) {
+// ToggleHidden hides the project at path, or shows it again if it was
+// hidden, and reports whether it is hidden now. The config isn't saved.
+func (c *Config) ToggleHidden(path string) bool {
+	return togglePath(&c.Hidden, path)
+}
+
+// containsPath reports whether list holds path
+func containsPath(list []string, path string) bool {
+	for _, p := range list {
+		if fsutil.SamePath(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// togglePath removes path from list if it is there and adds it otherwise,
+// and reports whether it was added
+func togglePath(list *[]string, path string) bool {
+	for i, p := range *list {
+		if fsutil.SamePath(p, path) {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return false
+		}
+	}
+	*list = append(*list, path)
+	return true
+}
+
+// Alias returns the alias of the project at path, or "" if it has none
+func (c *Config) Alias(path string) string {
+	if key, ok := findPath(c.Aliases, path); ok {
+		return c.Aliases[key]
+	}
+	return ""
+}
+
+// SetAlias sets the alias of the project at path; an empty alias removes
+// it. The config isn't saved.
+func (c *Config) SetAlias(path, alias string) {
+	if key, ok := findPath(c.Aliases, path); ok {
+		delete(c.Aliases, key)
+	}
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return
+	}
+	if c.Aliases == nil {
+		c.Aliases = make(map[string]string)
+	}
+	c.Aliases[path] = alias
+}
+
+// ProjectTags returns the tags of the project at path
+func (c *Config) ProjectTags(path string) []string {
+	if key, ok := findPath(c.Tags, path); ok {
+		return c.Tags[key]
+	}
+	return nil
+}
+
+// SetTags replaces the tags of the project at path, dropping duplicates
+// that differ only in case; no tags removes them. The config isn't saved.
+func (c *Config) SetTags(path string, tags []string) {
+	if key, ok := findPath(c.Tags, path); ok {
+		delete(c.Tags, key)
+	}
+	var kept []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	if c.Tags == nil {
+		c.Tags = make(map[string][]string)
+	}
+	c.Tags[path] = kept
+}
+
+// MoveProject carries the pin, hide, alias and tags of the project at
+// oldPath over to newPath, after the project was moved. The config isn't
+// saved.
+func (c *Config) MoveProject(oldPath, newPath string) {
+	if c.IsPinned(oldPath) {
+		c.TogglePin(oldPath)
+		if !c.IsPinned(newPath) {
+			c.TogglePin(newPath)
+		}
+	}
+	if c.IsHidden(oldPath) {
+		c.ToggleHidden(oldPath)
+		if !c.IsHidden(newPath) {
+			c.ToggleHidden(newPath)
+		}
+	}
+	if alias := c.Alias(oldPath); alias != "" {
+		c.SetAlias(oldPath, "")
+		c.SetAlias(newPath, alias)
+	}
+	if tags := c.ProjectTags(oldPath); tags != nil {
+		c.SetTags(oldPath, nil)
+		c.SetTags(newPath, append(c.ProjectTags(newPath), tags...))
+	}
+}
+
+// ParseTags splits a list of tags separated by commas or spaces, as typed
+// by the user. A leading # is dropped.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}) {
+		if tag = strings.TrimPrefix(tag, "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// findPath returns the key of m that names the same path as path
+func findPath[V any](m map[string]V, path string) (string, bool) {
+	if _, ok := m[path]; ok {
+		return path, true
+	}
+	for key := range m {
+		if fsutil.SamePath(key, path) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// Dir returns the directory holding the switcher's own files (config, caches).
+func Dir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -29,7 +231,7 @@ func configDir() (string, error) {
 }
 
 func configPath() (string, error) {
-	dir, err := configDir()
+	dir, err := Dir()
 	if err != nil {
 		return "", err
 	}
@@ -58,9 +260,30 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// Check reports why the config file can't be used, or nil if it is fine or
+// doesn't exist. Load falls back to defaults either way.
+func Check() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
 // Save writes the config to disk, creating the directory if needed.
 func Save(cfg *Config) error {
-	dir, err := configDir()
+	dir, err := Dir()
 	if err != nil {
 		return err
 	}
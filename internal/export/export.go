@@ -0,0 +1,187 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package export renders a session transcript as Markdown, for pasting into
+// a pull request or an incident write-up, or as a self-contained HTML page.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// Formats an export can be written in
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Options controls what an export includes
+type Options struct {
+	// NoToolOutput leaves out what tools returned, keeping the calls
+	NoToolOutput bool
+	// NoThinking leaves out the model's thinking
+	NoThinking bool
+	// Location is the time zone of the timestamps, time.Local if nil
+	Location *time.Location
+}
+
+// Transcript is a session with its messages, ready to export
+type Transcript struct {
+	Project projects.Project
+	Session projects.Session
+	Turns   []projects.Turn
+}
+
+// Load reads the messages of a session of the project
+func Load(p projects.Project, s projects.Session) (Transcript, error) {
+	turns, err := projects.ReadTranscript(s.FilePath)
+	if err != nil && len(turns) == 0 {
+		return Transcript{}, err
+	}
+	return Transcript{Project: p, Session: s, Turns: turns}, nil
+}
+
+// Write renders t in the given format
+func Write(w io.Writer, format string, t Transcript, opts Options) error {
+	switch format {
+	case FormatMarkdown:
+		return Markdown(w, t, opts)
+	case FormatHTML:
+		return HTML(w, t, opts)
+	}
+	return fmt.Errorf("unknown export format %q, want %s or %s", format, FormatMarkdown, FormatHTML)
+}
+
+// Extension returns the file name extension for a format, with the dot
+func Extension(format string) string {
+	if format == FormatHTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// section is a run of turns shown under one heading: a prompt, or a
+// response together with the tool calls it made and their results
+type section struct {
+	Role   string
+	Time   time.Time
+	Model  string
+	Blocks []projects.Block
+}
+
+// sections groups the turns of t under headings, dropping what opts leaves
+// out. Tool results are named after the call they answer.
+func sections(t Transcript, opts Options) []section {
+	tools := make(map[string]string)
+	var out []section
+	for _, turn := range t.Turns {
+		var blocks []projects.Block
+		for _, b := range turn.Blocks {
+			switch {
+			case b.Type == projects.BlockThinking && opts.NoThinking:
+				continue
+			case b.Type == projects.BlockToolResult && opts.NoToolOutput:
+				continue
+			case b.Type == projects.BlockToolUse:
+				tools[b.ID] = b.Name
+			case b.Type == projects.BlockToolResult:
+				b.Name = tools[b.ID]
+			}
+			blocks = append(blocks, b)
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		// Tool results belong with the response that asked for them, and a
+		// response continued after them stays under the same heading
+		onlyResults := turn.Role == "user" && allResults(blocks)
+		if n := len(out); n > 0 && out[n-1].Role == "assistant" && (onlyResults || turn.Role == "assistant") {
+			out[n-1].Blocks = append(out[n-1].Blocks, blocks...)
+			continue
+		}
+		role := turn.Role
+		if onlyResults {
+			role = "assistant"
+		}
+		out = append(out, section{Role: role, Time: turn.Time, Model: turn.Model, Blocks: blocks})
+	}
+	return out
+}
+
+// allResults reports whether every block is a tool result
+func allResults(blocks []projects.Block) bool {
+	for _, b := range blocks {
+		if b.Type != projects.BlockToolResult {
+			return false
+		}
+	}
+	return true
+}
+
+// heading returns the title of a section, e.g. "Claude (claude-sonnet-4-5)"
+func (s section) heading() string {
+	if s.Role == "user" {
+		return "User"
+	}
+	if s.Model != "" {
+		return "Claude (" + s.Model + ")"
+	}
+	return "Claude"
+}
+
+// formatTime formats a timestamp for a heading, or "" if it is unknown
+func formatTime(t time.Time, opts Options) string {
+	if t.IsZero() {
+		return ""
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
+
+// title returns the title of the export
+func (t Transcript) title() string {
+	if title := t.Session.Title(); title != "" {
+		return title
+	}
+	return "Claude Code session"
+}
+
+// toolInput returns the arguments of a tool call, indented
+func toolInput(b projects.Block) string {
+	var out bytes.Buffer
+	if json.Indent(&out, b.Input, "", "  ") != nil {
+		return string(b.Input)
+	}
+	return out.String()
+}
+
+// toolSummaryFields are the arguments that best describe a tool call, in
+// order of preference
+var toolSummaryFields = []string{"description", "command", "file_path", "path", "pattern", "url", "query", "prompt"}
+
+// toolSummary describes a tool call on one line, e.g. "Bash: Run the tests"
+func toolSummary(b projects.Block) string {
+	var args map[string]any
+	json.Unmarshal(b.Input, &args)
+	for _, field := range toolSummaryFields {
+		if s, ok := args[field].(string); ok && strings.TrimSpace(s) != "" {
+			s = strings.Join(strings.Fields(s), " ")
+			if r := []rune(s); len(r) > 80 {
+				s = string(r[:80]) + "..."
+			}
+			return b.Name + ": " + s
+		}
+	}
+	return b.Name
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// testTranscript is a short session: a prompt, a response that runs a
+// failing command and one that reads a file, and a final answer
+func testTranscript() Transcript {
+	at := func(sec int) time.Time { return time.Date(2026, 3, 1, 9, 0, sec, 0, time.UTC) }
+	model := "claude-sonnet-4-5"
+	return Transcript{
+		Project: projects.Project{Path: "/work/api"},
+		Session: projects.Session{ID: "7f3c9e21-aaaa", Summary: "Fix the <checkout> tests", Started: at(0), Ended: at(9)},
+		Turns: []projects.Turn{
+			{Role: "user", Time: at(0), Blocks: []projects.Block{{Type: projects.BlockText, Text: "Why do the checkout tests fail?"}}},
+			{Role: "assistant", Time: at(2), Model: model, Blocks: []projects.Block{
+				{Type: projects.BlockThinking, Text: "Run them first."},
+				{Type: projects.BlockToolUse, ID: "t1", Name: "Bash", Input: json.RawMessage(`{"command":"go test ./checkout","description":"Run the checkout tests"}`)},
+			}},
+			{Role: "user", Time: at(4), Blocks: []projects.Block{{Type: projects.BlockToolResult, ID: "t1", Text: "FAIL: ```total``` < 0", IsError: true}}},
+			{Role: "assistant", Time: at(5), Model: model, Blocks: []projects.Block{
+				{Type: projects.BlockToolUse, ID: "t2", Name: "Read", Input: json.RawMessage(`{"file_path":"checkout/cart.go"}`)},
+			}},
+			{Role: "user", Time: at(6), Blocks: []projects.Block{{Type: projects.BlockToolResult, ID: "t2", Text: "func Total() int"}}},
+			{Role: "assistant", Time: at(9), Model: model, Blocks: []projects.Block{{Type: projects.BlockText, Text: "The total goes negative."}}},
+		},
+	}
+}
+
+func TestSections(t *testing.T) {
+	s := sections(testTranscript(), Options{})
+	if len(s) != 2 {
+		t.Fatalf("got %d sections, want the prompt and one response: %+v", len(s), s)
+	}
+	if s[0].heading() != "User" || s[1].heading() != "Claude (claude-sonnet-4-5)" {
+		t.Errorf("headings = %q, %q", s[0].heading(), s[1].heading())
+	}
+	if n := len(s[1].Blocks); n != 6 {
+		t.Errorf("response has %d blocks, want 6", n)
+	}
+	if b := s[1].Blocks[2]; b.Type != projects.BlockToolResult || b.Name != "Bash" {
+		t.Errorf("result block = %+v, want one named after its call", b)
+	}
+
+	s = sections(testTranscript(), Options{NoToolOutput: true, NoThinking: true})
+	for _, b := range s[1].Blocks {
+		if b.Type == projects.BlockToolResult || b.Type == projects.BlockThinking {
+			t.Errorf("block %q left in with the options set", b.Type)
+		}
+	}
+	if n := len(s[1].Blocks); n != 3 {
+		t.Errorf("response has %d blocks, want the two calls and the answer", n)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Markdown(&buf, testTranscript(), Options{Location: time.UTC}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# Fix the <checkout> tests\n",
+		"- Project: `/work/api`\n",
+		"- Started: 2026-03-01 09:00:00\n",
+		"## User · 2026-03-01 09:00:00\n\nWhy do the checkout tests fail?\n",
+		"## Claude (claude-sonnet-4-5) · 2026-03-01 09:00:02\n",
+		"> Run them first.\n",
+		"**Tool call** `Bash: Run the checkout tests`\n\n```json\n{\n  \"command\": \"go test ./checkout\",",
+		"**Output of Bash** (error)\n\n````\nFAIL: ```total``` < 0\n````\n",
+		"**Tool call** `Read: checkout/cart.go`",
+		"The total goes negative.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown() is missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	Markdown(&buf, testTranscript(), Options{Location: time.UTC, NoToolOutput: true, NoThinking: true})
+	out = buf.String()
+	if strings.Contains(out, "Output of") || strings.Contains(out, "Run them first") {
+		t.Errorf("Markdown() with the options set still has tool output or thinking:\n%s", out)
+	}
+	if !strings.Contains(out, "**Tool call** `Bash: Run the checkout tests`") {
+		t.Errorf("Markdown() with NoToolOutput dropped the tool call:\n%s", out)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := HTML(&buf, testTranscript(), Options{Location: time.UTC}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"<title>Fix the &lt;checkout&gt; tests</title>",
+		"<h2>User<time>2026-03-01 09:00:00</time></h2>",
+		"<details class=\"thinking\"><summary>Thinking</summary>",
+		"<details class=\"tool\"><summary>Tool call: Bash: Run the checkout tests</summary><pre>{\n  &#34;command&#34;",
+		"<details class=\"result error\"><summary>Output of Bash (error)</summary><pre>FAIL: ```total``` &lt; 0</pre></details>",
+		"<div class=\"text\">The total goes negative.</div>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML() is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<checkout>") {
+		t.Error("HTML() left text unescaped")
+	}
+
+	buf.Reset()
+	HTML(&buf, testTranscript(), Options{Location: time.UTC, NoToolOutput: true, NoThinking: true})
+	if out := buf.String(); strings.Contains(out, "class=\"result") || strings.Contains(out, "class=\"thinking\">") {
+		t.Errorf("HTML() with the options set still has tool output or thinking:\n%s", out)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "pdf", testTranscript(), Options{}); err == nil {
+		t.Error("Write() accepted an unknown format")
+	}
+	if Extension(FormatHTML) != ".html" || Extension(FormatMarkdown) != ".md" {
+		t.Errorf("Extension() = %q, %q", Extension(FormatHTML), Extension(FormatMarkdown))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// htmlStyle is the style sheet of an HTML export, inline so that the page is
+// a single file that can be mailed or attached
+const htmlStyle = `body{font-family:system-ui,-apple-system,"Segoe UI",sans-serif;max-width:60rem;margin:2rem auto;padding:0 1rem;color:#222;line-height:1.5}
+h1{font-size:1.4rem}
+.meta{color:#666;font-size:.9rem}
+.meta code{font-size:.85rem}
+section{border-top:1px solid #ddd;padding:.5rem 0}
+h2{font-size:1rem;margin:.5rem 0}
+h2 time{color:#888;font-weight:normal;font-size:.85rem;margin-left:.5rem}
+section.user h2{color:#1a5fb4}
+section.assistant h2{color:#a04000}
+.text{white-space:pre-wrap;overflow-wrap:anywhere}
+details{margin:.4rem 0;border:1px solid #e2e2e2;border-radius:4px;background:#fafafa}
+summary{cursor:pointer;padding:.2rem .5rem;font-size:.9rem;color:#444}
+details pre{margin:0;padding:.5rem;overflow:auto;font-size:.85rem;border-top:1px solid #e2e2e2;white-space:pre-wrap;overflow-wrap:anywhere}
+details.thinking .text{padding:.5rem;color:#555;font-style:italic}
+details.error{border-color:#e0a0a0;background:#fff5f5}
+details.error summary{color:#a01010}
+`
+
+// HTML writes t as a self-contained HTML page. Tool calls, their output and
+// thinking are collapsed, so the conversation reads top to bottom.
+func HTML(w io.Writer, t Transcript, opts Options) error {
+	bw := bufio.NewWriter(w)
+	esc := html.EscapeString
+	title := oneLine(t.title())
+
+	bw.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(bw, "<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n", esc(title), htmlStyle)
+	fmt.Fprintf(bw, "<h1>%s</h1>\n<p class=\"meta\">", esc(title))
+	if t.Project.Path != "" {
+		fmt.Fprintf(bw, "Project <code>%s</code><br>\n", esc(t.Project.Path))
+	}
+	fmt.Fprintf(bw, "Session <code>%s</code>", esc(t.Session.ID))
+	if started := formatTime(t.Session.Started, opts); started != "" {
+		fmt.Fprintf(bw, "<br>\nStarted %s", esc(started))
+	}
+	if ended := formatTime(t.Session.Ended, opts); ended != "" {
+		fmt.Fprintf(bw, "<br>\nEnded %s", esc(ended))
+	}
+	bw.WriteString("</p>\n")
+
+	for _, s := range sections(t, opts) {
+		fmt.Fprintf(bw, "<section class=\"%s\">\n<h2>%s", s.Role, esc(s.heading()))
+		if at := formatTime(s.Time, opts); at != "" {
+			fmt.Fprintf(bw, "<time>%s</time>", esc(at))
+		}
+		bw.WriteString("</h2>\n")
+		for _, b := range s.Blocks {
+			switch b.Type {
+			case projects.BlockText:
+				fmt.Fprintf(bw, "<div class=\"text\">%s</div>\n", esc(strings.TrimSpace(b.Text)))
+			case projects.BlockThinking:
+				fmt.Fprintf(bw, "<details class=\"thinking\"><summary>Thinking</summary><div class=\"text\">%s</div></details>\n",
+					esc(strings.TrimSpace(b.Text)))
+			case projects.BlockToolUse:
+				fmt.Fprintf(bw, "<details class=\"tool\"><summary>Tool call: %s</summary><pre>%s</pre></details>\n",
+					esc(toolSummary(b)), esc(toolInput(b)))
+			case projects.BlockToolResult:
+				class, label := "result", "Output"
+				if b.Name != "" {
+					label = "Output of " + b.Name
+				}
+				if b.IsError {
+					class, label = "result error", label+" (error)"
+				}
+				fmt.Fprintf(bw, "<details class=\"%s\"><summary>%s</summary><pre>%s</pre></details>\n",
+					class, esc(label), esc(strings.TrimRight(b.Text, "\n")))
+			}
+		}
+		bw.WriteString("</section>\n")
+	}
+	bw.WriteString("</body>\n</html>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// Markdown writes t as Markdown. Messages are kept as written, since they
+// are mostly Markdown already; tool calls and their output are code blocks.
+func Markdown(w io.Writer, t Transcript, opts Options) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", oneLine(t.title()))
+	if t.Project.Path != "" {
+		fmt.Fprintf(bw, "- Project: `%s`\n", t.Project.Path)
+	}
+	fmt.Fprintf(bw, "- Session: `%s`\n", t.Session.ID)
+	if started := formatTime(t.Session.Started, opts); started != "" {
+		fmt.Fprintf(bw, "- Started: %s\n", started)
+	}
+	if ended := formatTime(t.Session.Ended, opts); ended != "" {
+		fmt.Fprintf(bw, "- Ended: %s\n", ended)
+	}
+
+	for _, s := range sections(t, opts) {
+		heading := s.heading()
+		if at := formatTime(s.Time, opts); at != "" {
+			heading += " · " + at
+		}
+		fmt.Fprintf(bw, "\n## %s\n", heading)
+		for _, b := range s.Blocks {
+			bw.WriteString("\n")
+			switch b.Type {
+			case projects.BlockText:
+				fmt.Fprintf(bw, "%s\n", strings.TrimSpace(b.Text))
+			case projects.BlockThinking:
+				bw.WriteString("> *Thinking*\n>\n")
+				for _, line := range strings.Split(strings.TrimSpace(b.Text), "\n") {
+					fmt.Fprintf(bw, "> %s\n", line)
+				}
+			case projects.BlockToolUse:
+				fmt.Fprintf(bw, "**Tool call** %s\n\n", inlineCode(toolSummary(b)))
+				bw.WriteString(codeBlock(toolInput(b), "json"))
+			case projects.BlockToolResult:
+				label := "**Output**"
+				if b.Name != "" {
+					label = "**Output of " + b.Name + "**"
+				}
+				if b.IsError {
+					label += " (error)"
+				}
+				fmt.Fprintf(bw, "%s\n\n", label)
+				bw.WriteString(codeBlock(b.Text, ""))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// codeBlock fences text so that backticks in it can't end the block early
+func codeBlock(text, lang string) string {
+	fence := strings.Repeat("`", max(3, longestRun(text, '`')+1))
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n"
+}
+
+// inlineCode wraps text in backticks, enough of them to hold any in text
+func inlineCode(text string) string {
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// longestRun returns the length of the longest run of c in text
+func longestRun(text string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// oneLine joins the lines of text with spaces
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package frecency ranks projects by how often and how recently they were
+// used, from their session timestamps and the switcher's launch history.
+package frecency
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// DefaultHalfLife is how long it takes a use to count half as much, unless
+// configured otherwise
+const DefaultHalfLife = 14 * 24 * time.Hour
+
+// HalfLife returns the half-life for a configured number of days, or
+// DefaultHalfLife if it isn't set
+func HalfLife(days float64) time.Duration {
+	if days <= 0 {
+		return DefaultHalfLife
+	}
+	return time.Duration(days * float64(24*time.Hour))
+}
+
+// Score adds up uses that decay exponentially with age: a use now counts 1,
+// one half-life ago 0.5, and so on. Uses in the future count as now.
+func Score(uses []time.Time, now time.Time, halfLife time.Duration) float64 {
+	var score float64
+	for _, t := range uses {
+		age := now.Sub(t)
+		if age < 0 {
+			age = 0
+		}
+		score += math.Exp2(-float64(age) / float64(halfLife))
+	}
+	return score
+}
+
+// Scores returns the frecency of every project, keyed by fsutil.PathKey of
+// its path. Each session counts as a use at the time it ended, and each
+// launch from the switcher as a use of its own.
+func Scores(list []projects.Project, history *History, now time.Time, halfLife time.Duration) map[string]float64 {
+	scores := make(map[string]float64, len(list))
+	var mu sync.Mutex
+	jobs := make(chan projects.Project)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				// A project without readable sessions still has its launches
+				uses := append(sessionEnds(p), history.Launches(p.Path)...)
+				score := Score(uses, now, halfLife)
+
+				mu.Lock()
+				scores[fsutil.PathKey(p.Path)] += score
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range list {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+	return scores
+}
+
+// sessionEnds returns when each session of a project ended. A transcript is
+// written to until its session ends, so that is its modification time; this
+// takes a directory listing rather than reading every transcript.
+func sessionEnds(p projects.Project) []time.Time {
+	dirs, err := p.DataDirs()
+	if err != nil {
+		return nil
+	}
+	// A session copied between data folders is one use, at its latest end
+	ends := make(map[string]time.Time)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") || strings.HasPrefix(name, "agent-") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.Size() == 0 {
+				continue
+			}
+			if id := strings.TrimSuffix(name, ".jsonl"); info.ModTime().After(ends[id]) {
+				ends[id] = info.ModTime()
+			}
+		}
+	}
+	uses := make([]time.Time, 0, len(ends))
+	for _, t := range ends {
+		uses = append(uses, t)
+	}
+	return uses
+}
+
+// Sort orders projects by score, highest first, breaking ties by recency
+func Sort(list []projects.Project, scores map[string]float64) {
+	projects.SortByLastUsed(list)
+	sort.SliceStable(list, func(i, j int) bool {
+		return scores[fsutil.PathKey(list[i].Path)] > scores[fsutil.PathKey(list[j].Path)]
+	})
+}
+
+// HistoryFileName is the name of the launch history in the switcher's
+// config directory
+const HistoryFileName = "history.json"
+
+// maxLaunches caps the launch history; older launches barely count anyway
+const maxLaunches = 1000
+
+// History is the switcher's record of the projects it launched
+type History struct {
+	Entries []Launch `json:"launches"`
+
+	path string
+}
+
+// Launch is one project opened from the switcher
+type Launch struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+}
+
+// LoadHistory reads the launch history at path. A missing or unreadable
+// file yields an empty history; an empty path yields one that is never saved.
+func LoadHistory(path string) *History {
+	h := &History{path: path}
+	if path == "" {
+		return h
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	json.Unmarshal(data, h)
+	return h
+}
+
+// Launches returns the times the project at path was launched
+func (h *History) Launches(path string) []time.Time {
+	key := fsutil.PathKey(path)
+	var times []time.Time
+	for _, l := range h.Entries {
+		if fsutil.PathKey(l.Path) == key {
+			times = append(times, l.Time)
+		}
+	}
+	return times
+}
+
+// Record adds a launch of the project at path and saves the history
+func (h *History) Record(path string, t time.Time) error {
+	h.Entries = append(h.Entries, Launch{Path: path, Time: t})
+	if len(h.Entries) > maxLaunches {
+		h.Entries = h.Entries[len(h.Entries)-maxLaunches:]
+	}
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(h.path, data)
+}
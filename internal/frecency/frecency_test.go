@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package frecency
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+func TestScore(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name string
+		uses []time.Time
+		want float64
+	}{
+		{"none", nil, 0},
+		{"now", []time.Time{now}, 1},
+		{"one half-life ago", []time.Time{now.Add(-14 * day)}, 0.5},
+		{"future counts as now", []time.Time{now.Add(day)}, 1},
+		{"adds up", []time.Time{now, now.Add(-14 * day), now.Add(-28 * day)}, 1.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.uses, now, DefaultHalfLife)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHalfLife(t *testing.T) {
+	if got := HalfLife(0); got != DefaultHalfLife {
+		t.Errorf("HalfLife(0) = %v, want %v", got, DefaultHalfLife)
+	}
+	if got := HalfLife(1.5); got != 36*time.Hour {
+		t.Errorf("HalfLife(1.5) = %v, want 36h", got)
+	}
+}
+
+func TestScores(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	root := t.TempDir()
+	dir := filepath.Join(root, "projects", "-work-app")
+	os.MkdirAll(dir, 0755)
+	// Sessions count at their transcript's modification time; empty and
+	// sub-agent transcripts don't count
+	for name, age := range map[string]time.Duration{"s1.jsonl": 0, "s2.jsonl": 14 * 24 * time.Hour, "agent-a1.jsonl": 0} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("{}\n"), 0644)
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+	os.WriteFile(filepath.Join(dir, "empty.jsonl"), nil, 0644)
+	app := projects.Project{Path: "/work/app", Root: root, EncodedDir: "-work-app", EncodedDirs: []string{"-work-app"}}
+
+	history := LoadHistory("")
+	history.Record("/work/app", now)
+	scores := Scores([]projects.Project{app}, history, now, DefaultHalfLife)
+	if got := scores[fsutil.PathKey("/work/app")]; math.Abs(got-2.5) > 1e-6 {
+		t.Errorf("Scores() = %v, want 2.5 for two sessions and a launch", got)
+	}
+}
+
+func TestSort(t *testing.T) {
+	now := time.Now()
+	list := []projects.Project{
+		{Name: "recent", Path: "/work/recent", LastUsed: now},
+		{Name: "frequent", Path: "/work/frequent", LastUsed: now.Add(-time.Hour)},
+		{Name: "unused", Path: "/work/unused", LastUsed: now.Add(-2 * time.Hour)},
+		{Name: "tie", Path: "/work/tie", LastUsed: now.Add(-time.Minute)},
+	}
+	scores := map[string]float64{
+		fsutil.PathKey("/work/recent"):   1,
+		fsutil.PathKey("/work/frequent"): 3,
+		fsutil.PathKey("/work/tie"):      1,
+	}
+	Sort(list, scores)
+
+	want := []string{"frequent", "recent", "tie", "unused"}
+	for i, name := range want {
+		if list[i].Name != name {
+			t.Errorf("position %d = %s, want %s", i, list[i].Name, name)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "switcher", HistoryFileName)
+	launched := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	h := LoadHistory(path)
+	if err := h.Record("/work/app", launched); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	h.Record("/work/other", launched)
+
+	h = LoadHistory(path)
+	got := h.Launches("/work/app/")
+	if len(got) != 1 || !got[0].Equal(launched) {
+		t.Errorf("Launches() = %v, want [%v]", got, launched)
+	}
+
+	for i := 0; i < maxLaunches; i++ {
+		h.Record("/work/other", launched)
+	}
+	if got := LoadHistory(path).Launches("/work/app"); len(got) != 0 {
+		t.Errorf("oldest launch kept past the cap: %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package fsutil holds the file system helpers shared by the other packages:
+// comparing paths the way the file system does, and replacing files so that
+// readers never see them half written.
+package fsutil
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// PathKey returns a key that is the same for every spelling of a path: it
+// is cleaned, and lowercased on Windows, whose file systems ignore case.
+func PathKey(path string) string {
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// SamePath reports whether a and b are spellings of the same path
+func SamePath(a, b string) bool {
+	return PathKey(a) == PathKey(b)
+}
+
+// WriteFile replaces the file at path with data atomically, creating its
+// directory if needed
+func WriteFile(path string, data []byte) error {
+	return WriteFileFunc(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteFileFunc replaces the file at path atomically with what write writes
+// to it, creating its directory if needed. The content goes to a temporary
+// file next to path, which is renamed over it once complete, so a concurrent
+// reader sees the old file or the new one. On error path is left as it was.
+func WriteFileFunc(path string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	bw := bufio.NewWriter(f)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
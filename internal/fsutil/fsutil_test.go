@@ -0,0 +1,55 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPathKey(t *testing.T) {
+	if PathKey("/work/app/") != PathKey("/work/./app") {
+		t.Error("PathKey() differs for two spellings of a path")
+	}
+	if PathKey("/work/app") == PathKey("/work/other") {
+		t.Error("PathKey() is the same for different paths")
+	}
+	if got := SamePath("/Work/App", "/work/app"); got != (runtime.GOOS == "windows") {
+		t.Errorf("SamePath() across case = %v on %s", got, runtime.GOOS)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "switcher")
+	path := filepath.Join(dir, "data.json")
+
+	if err := WriteFile(path, []byte("first")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := WriteFile(path, []byte("second")); err != nil {
+		t.Fatalf("WriteFile() over an existing file error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Errorf("file = %q, want second", data)
+	}
+
+	// A failed write leaves the old file and no temporary one
+	err := WriteFileFunc(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("encoding failed")
+	})
+	if err == nil {
+		t.Error("WriteFileFunc() hid the error of write")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Errorf("file after a failed write = %q, want second", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files after a failed write, want 1", len(entries))
+	}
+}
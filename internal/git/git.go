@@ -0,0 +1,343 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package git reads the state of a git checkout straight from its .git
+// directory, without running git. It understands loose and packed refs and
+// objects, linked worktrees and the index, which is enough to show a
+// project's branch, upstream distance and whether it has local changes.
+//
+// Read only looks at a few small files. The upstream distance and local
+// changes take a walk of the history and the working tree, so ReadStatus
+// finds them separately, for callers to do in the background.
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository indicates that no .git was found for a directory
+var ErrNotRepository = errors.New("not a git repository")
+
+// Info describes the state of a git checkout
+type Info struct {
+	Branch    string // Current branch, empty when HEAD is detached
+	Detached  bool   // HEAD points at a commit rather than a branch
+	Head      string // Commit HEAD points at, empty in a repository without commits
+	Upstream  string // Upstream of the current branch, e.g. origin/main
+	RemoteURL string // URL of the origin remote
+
+	// WorkTree is the top of the working tree. For a linked worktree
+	// (git worktree add), MainWorkTree is the main checkout it was created
+	// from; it is empty for a main checkout.
+	WorkTree     string
+	MainWorkTree string
+
+	// Status is unknown until set from ReadStatus
+	Status
+
+	repo       *repo
+	upstreamID string // Commit Upstream points at
+}
+
+// Status is the part of the state of a checkout that is slow to find out
+type Status struct {
+	Checked bool // Set by ReadStatus; the zero Status knows nothing
+
+	// Ahead and Behind count the commits on the branch that are not on
+	// Upstream and the other way round. AheadBehindKnown is false without
+	// an upstream, or if the histories couldn't be compared, e.g. because
+	// they are too long.
+	Ahead            int
+	Behind           int
+	AheadBehindKnown bool
+
+	// Dirty reports tracked files that are modified, deleted or unmerged.
+	// Untracked files and changes that are staged but match the working tree
+	// are not detected. DirtyKnown is false if the check was skipped because
+	// the index is too large or couldn't be read.
+	Dirty      bool
+	DirtyKnown bool
+}
+
+// Label returns a short description of the checkout for display, e.g.
+// "main*", "main ↑2 ↓1" or "detached@1a2b3c4". A distance from the upstream
+// that couldn't be found is shown as "↑↓?".
+func (i *Info) Label() string {
+	if i == nil {
+		return ""
+	}
+	label := i.Branch
+	if i.Detached {
+		label = "detached"
+		if len(i.Head) >= 7 {
+			label += "@" + i.Head[:7]
+		}
+	}
+	if i.Dirty {
+		label += "*"
+	}
+	if i.Checked && i.Upstream != "" && !i.AheadBehindKnown {
+		return label + " ↑↓?"
+	}
+	if i.Ahead > 0 {
+		label += fmt.Sprintf(" ↑%d", i.Ahead)
+	}
+	if i.Behind > 0 {
+		label += fmt.Sprintf(" ↓%d", i.Behind)
+	}
+	return label
+}
+
+// repo holds the locations that make up a repository. For a linked worktree
+// gitDir is the worktree's private directory (HEAD, index) and commonDir the
+// main repository's .git (refs, objects, config); otherwise they are equal.
+type repo struct {
+	workTree  string
+	gitDir    string
+	commonDir string
+	hashLen   int // Object ID length in bytes: 20 for SHA-1, 32 for SHA-256
+}
+
+// Read returns the git state of the checkout containing dir, without its
+// Status. Parent directories are searched for .git the way git itself does.
+func Read(dir string) (*Info, error) {
+	r, err := openRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+	cfg := readConfig(filepath.Join(r.commonDir, "config"))
+	if cfg["extensions.objectformat"] == "sha256" {
+		r.hashLen = 32
+	}
+
+	info := &Info{
+		RemoteURL:    cfg["remote.origin.url"],
+		WorkTree:     r.workTree,
+		MainWorkTree: r.mainWorkTree(),
+		repo:         r,
+	}
+
+	head, err := readFirstLine(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return nil, err
+	}
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		info.Branch = strings.TrimPrefix(ref, "refs/heads/")
+		info.Head, _ = r.resolveRef(ref)
+	} else {
+		info.Detached = true
+		info.Head = head
+	}
+
+	if info.Branch != "" && info.Head != "" {
+		if upstreamRef, name := upstreamOf(cfg, info.Branch); upstreamRef != "" {
+			info.Upstream = name
+			info.upstreamID, _ = r.resolveRef(upstreamRef)
+		}
+	}
+	return info, nil
+}
+
+// openRepo finds the repository whose working tree contains dir
+func openRepo(dir string) (*repo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			r := &repo{workTree: dir, gitDir: dotGit, hashLen: 20}
+			if !info.IsDir() {
+				// A linked worktree or submodule: .git is a file naming the real directory
+				line, err := readFirstLine(dotGit)
+				if err != nil {
+					return nil, err
+				}
+				target, ok := strings.CutPrefix(line, "gitdir: ")
+				if !ok {
+					return nil, ErrNotRepository
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(dir, target)
+				}
+				r.gitDir = filepath.Clean(target)
+			}
+			r.commonDir = r.gitDir
+			if common, err := readFirstLine(filepath.Join(r.gitDir, "commondir")); err == nil {
+				if !filepath.IsAbs(common) {
+					common = filepath.Join(r.gitDir, common)
+				}
+				r.commonDir = filepath.Clean(common)
+			}
+			return r, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+// mainWorkTree returns the main checkout of a linked worktree, or "" if r
+// is not one. A worktree's private directory is .git/worktrees/<name> inside
+// the main repository and names it through its commondir file.
+func (r *repo) mainWorkTree() string {
+	if r.gitDir == r.commonDir {
+		return ""
+	}
+	if filepath.Base(r.commonDir) == ".git" {
+		return filepath.Dir(r.commonDir)
+	}
+	// Worktrees of a bare repository have no main checkout; the repository
+	// itself ties them together
+	return r.commonDir
+}
+
+// resolveRef returns the object ID a ref points at, following symbolic refs
+func (r *repo) resolveRef(ref string) (string, error) {
+	for depth := 0; depth < 5; depth++ {
+		// Per-worktree refs live in gitDir, shared ones in commonDir
+		var value string
+		var err error
+		for _, dir := range []string{r.gitDir, r.commonDir} {
+			if value, err = readFirstLine(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return r.packedRef(ref)
+		}
+		next, symbolic := strings.CutPrefix(value, "ref: ")
+		if !symbolic {
+			return value, nil
+		}
+		ref = next
+	}
+	return "", fmt.Errorf("too many levels of symbolic refs: %s", ref)
+}
+
+// packedRef looks a ref up in packed-refs
+func (r *repo) packedRef(ref string) (string, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("ref not found: %s", ref)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Comments and peeled tag lines (^<id>) are skipped
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		id, name, ok := strings.Cut(line, " ")
+		if ok && name == ref {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("ref not found: %s", ref)
+}
+
+// upstreamOf returns the ref tracking branch's upstream and its display name
+func upstreamOf(cfg map[string]string, branch string) (string, string) {
+	remote := cfg["branch."+branch+".remote"]
+	merge := cfg["branch."+branch+".merge"]
+	if remote == "" || merge == "" {
+		return "", ""
+	}
+	name := strings.TrimPrefix(merge, "refs/heads/")
+	if remote == "." {
+		// Tracking another local branch
+		return merge, name
+	}
+	return "refs/remotes/" + remote + "/" + name, remote + "/" + name
+}
+
+// readConfig parses a git config file into a map keyed like git config
+// --list: section names and keys are lower-cased, subsections are kept as
+// written. Includes are not followed. A missing file yields an empty map.
+func readConfig(path string) map[string]string {
+	cfg := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.LastIndexByte(line, ']')
+			if end < 0 {
+				continue
+			}
+			header := line[1:end]
+			if name, sub, ok := strings.Cut(header, " "); ok {
+				section = strings.ToLower(name) + "." + strings.Trim(strings.TrimSpace(sub), `"`)
+			} else {
+				section = strings.ToLower(header)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			// A bare key is a boolean true
+			key, value = line, "true"
+		}
+		cfg[section+"."+strings.ToLower(strings.TrimSpace(key))] = configValue(value)
+	}
+	return cfg
+}
+
+// configValue strips comments and quotes from a raw config value
+func configValue(raw string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(raw[i])
+			}
+		case (c == '#' || c == ';') && !quoted:
+			return strings.TrimSpace(b.String())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// readFirstLine returns the first line of a small file, trimmed
+func readFirstLine(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(line), nil
+}
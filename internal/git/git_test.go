@@ -0,0 +1,246 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runGit runs git in dir for building test repositories
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL="+os.DevNull, "GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes a file and commits it
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", "update "+name)
+}
+
+// newTestRepo creates an upstream repository with a few commits and a clone
+// of it, returning the clone
+func newTestRepo(t *testing.T) (upstream, clone string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	upstream = filepath.Join(t.TempDir(), "upstream")
+	os.MkdirAll(upstream, 0755)
+	runGit(t, upstream, "init", "-q", "-b", "main")
+	for i := 0; i < 3; i++ {
+		commitFile(t, upstream, "a.txt", strings.Repeat("line\n", i+1))
+	}
+
+	clone = filepath.Join(t.TempDir(), "clone")
+	runGit(t, filepath.Dir(clone), "clone", "-q", upstream, clone)
+	return upstream, clone
+}
+
+// readWithStatus reads dir and its status, without reusing earlier checks
+func readWithStatus(t *testing.T, dir string) *Info {
+	t.Helper()
+	info, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	statusCache.Lock()
+	statusCache.dirty = make(map[string]dirtyCheck)
+	statusCache.Unlock()
+	info.Status = info.ReadStatus()
+	return info
+}
+
+func TestRead(t *testing.T) {
+	upstream, clone := newTestRepo(t)
+
+	info, err := Read(clone)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if info.Branch != "main" || info.Detached {
+		t.Errorf("Branch = %q, Detached = %v, want main on a branch", info.Branch, info.Detached)
+	}
+	if want := runGit(t, clone, "rev-parse", "HEAD"); info.Head != want {
+		t.Errorf("Head = %q, want %q", info.Head, want)
+	}
+	if info.Upstream != "origin/main" {
+		t.Errorf("Upstream = %q, want origin/main", info.Upstream)
+	}
+	if info.RemoteURL != upstream {
+		t.Errorf("RemoteURL = %q, want %q", info.RemoteURL, upstream)
+	}
+	if info.Checked {
+		t.Error("Read() checked the status, which is left to ReadStatus")
+	}
+	if st := info.ReadStatus(); st.Dirty || !st.DirtyKnown || !st.AheadBehindKnown {
+		t.Errorf("fresh clone ReadStatus() = %+v, want clean and level with upstream", st)
+	}
+
+	// Subdirectories belong to the same checkout
+	os.MkdirAll(filepath.Join(clone, "sub", "dir"), 0755)
+	if sub, err := Read(filepath.Join(clone, "sub", "dir")); err != nil || sub.Branch != "main" {
+		t.Errorf("Read(subdir) = %+v, %v, want branch main", sub, err)
+	}
+}
+
+func TestReadAheadBehind(t *testing.T) {
+	upstream, clone := newTestRepo(t)
+
+	commitFile(t, clone, "local.txt", "1")
+	commitFile(t, clone, "local.txt", "2")
+	commitFile(t, upstream, "remote.txt", "1")
+	runGit(t, clone, "fetch", "-q")
+
+	// Packed refs and objects must read the same as loose ones
+	for _, stage := range []string{"loose", "packed"} {
+		if stage == "packed" {
+			runGit(t, clone, "gc", "-q")
+		}
+		info := readWithStatus(t, clone)
+		if info.Ahead != 2 || info.Behind != 1 {
+			t.Errorf("%s: Ahead, Behind = %d, %d, want 2, 1", stage, info.Ahead, info.Behind)
+		}
+		if got := info.Label(); got != "main ↑2 ↓1" {
+			t.Errorf("%s: Label() = %q, want %q", stage, got, "main ↑2 ↓1")
+		}
+	}
+}
+
+func TestReadDirty(t *testing.T) {
+	_, clone := newTestRepo(t)
+	file := filepath.Join(clone, "a.txt")
+
+	// Touching a file without changing it is not a change
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(file, later, later)
+	if info := readWithStatus(t, clone); info.Dirty {
+		t.Error("touched file reported as dirty")
+	}
+
+	os.WriteFile(file, []byte("changed, same\n"), 0644)
+	if info := readWithStatus(t, clone); !info.Dirty {
+		t.Error("modified file not reported as dirty")
+	}
+	if info := readWithStatus(t, clone); info.Label() != "main*" {
+		t.Errorf("Label() = %q, want main*", info.Label())
+	}
+
+	runGit(t, clone, "checkout", "-q", "a.txt")
+	os.Remove(file)
+	if info := readWithStatus(t, clone); !info.Dirty {
+		t.Error("deleted file not reported as dirty")
+	}
+}
+
+func TestReadStatusUnknown(t *testing.T) {
+	_, clone := newTestRepo(t)
+
+	// An upstream whose commit isn't there can't be compared with
+	os.WriteFile(filepath.Join(clone, ".git", "refs", "remotes", "origin", "main"),
+		[]byte(strings.Repeat("ab", 20)+"\n"), 0644)
+	info := readWithStatus(t, clone)
+	if info.AheadBehindKnown || info.Ahead != 0 || info.Behind != 0 {
+		t.Errorf("Status = %+v, want an unknown distance", info.Status)
+	}
+	if got := info.Label(); got != "main ↑↓?" {
+		t.Errorf("Label() = %q, want %q", got, "main ↑↓?")
+	}
+
+	// Before ReadStatus nothing is claimed either way
+	info, _ = Read(clone)
+	if got := info.Label(); got != "main" {
+		t.Errorf("Label() before ReadStatus = %q, want main", got)
+	}
+}
+
+func TestReadDetached(t *testing.T) {
+	_, clone := newTestRepo(t)
+	head := runGit(t, clone, "rev-parse", "HEAD~1")
+	runGit(t, clone, "checkout", "-q", "--detach", head)
+
+	info, err := Read(clone)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !info.Detached || info.Branch != "" || info.Head != head {
+		t.Errorf("Read() = %+v, want detached at %s", info, head)
+	}
+	if want := "detached@" + head[:7]; info.Label() != want {
+		t.Errorf("Label() = %q, want %q", info.Label(), want)
+	}
+}
+
+func TestReadNotRepository(t *testing.T) {
+	if _, err := Read(t.TempDir()); err != ErrNotRepository {
+		t.Errorf("Read() error = %v, want ErrNotRepository", err)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte(`[core]
+	bare = false
+[remote "origin"]
+	url = "git@example.com:me/repo.git" ; trailing comment
+[branch "Feature/X"]
+	remote = origin
+	merge = refs/heads/Feature/X
+`), 0644)
+
+	cfg := readConfig(path)
+	if got := cfg["remote.origin.url"]; got != "git@example.com:me/repo.git" {
+		t.Errorf("remote.origin.url = %q", got)
+	}
+	ref, name := upstreamOf(cfg, "Feature/X")
+	if ref != "refs/remotes/origin/Feature/X" || name != "origin/Feature/X" {
+		t.Errorf("upstreamOf() = %q, %q", ref, name)
+	}
+}
+
+func TestReadWorktree(t *testing.T) {
+	_, clone := newTestRepo(t)
+	worktree := filepath.Join(t.TempDir(), "feature")
+	runGit(t, clone, "worktree", "add", "-q", "-b", "feature", worktree)
+
+	info, err := Read(worktree)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if info.Branch != "feature" {
+		t.Errorf("worktree Branch = %q, want feature", info.Branch)
+	}
+	if info.WorkTree != worktree || info.MainWorkTree != clone {
+		t.Errorf("WorkTree, MainWorkTree = %q, %q, want %q, %q", info.WorkTree, info.MainWorkTree, worktree, clone)
+	}
+	if st := info.ReadStatus(); st.Dirty || !st.DirtyKnown {
+		t.Errorf("fresh worktree Dirty = %v, DirtyKnown = %v, want clean", st.Dirty, st.DirtyKnown)
+	}
+
+	main, _ := Read(clone)
+	if main.MainWorkTree != "" || main.WorkTree != clone {
+		t.Errorf("main checkout WorkTree, MainWorkTree = %q, %q, want %q, \"\"", main.WorkTree, main.MainWorkTree, clone)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxIndexEntries is the largest index dirty() checks. Statting every file
+// of a huge repository would make loading the project list slow.
+const maxIndexEntries = 20000
+
+// Index entry flags
+const (
+	flagAssumeValid  = 0x8000
+	flagExtended     = 0x4000
+	flagStageMask    = 0x3000
+	flagNameMask     = 0x0fff
+	extSkipWorktree  = 0x4000
+	modeTypeMask     = 0170000
+	modeGitlink      = 0160000
+	indexEntryFixed  = 40 // ctime, mtime, dev, ino, mode, uid, gid, size
+	indexHeaderBytes = 12
+)
+
+// indexEntry is the part of an index entry needed to spot changes
+type indexEntry struct {
+	path      string
+	mtime     time.Time
+	size      uint32
+	mode      uint32
+	id        []byte
+	stage     int
+	skipCheck bool // assume-unchanged or skip-worktree
+}
+
+// dirty compares the index with the working tree. It returns whether a
+// tracked file is modified, deleted or unmerged, and whether the check could
+// be done at all.
+func (r *repo) dirty() (bool, bool) {
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		// A repository without commits or staged files has no index
+		return false, os.IsNotExist(err)
+	}
+	entries, err := parseIndex(data, r.hashLen)
+	if err != nil || len(entries) > maxIndexEntries {
+		return false, false
+	}
+
+	for _, e := range entries {
+		if e.stage != 0 {
+			return true, true
+		}
+		if e.skipCheck || e.mode&modeTypeMask == modeGitlink {
+			continue
+		}
+		path := filepath.Join(r.workTree, filepath.FromSlash(e.path))
+		info, err := os.Lstat(path)
+		if err != nil {
+			return true, true
+		}
+		if uint32(info.Size()) != e.size {
+			return true, true
+		}
+		if !info.ModTime().Equal(e.mtime) && !sameContent(path, e.id, info.Size()) {
+			// Touched but possibly unchanged: only the content decides
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// sameContent reports whether the file hashes to the given blob ID. Only
+// SHA-1 repositories are supported; content filters such as autocrlf aren't
+// applied, so a converted file counts as changed.
+func sameContent(path string, id []byte, size int64) bool {
+	if len(id) != sha1.Size {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || int64(len(data)) != size {
+		return false
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return bytes.Equal(h.Sum(nil), id)
+}
+
+// parseIndex reads the entries of a version 2, 3 or 4 index file
+func parseIndex(data []byte, hashLen int) ([]indexEntry, error) {
+	if len(data) < indexHeaderBytes || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("not an index file")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+	if count > maxIndexEntries {
+		// The caller skips large indexes; don't bother parsing them
+		return make([]indexEntry, count), nil
+	}
+
+	errTruncated := fmt.Errorf("truncated index")
+	entries := make([]indexEntry, 0, count)
+	pos := indexHeaderBytes
+	prevPath := ""
+	for i := 0; i < count; i++ {
+		start := pos
+		if pos+indexEntryFixed+hashLen+2 > len(data) {
+			return nil, errTruncated
+		}
+		field := func(n int) uint32 { return binary.BigEndian.Uint32(data[pos+n*4:]) }
+		e := indexEntry{
+			mtime: time.Unix(int64(field(2)), int64(field(3))),
+			mode:  field(6),
+			size:  field(9),
+		}
+		pos += indexEntryFixed
+		e.id = data[pos : pos+hashLen]
+		pos += hashLen
+		flags := binary.BigEndian.Uint16(data[pos:])
+		pos += 2
+		e.stage = int(flags&flagStageMask) >> 12
+		e.skipCheck = flags&flagAssumeValid != 0
+		if flags&flagExtended != 0 {
+			if version < 3 || pos+2 > len(data) {
+				return nil, errTruncated
+			}
+			e.skipCheck = e.skipCheck || binary.BigEndian.Uint16(data[pos:])&extSkipWorktree != 0
+			pos += 2
+		}
+
+		if version == 4 {
+			// Path is prefix-compressed against the previous entry: a varint
+			// count of bytes to drop from it, then a NUL-terminated suffix
+			strip, n := indexVarint(data[pos:])
+			if n == 0 || strip > len(prevPath) {
+				return nil, errTruncated
+			}
+			pos += n
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, errTruncated
+			}
+			e.path = prevPath[:len(prevPath)-strip] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			nameLen := int(flags & flagNameMask)
+			if nameLen == flagNameMask {
+				// Long names are only NUL-terminated
+				nameLen = bytes.IndexByte(data[pos:], 0)
+			}
+			if nameLen < 0 || pos+nameLen > len(data) {
+				return nil, errTruncated
+			}
+			e.path = string(data[pos : pos+nameLen])
+			// Entries are NUL-padded to a multiple of 8 bytes, with at least one NUL
+			pos = start + (pos-start+nameLen+8)&^7
+		}
+		prevPath = e.path
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// indexVarint decodes the offset-style varint used by index version 4. It
+// returns the value and the number of bytes read, or 0 bytes on error.
+func indexVarint(b []byte) (int, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	value := int(b[0] & 0x7f)
+	n := 1
+	for b[n-1]&0x80 != 0 {
+		if n >= len(b) {
+			return 0, 0
+		}
+		value = (value+1)<<7 | int(b[n]&0x7f)
+		n++
+	}
+	return value, n
+}
@@ -0,0 +1,483 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"container/heap"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Pack object types
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+// maxWalk bounds the number of commits aheadBehind visits, so a branch that
+// diverged long ago doesn't stall loading
+const maxWalk = 10000
+
+// errNotFound indicates an object isn't in the store
+var errNotFound = errors.New("object not found")
+
+// objectStore reads objects from a repository's objects directory, both
+// loose and from pack files. Alternates are not followed.
+type objectStore struct {
+	dir     string
+	hashLen int
+	packs   []*pack
+	loaded  bool
+}
+
+// pack is an opened pack file with its version 2 index
+type pack struct {
+	file    *os.File
+	fanout  [256]uint32
+	ids     []byte // Sorted object IDs, hashLen bytes each
+	offsets []byte // 4-byte offsets, high bit set for entries in large
+	large   []byte // 8-byte offsets for packs over 2 GiB
+}
+
+func newObjectStore(dir string, hashLen int) *objectStore {
+	return &objectStore{dir: dir, hashLen: hashLen}
+}
+
+// close releases the open pack files
+func (s *objectStore) close() {
+	for _, p := range s.packs {
+		p.file.Close()
+	}
+	s.packs = nil
+}
+
+// read returns the type and content of an object
+func (s *objectStore) read(id string) (int, []byte, error) {
+	// Loose objects first: they are the most recent ones
+	if len(id) > 2 {
+		if typ, data, err := s.readLoose(id); err == nil {
+			return typ, data, nil
+		}
+	}
+
+	raw, err := hex.DecodeString(id)
+	if err != nil || len(raw) != s.hashLen {
+		return 0, nil, fmt.Errorf("invalid object ID: %s", id)
+	}
+	s.openPacks()
+	for _, p := range s.packs {
+		if offset, ok := p.find(raw, s.hashLen); ok {
+			return s.readPacked(p, offset, 0)
+		}
+	}
+	return 0, nil, errNotFound
+}
+
+// readLoose reads objects/<xx>/<rest>
+func (s *objectStore) readLoose(id string) (int, []byte, error) {
+	f, err := os.Open(filepath.Join(s.dir, id[:2], id[2:]))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// "<type> <size>\0<content>"
+	header, content, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return 0, nil, fmt.Errorf("corrupt loose object %s", id)
+	}
+	kind, _, _ := strings.Cut(string(header), " ")
+	switch kind {
+	case "commit":
+		return objCommit, content, nil
+	case "tree":
+		return objTree, content, nil
+	case "blob":
+		return objBlob, content, nil
+	case "tag":
+		return objTag, content, nil
+	}
+	return 0, nil, fmt.Errorf("unknown object type %q", kind)
+}
+
+// openPacks opens every pack with a readable index, once
+func (s *objectStore) openPacks() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+
+	idxFiles, _ := filepath.Glob(filepath.Join(s.dir, "pack", "*.idx"))
+	for _, idxFile := range idxFiles {
+		p, err := openPack(idxFile, s.hashLen)
+		if err != nil {
+			continue
+		}
+		s.packs = append(s.packs, p)
+	}
+}
+
+// openPack reads a version 2 pack index and opens the matching pack
+func openPack(idxFile string, hashLen int) (*pack, error) {
+	idx, err := os.ReadFile(idxFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, fmt.Errorf("unsupported pack index %s", idxFile)
+	}
+
+	p := &pack{}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(idx[8+i*4:])
+	}
+	count := int(p.fanout[255])
+	pos := 8 + 256*4
+	// IDs, then CRCs, then 4-byte offsets, then 8-byte offsets
+	if len(idx) < pos+count*(hashLen+4+4) {
+		return nil, fmt.Errorf("truncated pack index %s", idxFile)
+	}
+	p.ids = idx[pos : pos+count*hashLen]
+	pos += count*hashLen + count*4
+	p.offsets = idx[pos : pos+count*4]
+	p.large = idx[pos+count*4:]
+
+	p.file, err = os.Open(strings.TrimSuffix(idxFile, ".idx") + ".pack")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// find returns the offset of an object in the pack
+func (p *pack) find(id []byte, hashLen int) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch cmp := bytes.Compare(p.ids[mid*hashLen:(mid+1)*hashLen], id); {
+		case cmp == 0:
+			offset := binary.BigEndian.Uint32(p.offsets[mid*4:])
+			if offset&0x80000000 == 0 {
+				return int64(offset), true
+			}
+			i := int(offset&0x7fffffff) * 8
+			if i+8 > len(p.large) {
+				return 0, false
+			}
+			return int64(binary.BigEndian.Uint64(p.large[i:])), true
+		case cmp < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+// readPacked reads the object at offset, resolving deltas against their base
+func (s *objectStore) readPacked(p *pack, offset int64, depth int) (int, []byte, error) {
+	if depth > 50 {
+		return 0, nil, errors.New("delta chain too long")
+	}
+	r := bufio.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+
+	// Type and size: 3 type bits and 4 size bits, then 7 size bits per byte
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	typ := int(c>>4) & 7
+	size := int64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	var baseType int
+	var base []byte
+	switch typ {
+	case objOfsDelta:
+		// Offset of the base, relative to this object, in a big-endian varint
+		// where each continuation adds one
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			rel = (rel+1)<<7 | int64(c&0x7f)
+		}
+		if baseType, base, err = s.readPacked(p, offset-rel, depth+1); err != nil {
+			return 0, nil, err
+		}
+	case objRefDelta:
+		id := make([]byte, s.hashLen)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return 0, nil, err
+		}
+		if baseType, base, err = s.read(hex.EncodeToString(id)); err != nil {
+			return 0, nil, err
+		}
+	case objCommit, objTree, objBlob, objTag:
+	default:
+		return 0, nil, fmt.Errorf("unknown pack object type %d", typ)
+	}
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return 0, nil, err
+	}
+
+	if base == nil {
+		return typ, data, nil
+	}
+	result, err := applyDelta(base, data)
+	return baseType, result, err
+}
+
+// applyDelta rebuilds an object from its base and a pack delta
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errCorrupt := errors.New("corrupt delta")
+	pos := 0
+	varint := func() (int, bool) {
+		n, shift := 0, 0
+		for pos < len(delta) {
+			c := delta[pos]
+			pos++
+			n |= int(c&0x7f) << shift
+			if c&0x80 == 0 {
+				return n, true
+			}
+			shift += 7
+		}
+		return 0, false
+	}
+
+	baseSize, ok1 := varint()
+	resultSize, ok2 := varint()
+	if !ok1 || !ok2 || baseSize != len(base) {
+		return nil, errCorrupt
+	}
+
+	result := make([]byte, 0, resultSize)
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		if op&0x80 != 0 {
+			// Copy from base: offset and size bytes are present per flag bit
+			var offset, size int
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if pos >= len(delta) {
+						return nil, errCorrupt
+					}
+					offset |= int(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if pos >= len(delta) {
+						return nil, errCorrupt
+					}
+					size |= int(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > len(base) {
+				return nil, errCorrupt
+			}
+			result = append(result, base[offset:offset+size]...)
+		} else if op != 0 {
+			// Insert the next op bytes literally
+			n := int(op)
+			if pos+n > len(delta) {
+				return nil, errCorrupt
+			}
+			result = append(result, delta[pos:pos+n]...)
+			pos += n
+		} else {
+			return nil, errCorrupt
+		}
+	}
+	if len(result) != resultSize {
+		return nil, errCorrupt
+	}
+	return result, nil
+}
+
+// commit is the part of a commit object aheadBehind needs
+type commit struct {
+	parents []string
+	time    int64 // Committer time, seconds since the epoch
+}
+
+// readCommit parses the header of a commit object
+func (s *objectStore) readCommit(id string) (commit, error) {
+	typ, data, err := s.read(id)
+	if err != nil {
+		return commit{}, err
+	}
+	if typ != objCommit {
+		return commit{}, fmt.Errorf("%s is not a commit", id)
+	}
+
+	var c commit
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break // End of headers
+		}
+		if parent, ok := strings.CutPrefix(line, "parent "); ok {
+			c.parents = append(c.parents, parent)
+		} else if committer, ok := strings.CutPrefix(line, "committer "); ok {
+			// "Name <email> <seconds> <zone>"
+			fields := strings.Fields(committer)
+			if len(fields) >= 2 {
+				c.time, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+	return c, nil
+}
+
+// aheadBehind counts the commits reachable from local but not upstream
+// (ahead) and the other way round (behind). Like git, it walks both histories
+// newest first and stops once every pending commit is reachable from both.
+func aheadBehind(s *objectStore, local, upstream string) (int, int, error) {
+	if local == upstream {
+		return 0, 0, nil
+	}
+
+	const (
+		fromLocal    = 1
+		fromUpstream = 2
+		fromBoth     = fromLocal | fromUpstream
+	)
+	flags := make(map[string]uint8)
+	commits := make(map[string]commit)
+	queue := &commitQueue{}
+
+	push := func(id string, flag uint8) error {
+		if flags[id]&flag == flag {
+			return nil
+		}
+		flags[id] |= flag
+		c, ok := commits[id]
+		if !ok {
+			var err error
+			if c, err = s.readCommit(id); err != nil {
+				return err
+			}
+			commits[id] = c
+		}
+		heap.Push(queue, queued{id, c.time})
+		return nil
+	}
+	if err := push(local, fromLocal); err != nil {
+		return 0, 0, err
+	}
+	if err := push(upstream, fromUpstream); err != nil {
+		return 0, 0, err
+	}
+
+	for walked := 0; queue.Len() > 0; walked++ {
+		if walked > maxWalk {
+			return 0, 0, errors.New("history too long to compare")
+		}
+		// Scanning the queue is linear, so only do it now and then; walking a
+		// few extra commits reachable from both sides doesn't change the counts
+		if walked%32 == 0 && queue.allFlagged(flags, fromBoth) {
+			break
+		}
+		item := heap.Pop(queue).(queued)
+		flag := flags[item.id]
+		for _, parent := range commits[item.id].parents {
+			if err := push(parent, flag); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	ahead, behind := 0, 0
+	for _, flag := range flags {
+		switch flag {
+		case fromLocal:
+			ahead++
+		case fromUpstream:
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// queued is a commit waiting to be walked
+type queued struct {
+	id   string
+	time int64
+}
+
+// commitQueue is a max-heap of commits by committer time
+type commitQueue []queued
+
+func (q commitQueue) Len() int           { return len(q) }
+func (q commitQueue) Less(i, j int) bool { return q[i].time > q[j].time }
+func (q commitQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)        { *q = append(*q, x.(queued)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// allFlagged reports whether every queued commit carries all of flag
+func (q commitQueue) allFlagged(flags map[string]uint8, flag uint8) bool {
+	for _, item := range q {
+		if flags[item.id] != flag {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// dirtyMaxAge is how long a comparison of the working tree with an unchanged
+// index is reused. Edits don't touch the index, so they show up this late.
+const dirtyMaxAge = 30 * time.Second
+
+// maxCachedDistances bounds the upstream distances kept; the cache starts
+// over when it is full
+const maxCachedDistances = 4096
+
+// statusCache keeps the results of ReadStatus. The distance between two
+// commits never changes; a working tree comparison holds while the index
+// is unchanged, for dirtyMaxAge.
+var statusCache = struct {
+	sync.Mutex
+	distances map[distanceKey]distance
+	dirty     map[string]dirtyCheck // By index path
+}{distances: make(map[distanceKey]distance), dirty: make(map[string]dirtyCheck)}
+
+// distanceKey identifies two commits of a repository
+type distanceKey struct {
+	objects, local, upstream string
+}
+
+// distance is the result of aheadBehind
+type distance struct {
+	ahead, behind int
+	known         bool
+}
+
+// dirtyCheck is the result of dirty for an index as it was
+type dirtyCheck struct {
+	size           int64
+	modTime        time.Time
+	checked        time.Time
+	dirty, dirtyOK bool
+}
+
+// ReadStatus compares the checkout with its upstream and its index with the
+// working tree. This walks the history and stats every tracked file, so it
+// is meant to run in the background after the Info is shown. Results are
+// cached, and safe to read from several goroutines.
+func (i *Info) ReadStatus() Status {
+	st := Status{Checked: true}
+	if i == nil || i.repo == nil {
+		return st
+	}
+	if i.Head != "" && i.upstreamID != "" {
+		st.Ahead, st.Behind, st.AheadBehindKnown = i.repo.distance(i.Head, i.upstreamID)
+	}
+	st.Dirty, st.DirtyKnown = i.repo.cachedDirty(time.Now())
+	return st
+}
+
+// distance returns the result of aheadBehind, from the cache if possible
+func (r *repo) distance(local, upstream string) (int, int, bool) {
+	objects := filepath.Join(r.commonDir, "objects")
+	key := distanceKey{objects, local, upstream}
+	statusCache.Lock()
+	d, ok := statusCache.distances[key]
+	statusCache.Unlock()
+	if ok {
+		return d.ahead, d.behind, d.known
+	}
+
+	store := newObjectStore(objects, r.hashLen)
+	ahead, behind, err := aheadBehind(store, local, upstream)
+	store.close()
+	d = distance{ahead, behind, err == nil}
+
+	statusCache.Lock()
+	if len(statusCache.distances) >= maxCachedDistances {
+		statusCache.distances = make(map[distanceKey]distance)
+	}
+	statusCache.distances[key] = d
+	statusCache.Unlock()
+	return d.ahead, d.behind, d.known
+}
+
+// cachedDirty returns the result of dirty, reusing one made at most
+// dirtyMaxAge before now against the same index
+func (r *repo) cachedDirty(now time.Time) (bool, bool) {
+	index := filepath.Join(r.gitDir, "index")
+	info, err := os.Stat(index)
+	if err != nil {
+		return r.dirty()
+	}
+	statusCache.Lock()
+	c, ok := statusCache.dirty[index]
+	statusCache.Unlock()
+	if ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) && now.Sub(c.checked) < dirtyMaxAge {
+		return c.dirty, c.dirtyOK
+	}
+
+	dirty, dirtyOK := r.dirty()
+	statusCache.Lock()
+	statusCache.dirty[index] = dirtyCheck{info.Size(), info.ModTime(), now, dirty, dirtyOK}
+	statusCache.Unlock()
+	return dirty, dirtyOK
+}
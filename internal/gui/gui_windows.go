@@ -6,16 +6,31 @@
 package gui
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"unsafe"
 
 	"github.com/fanis/claude-code-switcher/internal/config"
+	"github.com/fanis/claude-code-switcher/internal/export"
+	"github.com/fanis/claude-code-switcher/internal/frecency"
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
 	"github.com/fanis/claude-code-switcher/internal/fuzzy"
+	"github.com/fanis/claude-code-switcher/internal/notes"
 	"github.com/fanis/claude-code-switcher/internal/projects"
+	"github.com/fanis/claude-code-switcher/internal/search"
 	"github.com/fanis/claude-code-switcher/internal/terminal"
+	"github.com/fanis/claude-code-switcher/internal/trash"
 	"github.com/fanis/claude-code-switcher/internal/update"
+	"github.com/fanis/claude-code-switcher/internal/usage"
+	"github.com/fanis/claude-code-switcher/internal/watch"
 )
 
 var (
@@ -57,6 +72,7 @@ var (
 	procPostMessageW         = user32.NewProc("PostMessageW")
 	procEnableWindow         = user32.NewProc("EnableWindow")
 	procIsDialogMessageW     = user32.NewProc("IsDialogMessageW")
+	procGetKeyState          = user32.NewProc("GetKeyState")
 )
 
 const (
@@ -103,6 +119,10 @@ const (
 	WM_CTLCOLORSTATIC  = 0x0138
 	WM_APP             = 0x8000
 	WM_APP_UPDATE      = WM_APP + 1
+	WM_APP_PROJECTS    = WM_APP + 2
+	WM_APP_DISCOVERED  = WM_APP + 3
+	WM_APP_REFRESH     = WM_APP + 4
+	WM_APP_CALL        = WM_APP + 5
 
 	WA_INACTIVE = 0
 
@@ -116,6 +136,7 @@ const (
 	VK_TAB       = 0x09
 	VK_BACK      = 0x08
 	VK_CONTROL   = 0x11
+	VK_SHIFT     = 0x10
 
 	EM_SETSEL    = 0x00B1
 	EM_GETSEL    = 0x00B0
@@ -133,6 +154,7 @@ const (
 	MB_YESNO        = 0x00000004
 	MB_ICONERROR    = 0x00000010
 	MB_ICONQUESTION = 0x00000020
+	MB_ICONWARNING  = 0x00000030
 	IDYES           = 6
 
 	BM_GETCHECK      = 0x00F0
@@ -158,6 +180,8 @@ const (
 	DT_SINGLELINE   = 0x0020
 	DT_VCENTER      = 0x0004
 	DT_END_ELLIPSIS = 0x8000
+	DT_NOPREFIX     = 0x0800
+	DT_CALCRECT     = 0x0400
 
 	ODT_LISTBOX   = 2
 	ODA_DRAWENTIRE = 0x0001
@@ -242,6 +266,15 @@ const (
 
 const (
 	VK_F1 = 0x70
+	VK_F2 = 0x71
+	VK_F3 = 0x72
+	VK_F4 = 0x73
+	VK_F8 = 0x77
+	VK_E  = 0x45
+	VK_G  = 0x47
+	VK_H  = 0x48
+	VK_P  = 0x50
+	VK_T  = 0x54
 )
 
 var (
@@ -259,10 +292,55 @@ var (
 
 	allProjects      []projects.Project
 	filteredProjects []projects.Project
-	sortByName       bool
+	sortMode         int  // One of the sortBy* constants
+	groupWorktrees   bool // Nest git worktrees under their main checkout
+	showHidden       bool // List the projects hidden by the config as well
 	showingDialog    bool // Prevent close on focus loss while showing dialog
 	appVersion       string
 	appConfig        *config.Config
+
+	// Token usage by Project.Key, loaded in the background when first sorted
+	// by it or shown
+	projectUsage map[string]usage.ProjectUsage
+	usagePricing usage.Pricing
+	usageLoading bool // A load is running
+	usageStale   bool // The projects changed while it ran
+
+	// The search box holds a qualifier that can't be parsed, shown in the title
+	filterError bool
+
+	// Frecency per project, computed in the background when first sorted by it
+	projectFrecency map[string]float64
+	frecencyLoading bool // A ranking is running
+	frecencyStale   bool // The projects changed while it ran
+
+	// Transcript search ("?" prefix): the index, built in the background on
+	// first use, and the hits shown, which line up with filteredProjects. nil
+	// when not searching.
+	transcriptIndex    *search.Index
+	transcriptHits     []search.Hit
+	transcriptIndexing bool // A build is running
+	transcriptIndexOld bool // The transcripts changed since the last build began
+
+	// mainHwnd for background goroutines, 0 until the window exists
+	sharedHwnd atomic.Uintptr
+
+	// Work handed back to the GUI thread by background goroutines
+	uiCallsMu sync.Mutex
+	uiCalls   []func()
+
+	// What path checks and git status reads that finish after the list is
+	// shown found out, applied on the GUI thread
+	pendingUpdatesMu sync.Mutex
+	pendingUpdates   []projects.Update
+
+	// Repositories under the scan roots found after the list is shown
+	discoveredMu sync.Mutex
+	discovered   []projects.Project
+
+	// Projects that appeared, changed or disappeared since the list was shown
+	watchEventsMu sync.Mutex
+	watchEvents   []watch.Event
 )
 
 func utf16PtrFromString(s string) *uint16 {
@@ -275,11 +353,19 @@ func negInt(n int) uintptr {
 	return uintptr(int32(n))
 }
 
-func Run(projectList []projects.Project, version string, cfg *config.Config) {
-	allProjects = projectList
-	filteredProjects = projectList
+// Run shows the switcher window until it is closed. The watcher, if not nil,
+// keeps the list up to date while it is open.
+func Run(projectList []projects.Project, watcher *watch.Watcher, version string, cfg *config.Config) {
 	appVersion = version
 	appConfig = cfg
+	if _, err := projects.NewHideRules(cfg.IgnorePatterns, nil); err != nil {
+		showMessageBox(0, err.Error()+"\n\nThese patterns are skipped.", "Claude Code Switcher", MB_ICONERROR)
+	}
+	applyLabels(projectList)
+	// Brings pinned projects to the top
+	sortProjects(projectList)
+	allProjects = projectList
+	filteredProjects = projectList
 
 	// Initialize common controls
 	var icc INITCOMMONCONTROLSEX
@@ -321,8 +407,41 @@ func Run(projectList []projects.Project, version string, cfg *config.Config) {
 	)
 
 	mainHwnd = hwnd
+	sharedHwnd.Store(hwnd)
 	terminal.SetParentHwnd(hwnd)
 
+	// Apply path checks that finished while the window was being created
+	procPostMessageW.Call(hwnd, WM_APP_PROJECTS, 0, 0)
+
+	// Local changes and upstream distances take longer to find than the
+	// rest of the list, so they are filled in as they come
+	readGitStatus(allProjects)
+
+	// Repositories never opened in Claude Code are added once found
+	if len(appConfig.ScanRoots) > 0 {
+		known := append([]projects.Project(nil), allProjects...)
+		opts := scanOptions()
+		go func() {
+			found := projects.Discover(context.Background(), opts, known)
+			discoveredMu.Lock()
+			discovered = found
+			discoveredMu.Unlock()
+			procPostMessageW.Call(hwnd, WM_APP_DISCOVERED, 0, 0)
+		}()
+	}
+
+	// Sessions started while the window is open are added as they appear
+	if watcher != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go watcher.Run(ctx, func(events []watch.Event) {
+			watchEventsMu.Lock()
+			watchEvents = append(watchEvents, events...)
+			watchEventsMu.Unlock()
+			procPostMessageW.Call(hwnd, WM_APP_REFRESH, 0, 0)
+		})
+	}
+
 	procShowWindow.Call(hwnd, SW_SHOW)
 	procUpdateWindow.Call(hwnd)
 	procSetForegroundWindow.Call(hwnd)
@@ -416,6 +535,22 @@ func wndProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
 		showUpdateNotification()
 		return 0
 
+	case WM_APP_PROJECTS:
+		applyPendingUpdates()
+		return 0
+
+	case WM_APP_DISCOVERED:
+		applyDiscovered()
+		return 0
+
+	case WM_APP_REFRESH:
+		applyWatchEvents()
+		return 0
+
+	case WM_APP_CALL:
+		runUICalls()
+		return 0
+
 	case WM_DRAWITEM:
 		dis := (*DRAWITEMSTRUCT)(unsafe.Pointer(lParam))
 		if dis.CtlID == IDC_LISTBOX {
@@ -515,7 +650,7 @@ func createControls(hwnd uintptr) {
 		uintptr(unsafe.Pointer(utf16PtrFromString("BUTTON"))),
 		uintptr(unsafe.Pointer(utf16PtrFromString("By: Recent"))),
 		WS_CHILD|WS_VISIBLE|WS_TABSTOP,
-		500, 10, 90, 30,
+		500, 10, 100, 30,
 		hwnd, IDC_SORT, hInstance, 0,
 	)
 	procSendMessageW.Call(sortBtnHwnd, WM_SETFONT, hFont, 1)
@@ -557,6 +692,14 @@ func editSubclassProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr
 			deleteWordBackward(hwnd)
 			return 0
 		}
+		// Swallow the control characters produced by Ctrl+Enter, Ctrl+E, Ctrl+G, Ctrl+P and Ctrl+T
+		if wParam == 0x0A || wParam == 0x05 || wParam == 0x07 || wParam == 0x10 || wParam == 0x14 {
+			return 0
+		}
+		// Ctrl+H produces a backspace, which only deletes without Ctrl
+		if wParam == VK_BACK && isKeyDown(VK_CONTROL) {
+			return 0
+		}
 	case WM_KEYDOWN:
 		switch wParam {
 		case VK_TAB:
@@ -579,14 +722,59 @@ func editSubclassProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr
 			}
 			return 0
 		case VK_RETURN:
-			onProjectSelected()
+			if isKeyDown(VK_CONTROL) {
+				showSessionBrowser()
+			} else {
+				onProjectSelected()
+			}
 			return 0
+		case VK_E:
+			if isKeyDown(VK_CONTROL) {
+				exportSession()
+				return 0
+			}
+		case VK_G:
+			if isKeyDown(VK_CONTROL) {
+				toggleGroupWorktrees()
+				return 0
+			}
+		case VK_H:
+			if isKeyDown(VK_CONTROL) && isKeyDown(VK_SHIFT) {
+				toggleShowHidden()
+				return 0
+			}
+			if isKeyDown(VK_CONTROL) {
+				toggleHidden()
+				return 0
+			}
+		case VK_P:
+			if isKeyDown(VK_CONTROL) {
+				togglePin()
+				return 0
+			}
+		case VK_T:
+			if isKeyDown(VK_CONTROL) {
+				editTags()
+				return 0
+			}
 		case VK_ESCAPE:
 			procDestroyWindow.Call(mainHwnd)
 			return 0
 		case VK_F1:
 			showSettingsDialog()
 			return 0
+		case VK_F2:
+			editAlias()
+			return 0
+		case VK_F3:
+			showProjectDetails()
+			return 0
+		case VK_F4:
+			editNote()
+			return 0
+		case VK_F8:
+			pruneOrphans()
+			return 0
 		}
 	}
 
@@ -648,7 +836,7 @@ func resizeControls(hwnd uintptr) {
 	width := rect.Right - rect.Left
 	height := rect.Bottom - rect.Top
 
-	sortBtnWidth := int32(90)
+	sortBtnWidth := int32(100)
 	settingsBtnWidth := int32(30)
 	margin := int32(10)
 	gap := int32(6)
@@ -666,9 +854,13 @@ func resizeControls(hwnd uintptr) {
 func populateList() {
 	procSendMessageW.Call(listHwnd, LB_RESETCONTENT, 0, 0)
 
+	if groupWorktrees && transcriptHits == nil {
+		filteredProjects = projects.GroupWorktrees(filteredProjects)
+	}
+
 	for i, proj := range filteredProjects {
 		// Add the project name as the string (for accessibility)
-		text := utf16PtrFromString(proj.Name)
+		text := utf16PtrFromString(proj.DisplayName())
 		procSendMessageW.Call(listHwnd, LB_ADDSTRING, 0, uintptr(unsafe.Pointer(text)))
 		// Store the index in the original slice as item data
 		procSendMessageW.Call(listHwnd, LB_SETITEMDATA, uintptr(i), uintptr(i))
@@ -690,6 +882,10 @@ func drawListItem(dis *DRAWITEMSTRUCT) {
 	}
 
 	proj := filteredProjects[idx]
+	var hit *search.Hit
+	if idx < len(transcriptHits) {
+		hit = &transcriptHits[idx]
+	}
 
 	// DPI-scaled values
 	scale := func(base int32) int32 {
@@ -702,7 +898,7 @@ func drawListItem(dis *DRAWITEMSTRUCT) {
 		bgColor = 0x00CC7A00       // Nice blue (#007ACC in RGB)
 		textColor = 0x00FFFFFF     // White
 		secondaryColor = 0x00E0E0E0 // Light gray
-	} else if !proj.PathExists {
+	} else if proj.PathState == projects.PathMissing {
 		bgColor = 0x00F0F0F0       // Light gray background
 		textColor = 0x00808080     // Gray text
 		secondaryColor = 0x00A0A0A0 // Lighter gray
@@ -726,14 +922,52 @@ func drawListItem(dis *DRAWITEMSTRUCT) {
 	nameRect.Top += scale(4)
 	nameRect.Bottom = nameRect.Top + scale(18)
 
-	nameText := proj.Name
-	if !proj.PathExists {
+	nameText := proj.DisplayName()
+	if groupWorktrees && proj.IsWorktree() && hit == nil {
+		// Nested under the main checkout and named by what is checked out
+		nameRect.Left += scale(16)
+		if label := proj.Git.Label(); label != "" {
+			nameText = "\u21b3 " + label + "  (" + proj.DisplayName() + ")"
+		} else {
+			nameText = "\u21b3 " + proj.DisplayName()
+		}
+	} else if label := proj.Git.Label(); label != "" {
+		nameText += "  [" + label + "]"
+	}
+	if len(proj.Tags) > 0 {
+		nameText += "  #" + strings.Join(proj.Tags, " #")
+	}
+	switch proj.PathState {
+	case projects.PathMissing:
 		nameText = "[NOT FOUND] " + nameText
+	case projects.PathUnknown:
+		nameText = "[?] " + nameText
+	}
+	if proj.New {
+		nameText = "[NEW] " + nameText
+	}
+	if proj.Hidden {
+		nameText = "[HIDDEN] " + nameText
+	}
+	if appConfig.IsPinned(proj.Path) {
+		nameText = "\u2605 " + nameText
 	}
 	drawText(dis.HDC, nameText, &nameRect, DT_LEFT|DT_SINGLELINE|DT_END_ELLIPSIS)
 
-	// Draw last used timestamp (first line, right-aligned)
+	// Draw last used timestamp (first line, right-aligned), after the usage
+	// when sorting by it
 	lastUsedStr := formatLastUsed(proj.LastUsed)
+	switch {
+	case hit != nil:
+		lastUsedStr = formatLastUsed(hit.Time)
+		if hit.Matches > 1 {
+			lastUsedStr = fmt.Sprintf("%d messages \u00b7 %s", hit.Matches, lastUsedStr)
+		}
+	case sortMode == sortByTokens:
+		lastUsedStr = usage.FormatTokens(projectTokens(proj)) + " tokens \u00b7 " + lastUsedStr
+	case sortMode == sortByCost:
+		lastUsedStr = fmt.Sprintf("$%.2f \u00b7 %s", projectCost(proj), lastUsedStr)
+	}
 	timeRect := dis.RcItem
 	timeRect.Right -= scale(8)
 	timeRect.Top += scale(4)
@@ -741,12 +975,34 @@ func drawListItem(dis *DRAWITEMSTRUCT) {
 	setTextColor(dis.HDC, secondaryColor)
 	drawText(dis.HDC, lastUsedStr, &timeRect, DT_RIGHT|DT_SINGLELINE)
 
-	// Draw path (second line)
+	// Draw path, or the matching message when searching transcripts (second line)
 	infoRect := dis.RcItem
 	infoRect.Left += scale(8)
+	if groupWorktrees && proj.IsWorktree() && hit == nil {
+		infoRect.Left += scale(16)
+	}
 	infoRect.Top += scale(22)
 	infoRect.Bottom = infoRect.Top + scale(16)
-	drawText(dis.HDC, proj.Path, &infoRect, DT_LEFT|DT_SINGLELINE|DT_END_ELLIPSIS)
+	infoText := proj.Path
+	if proj.Note != "" {
+		// The note comes first so a long path can't push it out of view
+		infoText = proj.Note + "  \u00b7  " + proj.Path
+	}
+	if hit != nil {
+		infoText = hit.Snippet
+	} else if badges := proj.Context.Badges(); len(badges) > 0 {
+		// Claude Code configuration in the project, right-aligned after the path
+		badgeText := strings.Join(badges, " \u00b7 ")
+		badgeRect := infoRect
+		badgeRect.Right = dis.RcItem.Right - scale(8)
+		drawText(dis.HDC, badgeText, &badgeRect, DT_SINGLELINE|DT_NOPREFIX|DT_CALCRECT)
+		width := badgeRect.Right - badgeRect.Left
+		badgeRect.Right = dis.RcItem.Right - scale(8)
+		badgeRect.Left = badgeRect.Right - width
+		drawText(dis.HDC, badgeText, &badgeRect, DT_RIGHT|DT_SINGLELINE|DT_NOPREFIX)
+		infoRect.Right = badgeRect.Left - scale(12)
+	}
+	drawText(dis.HDC, infoText, &infoRect, DT_LEFT|DT_SINGLELINE|DT_END_ELLIPSIS|DT_NOPREFIX)
 }
 
 func formatLastUsed(t time.Time) string {
@@ -783,11 +1039,142 @@ func formatLastUsed(t time.Time) string {
 	}
 }
 
+// UpdateProject records what a project path check or git status read that
+// finished after the list was loaded found out. It is safe to call from any
+// goroutine, including before Run has created the window, which applies what
+// was recorded so far.
+func UpdateProject(u projects.Update) {
+	pendingUpdatesMu.Lock()
+	pendingUpdates = append(pendingUpdates, u)
+	pendingUpdatesMu.Unlock()
+
+	if hwnd := sharedHwnd.Load(); hwnd != 0 {
+		procPostMessageW.Call(hwnd, WM_APP_PROJECTS, 0, 0)
+	}
+}
+
+// applyPendingUpdates updates the project lists with late path checks and
+// git status reads
+func applyPendingUpdates() {
+	pendingUpdatesMu.Lock()
+	updates := pendingUpdates
+	pendingUpdates = nil
+	pendingUpdatesMu.Unlock()
+
+	if len(updates) == 0 {
+		return
+	}
+	// Merged duplicates may spell the path with a different case
+	byKey := make(map[string][]projects.Update, len(updates))
+	for _, u := range updates {
+		byKey[u.Key()] = append(byKey[u.Key()], u)
+	}
+	for _, list := range [][]projects.Project{allProjects, filteredProjects} {
+		for i := range list {
+			for _, u := range byKey[list[i].Key()] {
+				u.Apply(&list[i])
+			}
+		}
+	}
+	procInvalidateRect.Call(listHwnd, 0, 1)
+}
+
+// readGitStatus reads the git status of the projects in the background, and
+// has them updated as each one is read
+func readGitStatus(list []projects.Project) {
+	list = append([]projects.Project(nil), list...)
+	go projects.ReadGitStatus(context.Background(), list, 4, UpdateProject)
+}
+
+// applyDiscovered adds the repositories found under the scan roots to the
+// list, after the known projects, keeping the selection
+func applyDiscovered() {
+	discoveredMu.Lock()
+	found := discovered
+	discovered = nil
+	discoveredMu.Unlock()
+
+	// A project may have been relocated into one of them meanwhile. A
+	// repository known in any data root isn't listed again.
+	known := make(map[string]bool, len(allProjects))
+	for _, p := range allProjects {
+		known[fsutil.PathKey(p.Path)] = true
+	}
+	added := 0
+	for _, p := range found {
+		if !known[fsutil.PathKey(p.Path)] {
+			allProjects = append(allProjects, p)
+			added++
+		}
+	}
+	if added == 0 {
+		return
+	}
+	readGitStatus(allProjects[len(allProjects)-added:])
+
+	selected := ""
+	if proj := selectedProject(); proj != nil {
+		selected = proj.Path
+	}
+	applyLabels(allProjects)
+	sortProjects(allProjects)
+	onSearchChanged()
+	selectPath(selected)
+}
+
+// applyWatchEvents updates the list with the projects that appeared, changed
+// or disappeared, keeping the search and the selection
+func applyWatchEvents() {
+	watchEventsMu.Lock()
+	events := watchEvents
+	watchEvents = nil
+	watchEventsMu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+
+	selected := ""
+	if proj := selectedProject(); proj != nil {
+		selected = proj.Path
+	}
+	allProjects = watch.Apply(allProjects, events)
+	var changed []projects.Project
+	for _, e := range events {
+		if e.Op != watch.Remove {
+			changed = append(changed, e.Project)
+		}
+	}
+	readGitStatus(changed)
+	// Scores and totals are out of date, so recompute the ones in use
+	projectFrecency = nil
+	projectUsage = nil
+	usageStale = usageLoading
+	frecencyStale = frecencyLoading
+	transcriptIndexOld = true
+	switch sortMode {
+	case sortByFrecency:
+		loadFrecency()
+	case sortByTokens, sortByCost:
+		loadUsage()
+	}
+	applyLabels(allProjects)
+	sortProjects(allProjects)
+	onSearchChanged()
+	selectPath(selected)
+}
+
 func onSearchChanged() {
+	if filterError {
+		filterError = false
+		procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher"))))
+	}
+
 	// Get search text
 	length, _, _ := procGetWindowTextLengthW.Call(editHwnd)
 	if length == 0 {
-		filteredProjects = allProjects
+		transcriptHits = nil
+		filteredProjects = listedProjects()
 		populateList()
 		return
 	}
@@ -796,18 +1183,52 @@ func onSearchChanged() {
 	procGetWindowTextW.Call(editHwnd, uintptr(unsafe.Pointer(&buf[0])), length+1)
 	searchText := syscall.UTF16ToString(buf)
 
+	if query, ok := strings.CutPrefix(searchText, "?"); ok {
+		searchTranscripts(query)
+		return
+	}
+	transcriptHits = nil
+
+	// Qualifiers such as tag:oss narrow the list, in sort order. A misspelt
+	// feature lists nothing, and says why in the title.
+	filter, query, err := projects.ParseFilter(searchText)
+	if err != nil {
+		filterError = true
+		procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher - "+err.Error()))))
+		filteredProjects = nil
+		populateList()
+		return
+	}
+	candidates := listedProjects()
+	if !filter.IsEmpty() {
+		var matched []projects.Project
+		for _, p := range candidates {
+			if filter.Match(p) {
+				matched = append(matched, p)
+			}
+		}
+		candidates = matched
+	}
+	if query == "" {
+		filteredProjects = candidates
+		populateList()
+		return
+	}
+
 	// Fuzzy filter
 	var names []string
-	for _, p := range allProjects {
-		names = append(names, p.Name+" "+p.Path)
+	for _, p := range candidates {
+		names = append(names, p.SearchText())
 	}
 
-	scored := fuzzy.FilterAndScore(searchText, names)
+	scored := fuzzy.FilterAndScore(query, names)
 
 	filteredProjects = nil
 	for _, item := range scored {
-		filteredProjects = append(filteredProjects, allProjects[item.Index])
+		filteredProjects = append(filteredProjects, candidates[item.Index])
 	}
+	projects.NewLast(filteredProjects)
+	projects.PinFirst(filteredProjects, appConfig.IsPinned)
 
 	populateList()
 }
@@ -848,22 +1269,12 @@ func showSettingsDialog() {
 	}
 	procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
 
-	// Center on main window
-	var mainRect RECT
-	procGetClientRect.Call(mainHwnd, uintptr(unsafe.Pointer(&mainRect)))
-	var pt POINT
-	pt.X = mainRect.Left
-	pt.Y = mainRect.Top
-	clientToScreen := user32.NewProc("ClientToScreen")
-	clientToScreen.Call(mainHwnd, uintptr(unsafe.Pointer(&pt)))
-
 	dpiScale := func(base int) int {
 		return (base * int(currentDPI)) / 96
 	}
 	dlgWidth := dpiScale(310)
 	dlgHeight := dpiScale(400)
-	dlgX := int(pt.X) + (int(mainRect.Right-mainRect.Left)-dlgWidth)/2
-	dlgY := int(pt.Y) + (int(mainRect.Bottom-mainRect.Top)-dlgHeight)/2
+	dlgX, dlgY := dialogOrigin(dlgWidth, dlgHeight)
 
 	procEnableWindow.Call(mainHwnd, 0)
 
@@ -906,6 +1317,22 @@ func showSettingsDialog() {
 	procEnableWindow.Call(mainHwnd, 1)
 }
 
+// dialogOrigin returns the screen position that centers a dialog of the
+// given size on the main window
+func dialogOrigin(dlgWidth, dlgHeight int) (int, int) {
+	var mainRect RECT
+	procGetClientRect.Call(mainHwnd, uintptr(unsafe.Pointer(&mainRect)))
+	var pt POINT
+	pt.X = mainRect.Left
+	pt.Y = mainRect.Top
+	clientToScreen := user32.NewProc("ClientToScreen")
+	clientToScreen.Call(mainHwnd, uintptr(unsafe.Pointer(&pt)))
+
+	dlgX := int(pt.X) + (int(mainRect.Right-mainRect.Left)-dlgWidth)/2
+	dlgY := int(pt.Y) + (int(mainRect.Bottom-mainRect.Top)-dlgHeight)/2
+	return dlgX, dlgY
+}
+
 func createSettingsControls(hwnd uintptr, hInstance uintptr) {
 	su := func(base int) uintptr {
 		return uintptr((base * int(currentDPI)) / 96)
@@ -1170,125 +1597,1166 @@ func settingsDlgProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
 	return ret
 }
 
-func getDlgItem(hwnd uintptr, id uintptr) uintptr {
-	ret, _, _ := procGetDlgItem.Call(hwnd, id)
-	return ret
-}
+var listDlgHwnd uintptr
+var listDlgListHwnd uintptr
+var listDlgResult int
 
-func getWindowText(hwnd uintptr) string {
-	length, _, _ := procGetWindowTextLengthW.Call(hwnd)
-	if length == 0 {
-		return ""
-	}
-	buf := make([]uint16, length+1)
-	procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), length+1)
-	return syscall.UTF16ToString(buf)
-}
+const IDC_LISTDLG_LIST = 301
 
-func showUpdateNotification() {
-	version := appConfig.PendingVersion
-	url := appConfig.PendingURL
+// showListDialog shows a modal list of items and returns the index of the
+// chosen one, or -1 if the dialog was cancelled.
+func showListDialog(title string, items []string) int {
+	showingDialog = true
+	defer func() {
+		showingDialog = false
+		procSetFocus.Call(editHwnd)
+	}()
 
-	// Clear pending so we don't show again
-	appConfig.PendingVersion = ""
-	appConfig.PendingURL = ""
-	config.Save(appConfig)
+	hInstance, _, _ := procGetModuleHandleW.Call(0)
 
-	result := showMessageBox(mainHwnd,
-		fmt.Sprintf("Version %s is available.\n\nOpen the download page?", version),
-		"Update Available",
-		MB_YESNO|MB_ICONQUESTION)
+	className := utf16PtrFromString("ClaudeListDialog")
+	wc := WNDCLASSEXW{
+		Size:       uint32(unsafe.Sizeof(WNDCLASSEXW{})),
+		WndProc:    syscall.NewCallback(listDlgProc),
+		Instance:   syscall.Handle(hInstance),
+		ClassName:  className,
+		Background: syscall.Handle(COLOR_WINDOW + 1),
+	}
+	procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
 
-	if result == IDYES {
-		// Keep showingDialog true while opening browser to prevent
-		// the main window from closing on focus loss. Reset after a delay
-		// since ShellExecute returns before the browser steals focus.
-		showingDialog = true
-		openURL(url)
-		go func() {
-			time.Sleep(2 * time.Second)
-			showingDialog = false
-		}()
-	} else {
-		// User dismissed - don't notify again for this version
-		appConfig.DismissedVersion = version
-		config.Save(appConfig)
+	dpiScale := func(base int) int {
+		return (base * int(currentDPI)) / 96
 	}
-}
+	dlgWidth := dpiScale(560)
+	dlgHeight := dpiScale(360)
+	dlgX, dlgY := dialogOrigin(dlgWidth, dlgHeight)
 
-func openURL(url string) {
-	shell32 := syscall.NewLazyDLL("shell32.dll")
-	shellExecute := shell32.NewProc("ShellExecuteW")
+	procEnableWindow.Call(mainHwnd, 0)
 
-	shellExecute.Call(
+	listDlgResult = -1
+	listDlgHwnd, _, _ = procCreateWindowExW.Call(
 		0,
-		uintptr(unsafe.Pointer(utf16PtrFromString("open"))),
-		uintptr(unsafe.Pointer(utf16PtrFromString(url))),
+		uintptr(unsafe.Pointer(className)),
+		uintptr(unsafe.Pointer(utf16PtrFromString(title))),
+		WS_POPUP|WS_CAPTION|WS_SYSMENU,
+		uintptr(dlgX), uintptr(dlgY),
+		uintptr(dlgWidth), uintptr(dlgHeight),
+		mainHwnd, 0, hInstance, 0,
+	)
+
+	var rect RECT
+	procGetClientRect.Call(listDlgHwnd, uintptr(unsafe.Pointer(&rect)))
+	listDlgListHwnd, _, _ = procCreateWindowExW.Call(
 		0,
+		uintptr(unsafe.Pointer(utf16PtrFromString("LISTBOX"))),
 		0,
-		1,
+		WS_CHILD|WS_VISIBLE|WS_VSCROLL|WS_TABSTOP|LBS_NOTIFY|LBS_NOINTEGRALHEIGHT,
+		0, 0, uintptr(rect.Right), uintptr(rect.Bottom),
+		listDlgHwnd, IDC_LISTDLG_LIST, hInstance, 0,
 	)
-}
+	procSendMessageW.Call(listDlgListHwnd, WM_SETFONT, hFont, 1)
+	for _, item := range items {
+		procSendMessageW.Call(listDlgListHwnd, LB_ADDSTRING, 0,
+			uintptr(unsafe.Pointer(utf16PtrFromString(item))))
+	}
+	if len(items) > 0 {
+		procSendMessageW.Call(listDlgListHwnd, LB_SETCURSEL, 0, 0)
+	}
 
-func toggleSort() {
-	sortByName = !sortByName
+	procShowWindow.Call(listDlgHwnd, SW_SHOW)
+	procUpdateWindow.Call(listDlgHwnd)
+	procSetFocus.Call(listDlgListHwnd)
 
-	if sortByName {
-		procSetWindowTextW.Call(sortBtnHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("By: Name"))))
-		projects.SortByName(allProjects)
-		projects.SortByName(filteredProjects)
-	} else {
-		procSetWindowTextW.Call(sortBtnHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("By: Recent"))))
-		projects.SortByLastUsed(allProjects)
-		projects.SortByLastUsed(filteredProjects)
+	// Modal message loop
+	var msg MSG
+	for {
+		ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if ret == 0 || listDlgHwnd == 0 {
+			break
+		}
+		if msg.Message == WM_KEYDOWN && (msg.WParam == VK_ESCAPE || msg.WParam == VK_RETURN) {
+			if msg.WParam == VK_RETURN {
+				sel, _, _ := procSendMessageW.Call(listDlgListHwnd, LB_GETCURSEL, 0, 0)
+				listDlgResult = int(int32(sel))
+			}
+			procDestroyWindow.Call(listDlgHwnd)
+			listDlgHwnd = 0
+			break
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
 	}
 
-	populateList()
+	procEnableWindow.Call(mainHwnd, 1)
+	return listDlgResult
 }
 
-func onProjectSelected() {
-	sel, _, _ := procSendMessageW.Call(listHwnd, LB_GETCURSEL, 0, 0)
-	if sel == 0xFFFFFFFF || int(sel) >= len(filteredProjects) {
-		return
+func listDlgProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case WM_COMMAND:
+		wmId := wParam & 0xFFFF
+		wmEvent := (wParam >> 16) & 0xFFFF
+		if wmId == IDC_LISTDLG_LIST && wmEvent == LBN_DBLCLK {
+			sel, _, _ := procSendMessageW.Call(listDlgListHwnd, LB_GETCURSEL, 0, 0)
+			listDlgResult = int(int32(sel))
+			procDestroyWindow.Call(hwnd)
+			listDlgHwnd = 0
+		}
+		return 0
+	case WM_CLOSE:
+		procDestroyWindow.Call(hwnd)
+		listDlgHwnd = 0
+		return 0
+	case WM_DESTROY:
+		listDlgHwnd = 0
+		return 0
 	}
 
-	proj := &filteredProjects[sel]
+	ret, _, _ := procDefWindowProcW.Call(hwnd, uintptr(msg), wParam, lParam)
+	return ret
+}
 
-	// Check if project path exists
-	if !proj.PathExists {
-		showMessageBox(mainHwnd,
-			"The project directory no longer exists:\n\n"+proj.Path+"\n\n"+
-				"It may have been moved or deleted.",
-			"Project Not Found", MB_ICONERROR)
-		return
-	}
+var inputDlgHwnd uintptr
+var inputDlgEditHwnd uintptr
 
-	// Show opening indication
-	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString(fmt.Sprintf("Opening %s...", proj.Name)))))
-	procEnableWindow.Call(editHwnd, 0)
-	procEnableWindow.Call(listHwnd, 0)
-	procEnableWindow.Call(sortBtnHwnd, 0)
+const IDC_INPUTDLG_EDIT = 401
 
-	// Open in Windows Terminal
-	// Set flag to prevent close on focus loss during terminal dialogs
+// showInputDialog shows a modal prompt with a one-line text box holding
+// initial. It returns the text entered, or false if the dialog was cancelled.
+func showInputDialog(title, prompt, initial string) (string, bool) {
 	showingDialog = true
-	err := terminal.OpenProject(proj.Path, appConfig.Terminal)
-	showingDialog = false
+	defer func() {
+		showingDialog = false
+		procSetFocus.Call(editHwnd)
+	}()
 
-	if err != nil {
-		// Restore UI on failure
-		procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher"))))
-		procEnableWindow.Call(editHwnd, 1)
+	hInstance, _, _ := procGetModuleHandleW.Call(0)
+
+	className := utf16PtrFromString("ClaudeInputDialog")
+	wc := WNDCLASSEXW{
+		Size:       uint32(unsafe.Sizeof(WNDCLASSEXW{})),
+		WndProc:    syscall.NewCallback(inputDlgProc),
+		Instance:   syscall.Handle(hInstance),
+		ClassName:  className,
+		Background: syscall.Handle(COLOR_WINDOW + 1),
+	}
+	procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+
+	dpiScale := func(base int) int {
+		return (base * int(currentDPI)) / 96
+	}
+	su := func(base int) uintptr {
+		return uintptr(dpiScale(base))
+	}
+	dlgWidth := dpiScale(440)
+	dlgHeight := dpiScale(130)
+	dlgX, dlgY := dialogOrigin(dlgWidth, dlgHeight)
+
+	procEnableWindow.Call(mainHwnd, 0)
+
+	inputDlgHwnd, _, _ = procCreateWindowExW.Call(
+		0,
+		uintptr(unsafe.Pointer(className)),
+		uintptr(unsafe.Pointer(utf16PtrFromString(title))),
+		WS_POPUP|WS_CAPTION|WS_SYSMENU,
+		uintptr(dlgX), uintptr(dlgY),
+		uintptr(dlgWidth), uintptr(dlgHeight),
+		mainHwnd, 0, hInstance, 0,
+	)
+
+	promptHwnd, _, _ := procCreateWindowExW.Call(
+		0,
+		uintptr(unsafe.Pointer(utf16PtrFromString("STATIC"))),
+		uintptr(unsafe.Pointer(utf16PtrFromString(prompt))),
+		WS_CHILD|WS_VISIBLE,
+		su(12), su(12), su(400), su(20),
+		inputDlgHwnd, 0, hInstance, 0,
+	)
+	procSendMessageW.Call(promptHwnd, WM_SETFONT, hFont, 1)
+
+	inputDlgEditHwnd, _, _ = procCreateWindowExW.Call(
+		WS_EX_CLIENTEDGE,
+		uintptr(unsafe.Pointer(utf16PtrFromString("EDIT"))),
+		uintptr(unsafe.Pointer(utf16PtrFromString(initial))),
+		WS_CHILD|WS_VISIBLE|WS_TABSTOP|ES_AUTOHSCROLL,
+		su(12), su(38), su(400), su(26),
+		inputDlgHwnd, IDC_INPUTDLG_EDIT, hInstance, 0,
+	)
+	procSendMessageW.Call(inputDlgEditHwnd, WM_SETFONT, hFont, 1)
+	procSendMessageW.Call(inputDlgEditHwnd, EM_SETSEL, 0, negInt(-1))
+
+	procShowWindow.Call(inputDlgHwnd, SW_SHOW)
+	procUpdateWindow.Call(inputDlgHwnd)
+	procSetFocus.Call(inputDlgEditHwnd)
+
+	// Modal message loop
+	text, ok := "", false
+	var msg MSG
+	for {
+		ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if ret == 0 || inputDlgHwnd == 0 {
+			break
+		}
+		if msg.Message == WM_KEYDOWN && (msg.WParam == VK_ESCAPE || msg.WParam == VK_RETURN) {
+			if msg.WParam == VK_RETURN {
+				text, ok = getWindowText(inputDlgEditHwnd), true
+			}
+			procDestroyWindow.Call(inputDlgHwnd)
+			inputDlgHwnd = 0
+			break
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+
+	procEnableWindow.Call(mainHwnd, 1)
+	return text, ok
+}
+
+func inputDlgProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case WM_CLOSE:
+		procDestroyWindow.Call(hwnd)
+		inputDlgHwnd = 0
+		return 0
+	case WM_DESTROY:
+		inputDlgHwnd = 0
+		return 0
+	}
+
+	ret, _, _ := procDefWindowProcW.Call(hwnd, uintptr(msg), wParam, lParam)
+	return ret
+}
+
+func getDlgItem(hwnd uintptr, id uintptr) uintptr {
+	ret, _, _ := procGetDlgItem.Call(hwnd, id)
+	return ret
+}
+
+func getWindowText(hwnd uintptr) string {
+	length, _, _ := procGetWindowTextLengthW.Call(hwnd)
+	if length == 0 {
+		return ""
+	}
+	buf := make([]uint16, length+1)
+	procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), length+1)
+	return syscall.UTF16ToString(buf)
+}
+
+func showUpdateNotification() {
+	version := appConfig.PendingVersion
+	url := appConfig.PendingURL
+
+	// Clear pending so we don't show again
+	appConfig.PendingVersion = ""
+	appConfig.PendingURL = ""
+	config.Save(appConfig)
+
+	result := showMessageBox(mainHwnd,
+		fmt.Sprintf("Version %s is available.\n\nOpen the download page?", version),
+		"Update Available",
+		MB_YESNO|MB_ICONQUESTION)
+
+	if result == IDYES {
+		// Keep showingDialog true while opening browser to prevent
+		// the main window from closing on focus loss. Reset after a delay
+		// since ShellExecute returns before the browser steals focus.
+		showingDialog = true
+		openURL(url)
+		go func() {
+			time.Sleep(2 * time.Second)
+			showingDialog = false
+		}()
+	} else {
+		// User dismissed - don't notify again for this version
+		appConfig.DismissedVersion = version
+		config.Save(appConfig)
+	}
+}
+
+func openURL(url string) {
+	shell32 := syscall.NewLazyDLL("shell32.dll")
+	shellExecute := shell32.NewProc("ShellExecuteW")
+
+	shellExecute.Call(
+		0,
+		uintptr(unsafe.Pointer(utf16PtrFromString("open"))),
+		uintptr(unsafe.Pointer(utf16PtrFromString(url))),
+		0,
+		0,
+		1,
+	)
+}
+
+// Sort modes, in the order toggleSort cycles through them
+const (
+	sortByRecent = iota
+	sortByFrecency
+	sortByName
+	sortByTokens
+	sortByCost
+	sortModeCount
+)
+
+var sortLabels = [sortModeCount]string{"By: Recent", "By: Frecency", "By: Name", "By: Tokens", "By: Cost"}
+
+func toggleSort() {
+	sortMode = (sortMode + 1) % sortModeCount
+	procSetWindowTextW.Call(sortBtnHwnd, uintptr(unsafe.Pointer(utf16PtrFromString(sortLabels[sortMode]))))
+
+	switch sortMode {
+	case sortByFrecency:
+		loadFrecency()
+	case sortByTokens, sortByCost:
+		loadUsage()
+	}
+	sortProjects(allProjects)
+	// Transcript hits stay in their own order
+	if transcriptHits == nil {
+		sortProjects(filteredProjects)
+	}
+
+	populateList()
+}
+
+// sortProjects orders a project list by the current sort mode
+func sortProjects(list []projects.Project) {
+	switch sortMode {
+	case sortByFrecency:
+		frecency.Sort(list, projectFrecency)
+	case sortByName:
+		projects.SortByName(list)
+	case sortByTokens:
+		projects.SortByLastUsed(list)
+		sort.SliceStable(list, func(i, j int) bool {
+			return projectTokens(list[i]) > projectTokens(list[j])
+		})
+	case sortByCost:
+		projects.SortByLastUsed(list)
+		sort.SliceStable(list, func(i, j int) bool {
+			return projectCost(list[i]) > projectCost(list[j])
+		})
+	default:
+		projects.SortByLastUsed(list)
+	}
+	projects.NewLast(list)
+	projects.PinFirst(list, appConfig.IsPinned)
+}
+
+// loadUsage totals the token usage of every project, by session and model,
+// in the background, once, and sorts the list again when the totals are in. Transcripts that were
+// scanned before are served from the usage cache.
+func loadUsage() {
+	if projectUsage != nil {
+		return
+	}
+	if usageLoading {
+		usageStale = true
+		return
+	}
+	usageLoading = true
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Counting tokens..."))))
+
+	list := append([]projects.Project(nil), allProjects...)
+	go func() {
+		cache := usage.LoadCache(usageCachePath())
+		totals := make(map[string]usage.ProjectUsage, len(list))
+		for _, report := range usage.ForProjects(list, cache, usage.Filter{}) {
+			totals[report.Project.Key()] = report
+		}
+		// Failing to write the cache only costs speed next time
+		cache.Save(true)
+		runOnUI(func() { applyUsage(totals) })
+	}()
+}
+
+// applyUsage takes the totals counted by loadUsage, and sorts the list by
+// them if it is sorted by usage
+func applyUsage(totals map[string]usage.ProjectUsage) {
+	usageLoading = false
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher"))))
+	if usageStale {
+		// Counted before the last change to the projects
+		usageStale = false
+		loadUsage()
+		return
+	}
+	projectUsage = totals
+	usagePricing = usage.DefaultPricing().With(appConfig.Pricing)
+	if sortMode == sortByTokens || sortMode == sortByCost {
+		resort()
+	}
+}
+
+// resort sorts the list again, keeping the search and the selection
+func resort() {
+	selected := ""
+	if proj := selectedProject(); proj != nil {
+		selected = proj.Path
+	}
+	sortProjects(allProjects)
+	onSearchChanged()
+	selectPath(selected)
+}
+
+// runOnUI has fn run on the GUI thread. It is safe to call from any
+// goroutine once the window exists.
+func runOnUI(fn func()) {
+	uiCallsMu.Lock()
+	uiCalls = append(uiCalls, fn)
+	uiCallsMu.Unlock()
+	procPostMessageW.Call(sharedHwnd.Load(), WM_APP_CALL, 0, 0)
+}
+
+// runUICalls runs the functions handed to runOnUI
+func runUICalls() {
+	uiCallsMu.Lock()
+	calls := uiCalls
+	uiCalls = nil
+	uiCallsMu.Unlock()
+	for _, fn := range calls {
+		fn()
+	}
+}
+
+// usageCachePath returns the path of the usage cache, or "" to keep none
+func usageCachePath() string {
+	dir, err := config.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, usage.CacheFileName)
+}
+
+// loadFrecency scores every project by how often and how recently it was
+// used in the background, once, and sorts the list again when the scores
+// are in
+func loadFrecency() {
+	if projectFrecency != nil {
+		return
+	}
+	if frecencyLoading {
+		frecencyStale = true
+		return
+	}
+	frecencyLoading = true
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Ranking projects..."))))
+
+	list := append([]projects.Project(nil), allProjects...)
+	halfLife := frecency.HalfLife(appConfig.FrecencyHalfLifeDays)
+	go func() {
+		history := frecency.LoadHistory(historyPath())
+		scores := frecency.Scores(list, history, time.Now(), halfLife)
+		runOnUI(func() { applyFrecency(scores) })
+	}()
+}
+
+// applyFrecency takes the scores made by loadFrecency, and sorts the list by
+// them if it is sorted by frecency
+func applyFrecency(scores map[string]float64) {
+	frecencyLoading = false
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher"))))
+	if frecencyStale {
+		frecencyStale = false
+		loadFrecency()
+		return
+	}
+	projectFrecency = scores
+	if sortMode == sortByFrecency {
+		resort()
+	}
+}
+
+// historyPath returns the path of the launch history, or "" to keep none
+func historyPath() string {
+	dir, err := config.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, frecency.HistoryFileName)
+}
+
+// notesPath returns the path of the project notes, or "" to keep none
+func notesPath() string {
+	dir, err := config.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, notes.FileName)
+}
+
+// projectTokens returns the total tokens a project has used
+func projectTokens(p projects.Project) int64 {
+	return projectUsage[p.Key()].Usage.Total().Total()
+}
+
+// projectCost returns the estimated cost of a project's usage
+func projectCost(p projects.Project) float64 {
+	cost, _ := projectUsage[p.Key()].Usage.Cost(usagePricing)
+	return cost
+}
+
+// searchTranscripts lists the sessions with messages matching the query, in
+// place of the projects. The index is brought up to date in the background
+// on first use and after the transcripts change; until the first build is
+// in, nothing is listed.
+func searchTranscripts(query string) {
+	if transcriptIndex == nil || transcriptIndexOld {
+		loadTranscriptIndex()
+	}
+	if transcriptIndex == nil {
+		transcriptHits = []search.Hit{}
+		filteredProjects = nil
+		populateList()
+		return
+	}
+
+	transcriptHits = transcriptIndex.Search(listedProjects(), query, maxTranscriptHits)
+	if transcriptHits == nil {
+		transcriptHits = []search.Hit{}
+	}
+	filteredProjects = make([]projects.Project, len(transcriptHits))
+	for i, hit := range transcriptHits {
+		filteredProjects[i] = hit.Project
+	}
+	populateList()
+}
+
+// loadTranscriptIndex brings the transcript index up to date in the
+// background, unless that is already running. The index being searched
+// isn't touched meanwhile: the build works on its own copy, read from the
+// index file.
+func loadTranscriptIndex() {
+	if transcriptIndexing {
+		return
+	}
+	transcriptIndexing = true
+	transcriptIndexOld = false
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Indexing transcripts..."))))
+
+	list := append([]projects.Project(nil), allProjects...)
+	go func() {
+		var indexPath string
+		if dir, err := config.Dir(); err == nil {
+			indexPath = filepath.Join(dir, search.IndexFileName)
+		}
+		ix := search.Load(indexPath)
+		ix.Update(list)
+		// Failing to write the index only costs speed next time
+		ix.Save()
+		runOnUI(func() { applyTranscriptIndex(ix) })
+	}()
+}
+
+// applyTranscriptIndex takes the index built by loadTranscriptIndex, and
+// runs the transcript search being shown again against it
+func applyTranscriptIndex(ix *search.Index) {
+	transcriptIndexing = false
+	transcriptIndex = ix
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher"))))
+	if transcriptHits != nil {
+		// Starts another build if the transcripts changed meanwhile
+		onSearchChanged()
+	}
+}
+
+// maxTranscriptHits caps the sessions listed by a transcript search
+const maxTranscriptHits = 100
+
+// togglePin pins or unpins the selected project, keeping it selected
+func togglePin() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	appConfig.TogglePin(proj.Path)
+	saveProjectSettings(proj.Path)
+}
+
+// toggleHidden hides the selected project, or shows it again when hidden
+// projects are listed
+func toggleHidden() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	path := proj.Path
+	if proj.Hidden && !appConfig.IsHidden(path) {
+		showMessageBox(mainHwnd, proj.DisplayName()+" is hidden by an ignore pattern in the config.\n\n"+
+			"Edit ignore_patterns to show it again.", "Hidden Project", 0)
+		return
+	}
+	appConfig.ToggleHidden(path)
+	saveProjectSettings(path)
+}
+
+// toggleShowHidden lists the hidden projects as well, or leaves them out
+// again
+func toggleShowHidden() {
+	showHidden = !showHidden
+	selected := ""
+	if proj := selectedProject(); proj != nil {
+		selected = proj.Path
+	}
+	onSearchChanged()
+	selectPath(selected)
+}
+
+// listedProjects returns the projects to list, leaving out the hidden ones
+// unless they are shown
+func listedProjects() []projects.Project {
+	if showHidden {
+		return allProjects
+	}
+	return projects.Visible(allProjects)
+}
+
+// showProjectDetails shows the selected project's path, git state and the
+// Claude Code configuration found in its directory
+func showProjectDetails() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	lines := []string{proj.Path}
+	if label := proj.Git.Label(); label != "" {
+		lines = append(lines, "Git: "+label)
+	}
+	if len(proj.Tags) > 0 {
+		lines = append(lines, "Tags: #"+strings.Join(proj.Tags, " #"))
+	}
+	if proj.Note != "" {
+		lines = append(lines, "Note: "+proj.Note)
+	}
+	lines = append(lines, "Last used: "+formatLastUsed(proj.LastUsed))
+	if projectUsage == nil {
+		// Counted in the background, for the next time
+		if !usageLoading {
+			loadUsage()
+		}
+		lines = append(lines, "Models: counting tokens...")
+	} else if pu := projectUsage[proj.Key()]; len(pu.Usage) > 0 {
+		lines = append(lines, "Last model: "+pu.LastModel)
+		for _, model := range pu.Usage.Models() {
+			t := pu.Usage[model]
+			lines = append(lines, fmt.Sprintf("    %s: %d messages, %s tokens", model, t.Messages, usage.FormatTokens(t.Total())))
+		}
+	}
+	lines = append(lines, "")
+
+	c := proj.Context
+	if c == nil {
+		lines = append(lines, "The directory isn't available, so its Claude Code configuration is unknown.")
+	} else {
+		yesNo := map[bool]string{true: "yes", false: "missing"}
+		lines = append(lines, "CLAUDE.md: "+yesNo[c.ClaudeMD])
+		var settings []string
+		if c.Settings {
+			settings = append(settings, "settings.json")
+		}
+		if c.LocalSettings {
+			settings = append(settings, "settings.local.json")
+		}
+		lines = append(lines, "Settings: "+listOrNone(settings))
+		servers := listOrNone(c.MCPServers)
+		if c.MCP && len(c.MCPServers) == 0 {
+			servers = ".mcp.json names no servers"
+		}
+		lines = append(lines, "MCP servers: "+servers)
+		var commands []string
+		for _, name := range c.Commands {
+			commands = append(commands, "/"+name)
+		}
+		lines = append(lines, "Commands: "+listOrNone(commands), "Agents: "+listOrNone(c.Agents))
+	}
+
+	if cfg := proj.Config; cfg != nil {
+		trust := map[bool]string{true: "accepted", false: "not accepted"}
+		lines = append(lines, "", "Trust dialog: "+trust[cfg.Trusted])
+		if len(cfg.MCPServers) > 0 {
+			lines = append(lines, "Local MCP servers: "+strings.Join(cfg.MCPServers, ", "))
+		}
+		if len(cfg.DisabledMCPServers) > 0 {
+			lines = append(lines, "Disabled MCP servers: "+strings.Join(cfg.DisabledMCPServers, ", "))
+		}
+		lines = append(lines, "Allowed tools: "+listOrNone(cfg.AllowedTools))
+		if s := cfg.LastSession; s != nil {
+			duration := (time.Duration(s.DurationMS) * time.Millisecond).Round(time.Second)
+			lines = append(lines, fmt.Sprintf("Last session: $%.2f, %s, +%d -%d lines",
+				s.Cost, duration, s.LinesAdded, s.LinesRemoved))
+		}
+	}
+	showMessageBox(mainHwnd, strings.Join(lines, "\n"), proj.DisplayName(), 0)
+}
+
+// listOrNone joins names with commas, or says there are none
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// editAlias asks for the name the selected project is shown with
+func editAlias() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	path := proj.Path
+	alias, ok := showInputDialog("Alias - "+proj.Name,
+		"Show "+proj.Name+" as (leave empty for the folder name):", proj.Alias)
+	if !ok {
+		return
+	}
+	appConfig.SetAlias(path, alias)
+	saveProjectSettings(path)
+}
+
+// editNote asks for the note shown under the selected project
+func editNote() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	path := proj.Path
+	text, ok := showInputDialog("Note - "+proj.DisplayName(),
+		"A line shown under the project and matched by the search (leave empty to remove):", proj.Note)
+	if !ok {
+		return
+	}
+	// Read the notes again, as the note command may have changed them
+	store := notes.Load(notesPath())
+	store.Set(path, text, time.Now())
+	if err := store.Save(); err != nil {
+		showMessageBox(mainHwnd, "Failed to save the note: "+err.Error(), "Error", MB_ICONERROR)
+	}
+	refreshLabels(path)
+}
+
+// editTags asks for the tags of the selected project
+func editTags() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	path := proj.Path
+	text, ok := showInputDialog("Tags - "+proj.DisplayName(),
+		"Tags, separated by spaces or commas. Filter by them with tag:name.", strings.Join(proj.Tags, " "))
+	if !ok {
+		return
+	}
+	appConfig.SetTags(path, config.ParseTags(text))
+	saveProjectSettings(path)
+}
+
+// saveProjectSettings saves a changed pin, alias, tags or hiding and updates the
+// list, keeping the project at path selected
+func saveProjectSettings(path string) {
+	if err := config.Save(appConfig); err != nil {
+		showMessageBox(mainHwnd, "Failed to save the config: "+err.Error(), "Error", MB_ICONERROR)
+	}
+	refreshLabels(path)
+}
+
+// refreshLabels shows changed labels, keeping the project at path selected
+func refreshLabels(path string) {
+	applyLabels(allProjects)
+	sortProjects(allProjects)
+	onSearchChanged()
+	selectPath(path)
+}
+
+// selectPath highlights the project at path, if it is listed
+func selectPath(path string) {
+	for i, p := range filteredProjects {
+		if p.Path == path {
+			procSendMessageW.Call(listHwnd, LB_SETCURSEL, uintptr(i), 0)
+			return
+		}
+	}
+}
+
+// scanOptions returns where the config says to look for projects on disk
+func scanOptions() projects.ScanOptions {
+	return projects.ScanOptions{
+		Roots:  appConfig.ScanRoots,
+		Depth:  appConfig.ScanDepth,
+		Ignore: appConfig.ScanIgnore,
+	}
+}
+
+// applyLabels sets the aliases, tags and hiding from the config, and the
+// notes, on the projects
+func applyLabels(list []projects.Project) {
+	for i := range list {
+		list[i].Alias = appConfig.Alias(list[i].Path)
+		list[i].Tags = appConfig.ProjectTags(list[i].Path)
+	}
+	// Invalid patterns were reported at startup
+	rules, _ := projects.NewHideRules(appConfig.IgnorePatterns, appConfig.Hidden)
+	rules.Apply(list)
+	notes.Load(notesPath()).Apply(list)
+}
+
+// toggleGroupWorktrees switches between the flat list and the view with git
+// worktrees nested under their main checkout
+func toggleGroupWorktrees() {
+	groupWorktrees = !groupWorktrees
+	// Rebuild from the sorted or filtered order so ungrouping restores it
+	onSearchChanged()
+}
+
+func onProjectSelected() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	// A transcript hit resumes the session that matched
+	sessionID := ""
+	sel, _, _ := procSendMessageW.Call(listHwnd, LB_GETCURSEL, 0, 0)
+	if int(sel) < len(transcriptHits) {
+		sessionID = transcriptHits[sel].SessionID
+	}
+	launchProject(proj, sessionID)
+}
+
+// selectedProject returns the project highlighted in the list, or nil
+func selectedProject() *projects.Project {
+	sel, _, _ := procSendMessageW.Call(listHwnd, LB_GETCURSEL, 0, 0)
+	if sel == 0xFFFFFFFF || int(sel) >= len(filteredProjects) {
+		return nil
+	}
+	return &filteredProjects[sel]
+}
+
+// showSessionBrowser lists the sessions of the selected project and resumes the chosen one
+func showSessionBrowser() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	if s, ok := chooseSession(proj, "Sessions"); ok {
+		launchProject(proj, s.ID)
+	}
+}
+
+// chooseSession lists the sessions of a project in a dialog titled after
+// what they are chosen for, and returns the chosen one
+func chooseSession(proj *projects.Project, title string) (projects.Session, bool) {
+	sessions, err := projects.LoadSessions(*proj)
+	if err != nil || len(sessions) == 0 {
+		showMessageBox(mainHwnd, "No sessions were found for "+proj.DisplayName()+".", title, 0)
+		return projects.Session{}, false
+	}
+
+	// The model each session last ran on, for spotting ones on older models.
+	// Until the usage is counted, in the background, the models are left out.
+	if projectUsage == nil && !usageLoading {
+		loadUsage()
+	}
+	lastModels := make(map[string]string)
+	for _, s := range projectUsage[proj.Key()].Sessions {
+		lastModels[s.ID] = s.LastModel
+	}
+	items := make([]string, len(sessions))
+	for i, s := range sessions {
+		items[i] = fmt.Sprintf("%s  \u00b7  %d messages  \u00b7  %s",
+			formatLastUsed(s.Ended), s.MessageCount, s.Title())
+		if model := lastModels[s.ID]; model != "" {
+			items[i] += "  \u00b7  " + model
+		}
+	}
+
+	idx := showListDialog(title+" - "+proj.DisplayName(), items)
+	if idx < 0 || idx >= len(sessions) {
+		return projects.Session{}, false
+	}
+	return sessions[idx], true
+}
+
+// exportSession writes a session of the selected project as Markdown or
+// HTML to the exports folder, with or without tool output and thinking, and
+// offers to open it
+func exportSession() {
+	proj := selectedProject()
+	if proj == nil {
+		return
+	}
+	s, ok := chooseSession(proj, "Export Session")
+	if !ok {
+		return
+	}
+	formats := []string{export.FormatMarkdown, export.FormatHTML}
+	idx := showListDialog("Export Format", []string{"Markdown (.md)", "HTML (.html)"})
+	if idx < 0 || idx >= len(formats) {
+		return
+	}
+	contents := []export.Options{{}, {NoToolOutput: true}, {NoThinking: true}, {NoToolOutput: true, NoThinking: true}}
+	opt := showListDialog("Export Contents", []string{
+		"Everything",
+		"Without tool output",
+		"Without thinking",
+		"Without tool output and thinking",
+	})
+	if opt < 0 || opt >= len(contents) {
+		return
+	}
+
+	path, err := writeExport(*proj, s, formats[idx], contents[opt])
+	if err != nil {
+		showMessageBox(mainHwnd, "Failed to export the session: "+err.Error(), "Error", MB_ICONERROR)
+		return
+	}
+	if showMessageBox(mainHwnd, "The session was exported to:\n"+path+"\n\nOpen it now?",
+		"Export Session", MB_YESNO|MB_ICONQUESTION) == IDYES {
+		openURL(path)
+	}
+}
+
+// writeExport writes a session to the exports folder of the switcher, named
+// after the project and the start of the session ID, and returns its path
+func writeExport(proj projects.Project, s projects.Session, format string, opts export.Options) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "exports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	t, err := export.Load(proj, s)
+	if err != nil {
+		return "", err
+	}
+
+	id := s.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, filepath.Base(proj.Path))
+	path := filepath.Join(dir, name+"-"+id+export.Extension(format))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := export.Write(f, format, t, opts); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// launchProject opens the project in the configured terminal, resuming the
+// given session if sessionID is set, and closes the switcher on success
+func launchProject(proj *projects.Project, sessionID string) {
+	// Check if project path exists, offering to follow it if it moved.
+	// Unknown paths (e.g. a slow network share) are attempted anyway.
+	if proj.PathState == projects.PathMissing {
+		moved, ok := relocateProject(proj)
+		if !ok {
+			return
+		}
+		proj = moved
+	}
+
+	// Show opening indication
+	procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString(fmt.Sprintf("Opening %s...", proj.DisplayName())))))
+	procEnableWindow.Call(editHwnd, 0)
+	procEnableWindow.Call(listHwnd, 0)
+	procEnableWindow.Call(sortBtnHwnd, 0)
+
+	// Open in Windows Terminal
+	// Set flag to prevent close on focus loss during terminal dialogs
+	showingDialog = true
+	var err error
+	if sessionID != "" {
+		err = terminal.OpenSession(proj.Path, proj.ConfigDir(), sessionID, appConfig.Terminal)
+	} else {
+		err = terminal.OpenProject(proj.Path, proj.ConfigDir(), appConfig.Terminal)
+	}
+	showingDialog = false
+
+	if err != nil {
+		// Restore UI on failure
+		procSetWindowTextW.Call(mainHwnd, uintptr(unsafe.Pointer(utf16PtrFromString("Claude Code Switcher"))))
+		procEnableWindow.Call(editHwnd, 1)
 		procEnableWindow.Call(listHwnd, 1)
 		procEnableWindow.Call(sortBtnHwnd, 1)
 		showMessageBox(mainHwnd, "Failed to open terminal: "+err.Error(), "Error", 0)
 		return
 	}
 
+	// Failing to record the launch only affects the frecency ranking
+	frecency.LoadHistory(historyPath()).Record(proj.Path, time.Now())
 	procDestroyWindow.Call(mainHwnd)
 }
 
+// relocateProject offers to move the history of a project whose directory
+// is gone to a folder it may have moved to. It returns the relocated project,
+// or false if there was nowhere to go or the user declined.
+func relocateProject(proj *projects.Project) (*projects.Project, bool) {
+	candidates := projects.FindMoved(*proj, scanOptions())
+	if len(candidates) == 0 {
+		hint := ""
+		if len(appConfig.ScanRoots) == 0 {
+			hint = " Add the folders you keep projects in to scan_roots in the config to search them."
+		}
+		showMessageBox(mainHwnd,
+			"The project directory no longer exists:\n\n"+proj.Path+"\n\n"+
+				"It may have been moved or deleted. No folder with the same name or git origin was found."+hint+
+				"\n\nPress F8 to prune the data of deleted projects.",
+			"Project Not Found", MB_ICONERROR)
+		return nil, false
+	}
+
+	items := make([]string, len(candidates))
+	for i, c := range candidates {
+		items[i] = c.Path + "  \u00b7  " + c.Reason()
+	}
+	idx := showListDialog("Moved? Choose the new location of "+proj.DisplayName(), items)
+	if idx < 0 || idx >= len(candidates) {
+		return nil, false
+	}
+	target := candidates[idx].Path
+	if showMessageBox(mainHwnd,
+		"Move the Claude Code history of\n\n"+proj.Path+"\n\nto\n\n"+target+"?\n\n"+
+			"Its sessions can then be resumed in the new location.",
+		"Relocate Project", MB_YESNO|MB_ICONQUESTION) != IDYES {
+		return nil, false
+	}
+
+	var diag projects.Diagnostics
+	moved, err := projects.Relocate(*proj, target, &diag)
+	if err != nil {
+		showMessageBox(mainHwnd, "Failed to relocate the project: "+err.Error(), "Error", MB_ICONERROR)
+		return nil, false
+	}
+	if len(diag.Problems) > 0 {
+		var text strings.Builder
+		text.WriteString("The history was moved, but these files were left behind:\n")
+		for _, problem := range diag.Problems {
+			text.WriteString("\n" + problem.String())
+		}
+		showMessageBox(mainHwnd, text.String(), "Relocate Project", MB_ICONWARNING)
+	}
+
+	// Pins, aliases, tags and the note follow the project
+	appConfig.MoveProject(proj.Path, moved.Path)
+	if err := config.Save(appConfig); err != nil {
+		showMessageBox(mainHwnd, "Failed to save the config: "+err.Error(), "Error", MB_ICONERROR)
+	}
+	store := notes.Load(notesPath())
+	store.Move(proj.Path, moved.Path)
+	if err := store.Save(); err != nil {
+		showMessageBox(mainHwnd, "Failed to save the notes: "+err.Error(), "Error", MB_ICONERROR)
+	}
+	moved.Alias = appConfig.Alias(moved.Path)
+	moved.Tags = appConfig.ProjectTags(moved.Path)
+	moved.Note = store.Get(moved.Path)
+
+	// The new location may already be listed on its own; it now holds both.
+	// proj may point into the list being rewritten.
+	old := *proj
+	kept := allProjects[:0]
+	for _, p := range allProjects {
+		if p.Root == old.Root && strings.EqualFold(p.Path, moved.Path) {
+			continue
+		}
+		if p.Root == old.Root && strings.EqualFold(p.Path, old.Path) {
+			p = moved
+		}
+		kept = append(kept, p)
+	}
+	allProjects = kept
+	onSearchChanged()
+	return &moved, true
+}
+
+// maxPruneListed caps the folders named in the prune confirmation
+const maxPruneListed = 12
+
+// pruneOrphans offers to move the data of projects whose directory is gone
+// to the switcher's trash, and drops them from the list
+func pruneOrphans() {
+	// Pinned projects are kept, even when their directory is gone for good
+	var candidates []projects.Project
+	for _, p := range listedProjects() {
+		if !appConfig.IsPinned(p.Path) {
+			candidates = append(candidates, p)
+		}
+	}
+	orphans := projects.Orphans(candidates)
+	if len(orphans) == 0 {
+		showMessageBox(mainHwnd, "No orphaned project data found.\n\n"+
+			"Projects are orphaned when their directory no longer exists.", "Prune", 0)
+		return
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		showMessageBox(mainHwnd, "Failed to locate the trash: "+err.Error(), "Error", MB_ICONERROR)
+		return
+	}
+
+	var total int64
+	var lines []string
+	for i, o := range orphans {
+		total += o.Size
+		if i < maxPruneListed {
+			lines = append(lines, fmt.Sprintf("%s  (%d sessions, %s)", o.Project.Path, o.Sessions, trash.FormatSize(o.Size)))
+		}
+	}
+	if len(orphans) > maxPruneListed {
+		lines = append(lines, fmt.Sprintf("...and %d more", len(orphans)-maxPruneListed))
+	}
+	retention := trash.Retention(appConfig.TrashRetentionDays)
+	text := fmt.Sprintf("%d Claude Code data folders (%s) belong to projects whose directory no longer exists:\n\n%s\n\n"+
+		"Move them to the switcher's trash? They can be restored with\n"+
+		"'claude-code-switcher trash restore' for %d days.",
+		len(orphans), trash.FormatSize(total), strings.Join(lines, "\n"), int(retention.Hours()/24))
+	if showMessageBox(mainHwnd, text, "Prune Orphaned Projects", MB_YESNO|MB_ICONQUESTION) != IDYES {
+		return
+	}
+
+	tr := trash.Open(filepath.Join(dir, trash.DirName))
+	tr.Purge(time.Now().Add(-retention))
+	moved, err := tr.Prune(orphans)
+
+	// A project under another data root may have the same path, and stays
+	byDir := make(map[string]string, len(orphans))
+	for _, o := range orphans {
+		byDir[fsutil.PathKey(o.Dir)] = o.Project.Key()
+	}
+	gone := make(map[string]bool, len(moved))
+	for _, e := range moved {
+		gone[byDir[fsutil.PathKey(e.OriginalDir)]] = true
+	}
+	kept := allProjects[:0]
+	for _, p := range allProjects {
+		if !gone[p.Key()] {
+			kept = append(kept, p)
+		}
+	}
+	allProjects = kept
+	onSearchChanged()
+
+	if err != nil {
+		showMessageBox(mainHwnd, "Some folders could not be fully moved to the trash:\n\n"+err.Error(), "Prune", MB_ICONERROR)
+	}
+}
+
 // Win32 helper functions
+func isKeyDown(vk uintptr) bool {
+	state, _, _ := procGetKeyState.Call(vk)
+	return state&0x8000 != 0
+}
+
 func setBkColor(hdc syscall.Handle, color uint32) {
 	procSetBkColor := gdi32.NewProc("SetBkColor")
 	procSetBkColor.Call(uintptr(hdc), uintptr(color))
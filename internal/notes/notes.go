@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package notes keeps the short free-text notes attached to projects, such
+// as "waiting on PR #412", in the switcher's own directory.
+package notes
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// FileName is the name of the notes file in the switcher's directory
+const FileName = "notes.json"
+
+// MaxLength is the longest note kept, in characters; a note is meant to fit
+// on one line of the list
+const MaxLength = 200
+
+// Store holds the notes of all projects, keyed by Key of their path
+type Store struct {
+	Notes map[string]Note `json:"notes"`
+
+	path string
+}
+
+// Note is the note of one project
+type Note struct {
+	Path    string    `json:"path"` // The project path as it was given
+	Text    string    `json:"text"`
+	Updated time.Time `json:"updated"`
+}
+
+// Load reads the notes at path. A missing or unreadable file yields an
+// empty store; an empty path yields one that is never saved.
+func Load(path string) *Store {
+	s := &Store{path: path}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, s)
+		}
+	}
+	if s.Notes == nil {
+		s.Notes = make(map[string]Note)
+	}
+	return s
+}
+
+// Get returns the note of the project at path, or ""
+func (s *Store) Get(path string) string {
+	return s.Notes[fsutil.PathKey(path)].Text
+}
+
+// Set sets the note of the project at path, and returns it as stored: on
+// one line, without surrounding space and cut to MaxLength. An empty note
+// removes it. The store isn't saved.
+func (s *Store) Set(path, text string, now time.Time) string {
+	text = Clean(text)
+	if text == "" {
+		delete(s.Notes, fsutil.PathKey(path))
+		return ""
+	}
+	s.Notes[fsutil.PathKey(path)] = Note{Path: path, Text: text, Updated: now}
+	return text
+}
+
+// Clean puts a note on one line, drops surrounding space and cuts it to
+// MaxLength characters
+func Clean(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > MaxLength {
+		text = strings.TrimSpace(string([]rune(text)[:MaxLength]))
+	}
+	return text
+}
+
+// Move gives the note of the project at oldPath to newPath, for a project
+// that was relocated. A note newPath already has comes first.
+func (s *Store) Move(oldPath, newPath string) {
+	note, ok := s.Notes[fsutil.PathKey(oldPath)]
+	if !ok || fsutil.PathKey(oldPath) == fsutil.PathKey(newPath) {
+		return
+	}
+	delete(s.Notes, fsutil.PathKey(oldPath))
+	if existing := s.Get(newPath); existing != "" {
+		note.Text = existing + " " + note.Text
+	}
+	s.Set(newPath, note.Text, note.Updated)
+}
+
+// Apply sets the Note of every project in list
+func (s *Store) Apply(list []projects.Project) {
+	for i := range list {
+		list[i].Note = s.Get(list[i].Path)
+	}
+}
+
+// Save writes the store back to the file it was loaded from
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(s.path, data)
+}
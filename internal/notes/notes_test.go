@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package notes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	s := Load(path)
+	if got := s.Set("/work/api/", "  waiting on\n PR #412 ", now); got != "waiting on PR #412" {
+		t.Errorf("Set() = %q, want the note on one line", got)
+	}
+	if got := s.Set("/work/web", strings.Repeat("x", MaxLength+10), now); len(got) != MaxLength {
+		t.Errorf("Set() kept %d characters, want %d", len(got), MaxLength)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	s = Load(path)
+	if got := s.Get("/work/api"); got != "waiting on PR #412" {
+		t.Errorf("Get() after reload = %q", got)
+	}
+	if note := s.Notes[fsutil.PathKey("/work/api")]; !note.Updated.Equal(now) {
+		t.Errorf("note updated %v, want %v", note.Updated, now)
+	}
+
+	// Relocating joins the note with one already on the new path
+	s.Set("/src/api", "moved", now)
+	s.Move("/work/api", "/src/api")
+	if s.Get("/work/api") != "" || s.Get("/src/api") != "moved waiting on PR #412" {
+		t.Errorf("after Move() = %q, %q", s.Get("/work/api"), s.Get("/src/api"))
+	}
+
+	list := []projects.Project{{Path: "/src/api"}, {Path: "/other"}}
+	s.Apply(list)
+	if list[0].Note != "moved waiting on PR #412" || list[1].Note != "" {
+		t.Errorf("Apply() = %+v", list)
+	}
+
+	s.Set("/src/api", " ", now)
+	if _, ok := s.Notes[fsutil.PathKey("/src/api")]; ok {
+		t.Error("an empty note wasn't removed")
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/config"
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// cacheVersion is bumped whenever the cache format or the way cached values
+// are derived changes. Cache files with any other version are discarded.
+const cacheVersion = 2
+
+// projectCache is the on-disk index of previously loaded projects, keyed by
+// the full path of each project folder
+type projectCache struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+
+	mu    sync.Mutex      // guards everything below and Entries during a load
+	seen  map[string]bool // entries looked up or stored during this load
+	dirty bool
+}
+
+// cacheEntry holds the parsed result for one project folder, valid for as
+// long as the folder's fingerprint is unchanged
+type cacheEntry struct {
+	Fingerprint string    `json:"fingerprint"`
+	Path        string    `json:"path"`
+	LastUsed    time.Time `json:"lastUsed"`
+	// RemoteURL is the git origin last seen at Path. It outlives changes to
+	// the fingerprint, so a repository can be recognised after it moved.
+	RemoteURL string `json:"remoteUrl,omitempty"`
+}
+
+// defaultCachePath returns the location of the project cache file
+func defaultCachePath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "projects-cache.json"), nil
+}
+
+// loadCache reads the cache file, returning an empty cache if it is missing,
+// unreadable or written by a different cache version
+func loadCache(path string) *projectCache {
+	cache := &projectCache{
+		Version: cacheVersion,
+		Entries: make(map[string]cacheEntry),
+		seen:    make(map[string]bool),
+	}
+	if path == "" {
+		return cache
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	var stored projectCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Entries == nil {
+		cache.dirty = true
+		return cache
+	}
+	cache.Entries = stored.Entries
+	return cache
+}
+
+// lookup returns the cached entry for projectDir if its fingerprint matches
+func (c *projectCache) lookup(projectDir, fingerprint string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[projectDir]
+	if !ok || entry.Fingerprint != fingerprint {
+		return cacheEntry{}, false
+	}
+	c.seen[projectDir] = true
+	return entry, true
+}
+
+// store records a freshly parsed entry, keeping the remembered git origin
+func (c *projectCache) store(projectDir string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry.RemoteURL == "" {
+		entry.RemoteURL = c.Entries[projectDir].RemoteURL
+	}
+	c.Entries[projectDir] = entry
+	c.seen[projectDir] = true
+	c.dirty = true
+}
+
+// remoteURL returns the git origin remembered for projectDir, if any
+func (c *projectCache) remoteURL(projectDir string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Entries[projectDir].RemoteURL
+}
+
+// setRemoteURL remembers the git origin of the project in projectDir
+func (c *projectCache) setRemoteURL(projectDir, url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[projectDir]
+	if !ok || entry.RemoteURL == url {
+		return
+	}
+	entry.RemoteURL = url
+	c.Entries[projectDir] = entry
+	c.dirty = true
+}
+
+// save drops entries for folders that no longer exist under the given
+// roots, which this load read, and writes the cache if anything changed.
+// Entries of other roots are kept for the runs that load them. The file is
+// replaced atomically so a concurrent switcher never reads a partial cache.
+func (c *projectCache) save(path string, roots []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loaded := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		loaded[fsutil.PathKey(filepath.Join(root, "projects"))] = true
+	}
+	for name := range c.Entries {
+		if !c.seen[name] && loaded[fsutil.PathKey(filepath.Dir(name))] {
+			delete(c.Entries, name)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data)
+}
+
+// fingerprintDir summarises a project folder's modification time and the
+// name, size and modification time of every file in it. Any session being
+// added, removed or appended to changes the fingerprint.
+func fingerprintDir(projectDir string) (string, error) {
+	info, err := os.Stat(projectDir)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\n", info.ModTime().UnixNano())
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", entry.Name(), fi.Size(), fi.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
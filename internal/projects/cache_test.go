@@ -0,0 +1,170 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestProject creates a project folder under root/projects with a
+// sessions-index.json and the given number of session files
+func writeTestProject(tb testing.TB, root, projectPath string, sessions int) string {
+	tb.Helper()
+
+	projectDir := filepath.Join(root, "projects", encodePath(projectPath))
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		tb.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	index := SessionsIndex{Version: 1, OriginalPath: projectPath}
+	for i := 0; i < sessions; i++ {
+		id := fmt.Sprintf("session-%d", i)
+		index.Entries = append(index.Entries, SessionEntry{
+			SessionID:   id,
+			Modified:    "2026-01-26T10:00:00Z",
+			ProjectPath: projectPath,
+		})
+		line := fmt.Sprintf(`{"type":"user","cwd":%q,"sessionId":%q}`+"\n", projectPath, id)
+		if err := os.WriteFile(filepath.Join(projectDir, id+".jsonl"), []byte(line), 0644); err != nil {
+			tb.Fatalf("Failed to write session file: %v", err)
+		}
+	}
+	data, _ := json.Marshal(index)
+	if err := os.WriteFile(filepath.Join(projectDir, "sessions-index.json"), data, 0644); err != nil {
+		tb.Fatalf("Failed to write sessions index: %v", err)
+	}
+	return projectDir
+}
+
+func TestProjectCache(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "projects-cache.json")
+	projectDir := writeTestProject(t, root, "/work/cached", 2)
+
+	if _, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath}); err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+
+	// Tamper with the cached path: an unchanged folder must be served from the cache
+	cache := loadCache(cachePath)
+	entry, ok := cache.Entries[projectDir]
+	if !ok {
+		t.Fatalf("cache has no entry for %s", projectDir)
+	}
+	entry.Path = "/from/cache"
+	cache.Entries[projectDir] = entry
+	cache.dirty = true
+	cache.seen[projectDir] = true
+	if err := cache.save(cachePath, []string{root}); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	projects, _ := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath})
+	if projects[0].Path != "/from/cache" {
+		t.Errorf("unchanged project Path = %q, want cached %q", projects[0].Path, "/from/cache")
+	}
+
+	// Appending to a session changes the fingerprint and forces a re-parse
+	f, err := os.OpenFile(filepath.Join(projectDir, "session-0.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open session file: %v", err)
+	}
+	f.WriteString(`{"type":"assistant"}` + "\n")
+	f.Close()
+
+	projects, _ = LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath})
+	if projects[0].Path != "/work/cached" {
+		t.Errorf("changed project Path = %q, want re-parsed %q", projects[0].Path, "/work/cached")
+	}
+}
+
+func TestProjectCacheVersionMismatch(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "projects-cache.json")
+	data := []byte(`{"version":0,"entries":{"x":{"fingerprint":"f","path":"/stale"}}}`)
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		t.Fatalf("Failed to write cache: %v", err)
+	}
+
+	cache := loadCache(cachePath)
+	if len(cache.Entries) != 0 {
+		t.Errorf("cache with old version kept %d entries, want 0", len(cache.Entries))
+	}
+	if _, ok := cache.lookup("x", "f"); ok {
+		t.Error("lookup() hit an entry from an old cache version")
+	}
+}
+
+func TestProjectCacheDropsRemovedProjects(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "projects-cache.json")
+	kept := writeTestProject(t, root, "/work/keep", 1)
+	removed := writeTestProject(t, root, "/work/removed", 1)
+
+	LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath})
+	os.RemoveAll(removed)
+	LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath})
+
+	cache := loadCache(cachePath)
+	if _, ok := cache.Entries[removed]; ok {
+		t.Error("cache still holds an entry for a removed project folder")
+	}
+	if _, ok := cache.Entries[kept]; !ok {
+		t.Error("cache lost the entry for a remaining project folder")
+	}
+}
+
+func TestProjectCacheKeepsOtherRoots(t *testing.T) {
+	root, other := t.TempDir(), t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "projects-cache.json")
+	mine := writeTestProject(t, root, "/work/mine", 1)
+	theirs := writeTestProject(t, other, "/work/theirs", 1)
+
+	LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root, other}, CachePath: cachePath})
+	// A run over one root, e.g. from the command line, leaves the other's entries
+	LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath})
+
+	cache := loadCache(cachePath)
+	if _, ok := cache.Entries[theirs]; !ok {
+		t.Error("cache lost the entry of a root that wasn't loaded")
+	}
+	if _, ok := cache.Entries[mine]; !ok {
+		t.Error("cache lost the entry for a remaining project folder")
+	}
+}
+
+// BenchmarkLoadProjects compares a cold start (no cache file) with a warm
+// start (every folder unchanged) on a synthetic tree of 1,000 projects.
+func BenchmarkLoadProjects(b *testing.B) {
+	root := b.TempDir()
+	for i := 0; i < 1000; i++ {
+		writeTestProject(b, root, fmt.Sprintf("/work/project-%04d", i), 3)
+	}
+	cachePath := filepath.Join(b.TempDir(), "projects-cache.json")
+
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			os.Remove(cachePath)
+			b.StartTimer()
+			if _, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath}); err != nil {
+				b.Fatalf("LoadProjectsContext() error = %v", err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath})
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, CachePath: cachePath}); err != nil {
+				b.Fatalf("LoadProjectsContext() error = %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// ProjectConfig is a project's entry in Claude Code's global config file,
+// ~/.claude.json, or .claude.json in a CLAUDE_CONFIG_DIR root
+type ProjectConfig struct {
+	// Trusted reports that the trust dialog for the folder was accepted
+	Trusted bool `json:"trusted"`
+	// Onboarded reports that Claude Code's project onboarding was completed
+	Onboarded    bool     `json:"onboarded"`
+	AllowedTools []string `json:"allowedTools,omitempty"`
+	// MCPServers are the servers added to the project with local scope, and
+	// DisabledMCPServers the .mcp.json servers the user turned down
+	MCPServers         []string     `json:"mcpServers,omitempty"`
+	DisabledMCPServers []string     `json:"disabledMcpServers,omitempty"`
+	LastSession        *LastSession `json:"lastSession,omitempty"`
+}
+
+// LastSession is what Claude Code recorded about the last session in a
+// project when it ended
+type LastSession struct {
+	ID                  string  `json:"id"`
+	Cost                float64 `json:"cost"`       // Estimated cost in USD
+	DurationMS          int64   `json:"durationMs"` // Wall-clock time
+	LinesAdded          int     `json:"linesAdded"`
+	LinesRemoved        int     `json:"linesRemoved"`
+	InputTokens         int64   `json:"inputTokens"`
+	OutputTokens        int64   `json:"outputTokens"`
+	CacheCreationTokens int64   `json:"cacheCreationTokens"`
+	CacheReadTokens     int64   `json:"cacheReadTokens"`
+}
+
+// claudeJSONProject mirrors the fields of a project entry in .claude.json
+// that ProjectConfig reports. The entries hold more, such as the prompt
+// history of older versions, which is skipped.
+type claudeJSONProject struct {
+	AllowedTools                  []string                   `json:"allowedTools"`
+	MCPServers                    map[string]json.RawMessage `json:"mcpServers"`
+	DisabledMCPJSONServers        []string                   `json:"disabledMcpjsonServers"`
+	HasTrustDialogAccepted        bool                       `json:"hasTrustDialogAccepted"`
+	HasCompletedProjectOnboarding bool                       `json:"hasCompletedProjectOnboarding"`
+	LastSessionID                 string                     `json:"lastSessionId"`
+	LastCost                      float64                    `json:"lastCost"`
+	LastDuration                  int64                      `json:"lastDuration"`
+	LastLinesAdded                int                        `json:"lastLinesAdded"`
+	LastLinesRemoved              int                        `json:"lastLinesRemoved"`
+	LastTotalInputTokens          int64                      `json:"lastTotalInputTokens"`
+	LastTotalOutputTokens         int64                      `json:"lastTotalOutputTokens"`
+	LastTotalCacheCreationTokens  int64                      `json:"lastTotalCacheCreationInputTokens"`
+	LastTotalCacheReadTokens      int64                      `json:"lastTotalCacheReadInputTokens"`
+}
+
+// config converts the entry to the form the switcher exposes
+func (e claudeJSONProject) config() *ProjectConfig {
+	c := &ProjectConfig{
+		Trusted:            e.HasTrustDialogAccepted,
+		Onboarded:          e.HasCompletedProjectOnboarding,
+		AllowedTools:       e.AllowedTools,
+		DisabledMCPServers: e.DisabledMCPJSONServers,
+	}
+	for name := range e.MCPServers {
+		c.MCPServers = append(c.MCPServers, name)
+	}
+	sort.Strings(c.MCPServers)
+	if e.LastSessionID != "" {
+		c.LastSession = &LastSession{
+			ID:                  e.LastSessionID,
+			Cost:                e.LastCost,
+			DurationMS:          e.LastDuration,
+			LinesAdded:          e.LastLinesAdded,
+			LinesRemoved:        e.LastLinesRemoved,
+			InputTokens:         e.LastTotalInputTokens,
+			OutputTokens:        e.LastTotalOutputTokens,
+			CacheCreationTokens: e.LastTotalCacheCreationTokens,
+			CacheReadTokens:     e.LastTotalCacheReadTokens,
+		}
+	}
+	return c
+}
+
+// ClaudeJSONPath returns the global config file Claude Code keeps next to a
+// data root: ~/.claude.json for the default root, and .claude.json inside
+// any other root named by CLAUDE_CONFIG_DIR
+func ClaudeJSONPath(root string) string {
+	if defaultRoot, err := DefaultRoot(); err == nil && fsutil.SamePath(defaultRoot, root) {
+		return filepath.Join(filepath.Dir(root), ".claude.json")
+	}
+	return filepath.Join(root, ".claude.json")
+}
+
+// Retries of a .claude.json read that met a file being rewritten
+const (
+	claudeJSONAttempts   = 4
+	claudeJSONRetryDelay = 50 * time.Millisecond
+)
+
+// ReadClaudeJSON reads the project entries of a .claude.json file, keyed by
+// project path. Claude Code rewrites the file while it runs, so a read that
+// fails to parse or sees the file change underneath it is retried a few
+// times before giving up. The file is decoded as a stream, and only the
+// fields ProjectConfig reports are kept, so a large file costs time but not
+// memory.
+func ReadClaudeJSON(path string) (map[string]*ProjectConfig, error) {
+	delay := claudeJSONRetryDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		var configs map[string]*ProjectConfig
+		configs, err = readClaudeJSONOnce(path)
+		if err == nil || os.IsNotExist(err) || attempt == claudeJSONAttempts {
+			return configs, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// readClaudeJSONOnce makes one attempt at ReadClaudeJSON, failing if the
+// file's size or modification time changed while it was read
+func readClaudeJSONOnce(path string) (map[string]*ProjectConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	before, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := decodeClaudeJSON(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	// The file may have been replaced, so look at the path, not the handle
+	after, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) {
+		return nil, fmt.Errorf("%s changed while it was read", filepath.Base(path))
+	}
+	return configs, nil
+}
+
+// decodeClaudeJSON decodes the "projects" object of a .claude.json stream,
+// skipping every other top-level field
+func decodeClaudeJSON(r io.Reader) (map[string]*ProjectConfig, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	configs := make(map[string]*ProjectConfig)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key, _ := tok.(string); key != "projects" {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, fmt.Errorf("projects: %w", err)
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var entry claudeJSONProject
+			if err := dec.Decode(&entry); err != nil {
+				return nil, fmt.Errorf("project %v: %w", tok, err)
+			}
+			if key, _ := tok.(string); key != "" {
+				configs[canonicalPath(filepath.FromSlash(key))] = entry.config()
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	// The closing brace proves the file wasn't cut short
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// expectDelim reads the next token and fails unless it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %v, found %v", delim, tok)
+	}
+	return nil
+}
+
+// skipValue reads past the next value, token by token so that large values
+// aren't held in memory at once
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// claudeJSONMemo keeps the last read of each .claude.json, so that rescans
+// while the file is unchanged don't parse it again
+var claudeJSONMemo = struct {
+	sync.Mutex
+	entries map[string]claudeJSONRead
+}{entries: make(map[string]claudeJSONRead)}
+
+// claudeJSONRead is a memoized read of one file
+type claudeJSONRead struct {
+	size    int64
+	modTime time.Time
+	configs map[string]*ProjectConfig
+}
+
+// readClaudeJSONCached reads a .claude.json, reusing the previous result if
+// the file's size and modification time are unchanged. A file that can't be
+// read returns the previous result along with the error, so a rewrite in
+// progress doesn't make entries disappear.
+func readClaudeJSONCached(path string) (map[string]*ProjectConfig, error) {
+	claudeJSONMemo.Lock()
+	last, ok := claudeJSONMemo.entries[path]
+	claudeJSONMemo.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return last.configs, err
+	}
+	if ok && info.Size() == last.size && info.ModTime().Equal(last.modTime) {
+		return last.configs, nil
+	}
+
+	configs, err := ReadClaudeJSON(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return last.configs, err
+	}
+	claudeJSONMemo.Lock()
+	claudeJSONMemo.entries[path] = claudeJSONRead{size: info.Size(), modTime: info.ModTime(), configs: configs}
+	claudeJSONMemo.Unlock()
+	return configs, nil
+}
+
+// loadClaudeJSON reads the .claude.json of every root in opts.Roots. It sets
+// Config on the projects in list and returns a project for every entry of a
+// folder Claude Code keeps no transcripts for, e.g. because they were
+// cleaned up. Entries for folders that no longer exist are left out, as
+// there is nothing to open or prune for them. The configs are shared with
+// later loads and must not be modified.
+func loadClaudeJSON(ctx context.Context, list []Project, opts LoadOptions) []Project {
+	index := make(map[string]int, len(list))
+	for i, p := range list {
+		index[p.Key()] = i
+	}
+
+	var added []Project
+	for _, root := range opts.Roots {
+		path := ClaudeJSONPath(root)
+		configs, err := readClaudeJSONCached(path)
+		if err != nil {
+			opts.Diagnostics.addProblem(path, 0, err)
+		}
+		var pending []Project
+		for projectPath, config := range configs {
+			if i, ok := index[Project{Root: root, Path: projectPath}.Key()]; ok {
+				list[i].Config = config
+				continue
+			}
+			p := newProject(projectPath, root, encodePath(projectPath), time.Time{})
+			p.Config = config
+			pending = append(pending, p)
+		}
+
+		sort.Slice(pending, func(i, j int) bool { return pending[i].Path < pending[j].Path })
+
+		// Check the folders on a pool of workers like the project folders,
+		// so that a few on an unreachable drive don't take one StatTimeout
+		// each, nor a long .claude.json read every repository at once
+		jobs := make(chan *Project)
+		var wg sync.WaitGroup
+		for w := 0; w < opts.Workers && w < len(pending); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for p := range jobs {
+					checkProject(ctx, p, opts)
+				}
+			}()
+		}
+		for i := range pending {
+			jobs <- &pending[i]
+		}
+		close(jobs)
+		wg.Wait()
+
+		for _, p := range pending {
+			if p.PathState == PathMissing {
+				continue
+			}
+			added = append(added, p)
+			opts.Diagnostics.addProject(ProjectSources{Path: p.Path, Sources: []string{SourceClaudeJSON}})
+		}
+	}
+	return added
+}
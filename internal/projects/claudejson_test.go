@@ -0,0 +1,153 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// claudeJSONEntry is a project entry the way Claude Code writes it
+const claudeJSONEntry = `{
+	"allowedTools": ["Bash(go test:*)"],
+	"history": [{"display": "a long prompt", "pastedContents": {}}],
+	"mcpServers": {"sentry": {"type": "http"}, "db": {"command": "db-mcp"}},
+	"disabledMcpjsonServers": ["github"],
+	"hasTrustDialogAccepted": true,
+	"hasCompletedProjectOnboarding": true,
+	"lastCost": 0.4217,
+	"lastDuration": 95000,
+	"lastLinesAdded": 12,
+	"lastLinesRemoved": 3,
+	"lastTotalInputTokens": 1200,
+	"lastTotalOutputTokens": 340,
+	"lastSessionId": "0b6f2c1e-5d4a-4e2b-9c1f-3a7d8e9f0a1b"
+}`
+
+// writeClaudeJSON writes a .claude.json listing the given project paths
+func writeClaudeJSON(t *testing.T, path string, projectPaths ...string) {
+	t.Helper()
+	var entries []string
+	for _, p := range projectPaths {
+		entries = append(entries, fmt.Sprintf("%q: %s", filepath.ToSlash(p), claudeJSONEntry))
+	}
+	data := `{"numStartups": 42, "tipsHistory": {"a": [1, {"b": null}]}, "projects": {` +
+		strings.Join(entries, ",") + `}, "userID": "x"}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadClaudeJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".claude.json")
+	project := filepath.Join(dir, "shop-api")
+	writeClaudeJSON(t, path, project)
+
+	configs, err := ReadClaudeJSON(path)
+	if err != nil {
+		t.Fatalf("ReadClaudeJSON() error = %v", err)
+	}
+	c := configs[canonicalPath(project)]
+	if c == nil {
+		t.Fatalf("ReadClaudeJSON() = %v, want an entry for %s", configs, project)
+	}
+	got := fmt.Sprintf("%v %v %v %v %v", c.Trusted, c.Onboarded, c.AllowedTools, c.MCPServers, c.DisabledMCPServers)
+	if want := "true true [Bash(go test:*)] [db sentry] [github]"; got != want {
+		t.Errorf("config = %s, want %s", got, want)
+	}
+	if s := c.LastSession; s == nil || s.Cost != 0.4217 || s.DurationMS != 95000 || s.LinesAdded != 12 || s.OutputTokens != 340 {
+		t.Errorf("last session = %+v", s)
+	}
+
+	// A file cut short by a write in progress is an error, once it has
+	// stayed that way through the retries
+	os.WriteFile(path, []byte(`{"projects": {"/a": {"lastCost": 1}`), 0644)
+	if _, err := ReadClaudeJSON(path); err == nil {
+		t.Error("ReadClaudeJSON() of a truncated file succeeded")
+	}
+}
+
+func TestReadClaudeJSONRewritten(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".claude.json")
+	os.WriteFile(path, []byte(`{"projects": {"/a": {"lastC`), 0644)
+
+	// Claude Code finishes writing while the first attempt is retried
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(claudeJSONRetryDelay / 2)
+		writeClaudeJSON(t, path, filepath.Join(dir, "a"))
+	}()
+	configs, err := ReadClaudeJSON(path)
+	<-done
+	if err != nil || len(configs) != 1 {
+		t.Errorf("ReadClaudeJSON() = %v, %v; want the rewritten file", configs, err)
+	}
+}
+
+func TestLoadClaudeJSON(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	withData := filepath.Join(home, "with-data")
+	withoutData := filepath.Join(home, "without-data")
+	deleted := filepath.Join(home, "deleted")
+	os.MkdirAll(withData, 0755)
+	os.MkdirAll(withoutData, 0755)
+	writeTestProject(t, root, withData, 1)
+	writeClaudeJSON(t, filepath.Join(root, ".claude.json"), withData, withoutData, deleted)
+
+	var diag Diagnostics
+	opts := LoadOptions{Roots: []string{root}, ClaudeJSON: true, Diagnostics: &diag}
+	list, err := LoadProjectsContext(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("projects = %+v, want %s and %s", list, withData, withoutData)
+	}
+	for _, p := range list {
+		if p.Config == nil || !p.Config.Trusted {
+			t.Errorf("%s has config %+v", p.Path, p.Config)
+		}
+		if p.Path == resolvePath(withoutData) && (p.PathState != PathFound || !p.LastUsed.IsZero() || p.Root != root) {
+			t.Errorf("project without data = %+v", p)
+		}
+	}
+	found := false
+	for _, s := range diag.Projects {
+		found = found || len(s.Sources) == 1 && s.Sources[0] == SourceClaudeJSON
+	}
+	if !found {
+		t.Errorf("diagnostics = %+v, want a project found from .claude.json", diag.Projects)
+	}
+
+	// A root with only a .claude.json still has projects
+	onlyConfig := t.TempDir()
+	writeClaudeJSON(t, filepath.Join(onlyConfig, ".claude.json"), withoutData)
+	opts.Roots = []string{onlyConfig}
+	if list, err := LoadProjectsContext(context.Background(), opts); err != nil || len(list) != 1 {
+		t.Errorf("LoadProjectsContext() = %+v, %v; want %s", list, err, withoutData)
+	}
+
+	// The entries are checked on the worker pool, however small
+	var paths []string
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(home, fmt.Sprintf("listed-%d", i))
+		os.MkdirAll(path, 0755)
+		paths = append(paths, path)
+	}
+	many := t.TempDir()
+	writeClaudeJSON(t, filepath.Join(many, ".claude.json"), paths...)
+	opts.Roots, opts.Workers = []string{many}, 1
+	if list, err := LoadProjectsContext(context.Background(), opts); err != nil || len(list) != len(paths) {
+		t.Errorf("LoadProjectsContext() with one worker = %+v, %v; want %d projects", list, err, len(paths))
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Context is the Claude Code configuration kept in a project directory
+type Context struct {
+	ClaudeMD      bool `json:"claudeMd"`      // CLAUDE.md at the top or in .claude
+	Settings      bool `json:"settings"`      // .claude/settings.json
+	LocalSettings bool `json:"localSettings"` // .claude/settings.local.json
+	// MCP reports a .mcp.json, and MCPServers the servers it defines
+	MCP        bool     `json:"mcp"`
+	MCPServers []string `json:"mcpServers,omitempty"`
+	// Commands are the custom slash commands in .claude/commands and Agents
+	// the sub-agents in .claude/agents, by name. Commands in a subfolder are
+	// named folder:command, the way Claude Code lists them.
+	Commands []string `json:"commands,omitempty"`
+	Agents   []string `json:"agents,omitempty"`
+}
+
+// ContextFeatures are the names has: filters and badges use for what a
+// Context can report, in display order
+var ContextFeatures = []string{"claudemd", "settings", "mcp", "commands", "agents"}
+
+// ReadContext looks for Claude Code configuration in the project directory.
+// Files that can't be read count as absent; a .mcp.json that doesn't parse
+// still counts, without server names.
+func ReadContext(dir string) *Context {
+	c := &Context{
+		ClaudeMD:      isFile(filepath.Join(dir, "CLAUDE.md")) || isFile(filepath.Join(dir, ".claude", "CLAUDE.md")),
+		Settings:      isFile(filepath.Join(dir, ".claude", "settings.json")),
+		LocalSettings: isFile(filepath.Join(dir, ".claude", "settings.local.json")),
+		Commands:      markdownNames(filepath.Join(dir, ".claude", "commands"), ":"),
+		Agents:        markdownNames(filepath.Join(dir, ".claude", "agents"), ""),
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, ".mcp.json")); err == nil {
+		c.MCP = true
+		var config struct {
+			MCPServers map[string]json.RawMessage `json:"mcpServers"`
+		}
+		if json.Unmarshal(data, &config) == nil {
+			for name := range config.MCPServers {
+				c.MCPServers = append(c.MCPServers, name)
+			}
+			sort.Strings(c.MCPServers)
+		}
+	}
+	return c
+}
+
+// Has reports whether the context has a feature named in ContextFeatures.
+// A nil context has none.
+func (c *Context) Has(feature string) bool {
+	if c == nil {
+		return false
+	}
+	switch strings.ToLower(feature) {
+	case "claudemd":
+		return c.ClaudeMD
+	case "settings":
+		return c.Settings || c.LocalSettings
+	case "mcp":
+		return c.MCP
+	case "commands":
+		return len(c.Commands) > 0
+	case "agents":
+		return len(c.Agents) > 0
+	}
+	return false
+}
+
+// Badges returns short labels for what the context has, e.g. "CLAUDE.md"
+// or "MCP 3"
+func (c *Context) Badges() []string {
+	if c == nil {
+		return nil
+	}
+	var badges []string
+	if c.ClaudeMD {
+		badges = append(badges, "CLAUDE.md")
+	}
+	if c.Settings || c.LocalSettings {
+		badges = append(badges, "settings")
+	}
+	if c.MCP {
+		badges = append(badges, "MCP "+strconv.Itoa(len(c.MCPServers)))
+	}
+	if len(c.Commands) > 0 {
+		badges = append(badges, "commands "+strconv.Itoa(len(c.Commands)))
+	}
+	if len(c.Agents) > 0 {
+		badges = append(badges, "agents "+strconv.Itoa(len(c.Agents)))
+	}
+	return badges
+}
+
+// isFile reports whether path is an existing regular file
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// markdownNames returns the names of the .md files under dir, sorted. Files
+// in subfolders are prefixed with their folder joined by sep, or skipped if
+// sep is empty.
+func markdownNames(dir, sep string) []string {
+	var names []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && sep == "" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel))
+		names = append(names, strings.Join(strings.Split(filepath.ToSlash(rel), "/"), sep))
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadContext(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"CLAUDE.md":                      "# Project",
+		".claude/settings.local.json":    "{}",
+		".mcp.json":                      `{"mcpServers":{"postgres":{"command":"pg-mcp"},"github":{"type":"http"}}}`,
+		".claude/commands/review.md":     "Review the diff",
+		".claude/commands/ops/deploy.md": "Deploy",
+		".claude/commands/notes.txt":     "not a command",
+		".claude/agents/test-runner.md":  "---\nname: test-runner\n---",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := ReadContext(dir)
+	got := fmt.Sprintf("%v %v %v %v %v %v %v", c.ClaudeMD, c.Settings, c.LocalSettings, c.MCP, c.MCPServers, c.Commands, c.Agents)
+	want := "true false true true [github postgres] [ops:deploy review] [test-runner]"
+	if got != want {
+		t.Errorf("ReadContext() = %s, want %s", got, want)
+	}
+	for _, feature := range ContextFeatures {
+		if !c.Has(feature) {
+			t.Errorf("Has(%q) = false", feature)
+		}
+	}
+	if got := fmt.Sprint(c.Badges()); got != "[CLAUDE.md settings MCP 2 commands 2 agents 1]" {
+		t.Errorf("Badges() = %s", got)
+	}
+
+	empty := ReadContext(t.TempDir())
+	if empty.Has("claudemd") || len(empty.Badges()) != 0 {
+		t.Errorf("empty directory has %v", empty.Badges())
+	}
+	var unknown *Context
+	if unknown.Has("mcp") || unknown.Badges() != nil {
+		t.Error("nil context reports features")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Where a project's path and last use were read from, as listed in
+// ProjectSources
+const (
+	SourceCache      = "cache"
+	SourceIndex      = "sessions index"
+	SourceTranscript = "transcript cwd"
+	SourceFolderName = "folder name"
+	SourceModTimes   = "transcript modification times"
+	SourcePinned     = "pinned in config"
+	SourceClaudeJSON = ".claude.json"
+)
+
+// Diagnostics reports how LoadProjectsContext found each project and every
+// file it couldn't use. Set LoadOptions.Diagnostics to collect it.
+type Diagnostics struct {
+	Projects []ProjectSources `json:"projects"`
+	Problems []Problem        `json:"problems"`
+
+	mu sync.Mutex
+}
+
+// ProjectSources lists where the path and last use of one project folder
+// came from. Path is empty for a folder no path could be found for, which
+// isn't listed.
+type ProjectSources struct {
+	Dir     string   `json:"dir"`
+	Path    string   `json:"path"`
+	Sources []string `json:"sources"`
+}
+
+// Problem is a file or folder that couldn't be read or parsed
+type Problem struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// String formats the problem as file:line: reason
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Reason)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Reason)
+}
+
+// addProject records the sources of a project. d may be nil.
+func (d *Diagnostics) addProject(s ProjectSources) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.Projects = append(d.Projects, s)
+	d.mu.Unlock()
+}
+
+// addProblem records a file that couldn't be used. d may be nil.
+func (d *Diagnostics) addProblem(file string, line int, err error) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.Problems = append(d.Problems, Problem{File: file, Line: line, Reason: err.Error()})
+	d.mu.Unlock()
+}
+
+// sort orders the report by folder and file, since workers fill it in no
+// particular order
+func (d *Diagnostics) sort() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sort.Slice(d.Projects, func(i, j int) bool {
+		return d.Projects[i].Dir < d.Projects[j].Dir
+	})
+	sort.SliceStable(d.Problems, func(i, j int) bool {
+		if d.Problems[i].File != d.Problems[j].File {
+			return d.Problems[i].File < d.Problems[j].File
+		}
+		return d.Problems[i].Line < d.Problems[j].Line
+	})
+}
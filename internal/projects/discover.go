@@ -0,0 +1,162 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/git"
+)
+
+// DefaultScanDepth is how many folder levels below a scan root are searched
+const DefaultScanDepth = 4
+
+// ScanOptions says where to look for project folders on disk, both for
+// repositories that were never opened in Claude Code and for the new
+// location of a moved project
+type ScanOptions struct {
+	// Roots are the folders searched, e.g. ~/src
+	Roots []string
+	// Depth is how many folder levels below a root are searched; 0 means
+	// DefaultScanDepth
+	Depth int
+	// Ignore holds glob patterns of folders to skip, matched against the
+	// folder name and its full path. Hidden folders and node_modules are
+	// always skipped.
+	Ignore []string
+	// DataRoot is the Claude data root discovered projects are started
+	// with; empty means the first of DefaultRoots()
+	DataRoot string
+}
+
+// Discover returns the git repositories under the scan roots that aren't
+// among the known projects, sorted by name and flagged New. Repositories
+// nested in another one, such as submodules, aren't listed. The scan stops
+// early when ctx is cancelled.
+func Discover(ctx context.Context, opts ScanOptions, known []Project) []Project {
+	if opts.DataRoot == "" {
+		if roots := DefaultRoots(); len(roots) > 0 {
+			opts.DataRoot = roots[0]
+		}
+	}
+	seen := make(map[string]bool, len(known))
+	for _, p := range known {
+		seen[fsutil.PathKey(p.Path)] = true
+	}
+
+	var found []Project
+	for _, root := range opts.roots() {
+		opts.walk(root, func(dir string) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+				return true
+			}
+			if key := fsutil.PathKey(dir); !seen[key] {
+				seen[key] = true
+				found = append(found, discovered(dir, opts.DataRoot))
+			}
+			// Folders inside a checkout are part of it
+			return false
+		})
+	}
+	SortByName(found)
+	return found
+}
+
+// discovered returns the project for a repository found on disk
+func discovered(dir, dataRoot string) Project {
+	p := newProject(dir, dataRoot, encodePath(dir), time.Time{})
+	p.PathState = PathFound
+	p.New = true
+	// A checkout git can't describe is still worth listing
+	if info, err := git.Read(dir); err == nil {
+		p.Git = info
+		p.RepoRoot = info.WorkTree
+		p.WorktreeOf = info.MainWorkTree
+		p.RemoteURL = info.RemoteURL
+	}
+	p.Context = ReadContext(dir)
+	return p
+}
+
+// NewLast moves the projects never opened in Claude Code to the end of the
+// list, keeping the order of both
+func NewLast(list []Project) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return !list[i].New && list[j].New
+	})
+}
+
+// roots returns the scan roots with ~ expanded
+func (o ScanOptions) roots() []string {
+	roots := make([]string, 0, len(o.Roots))
+	for _, root := range o.Roots {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, filepath.Clean(expandHome(root)))
+		}
+	}
+	return roots
+}
+
+// walk calls fn for every directory below root, down to the scan depth,
+// descending into it only if fn returns true. Ignored folders are skipped,
+// and symlinks aren't followed.
+func (o ScanOptions) walk(root string, fn func(dir string) bool) {
+	depth := o.Depth
+	if depth <= 0 {
+		depth = DefaultScanDepth
+	}
+	o.walkDepth(root, depth, fn)
+}
+
+func (o ScanOptions) walkDepth(root string, depth int, fn func(dir string) bool) {
+	if depth == 0 {
+		return
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || name == "node_modules" {
+			continue
+		}
+		dir := filepath.Join(root, name)
+		if o.ignored(dir) {
+			continue
+		}
+		if fn(dir) {
+			o.walkDepth(dir, depth-1, fn)
+		}
+	}
+}
+
+// ignored reports whether dir matches one of the ignore patterns
+func (o ScanOptions) ignored(dir string) bool {
+	name := filepath.Base(dir)
+	for _, pattern := range o.Ignore {
+		pattern = expandHome(pattern)
+		if runtime.GOOS == "windows" {
+			// Patterns use the separator of the platform, and case doesn't matter
+			pattern, name, dir = strings.ToLower(pattern), strings.ToLower(name), strings.ToLower(dir)
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "src")
+	repo := func(rel string, gitFile bool) string {
+		dir := filepath.Join(src, filepath.FromSlash(rel))
+		if gitFile {
+			// Linked worktrees and submodules have a .git file
+			os.MkdirAll(dir, 0755)
+			os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: elsewhere\n"), 0644)
+		} else {
+			os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+		}
+		return dir
+	}
+	app := repo("app", false)
+	repo("app/vendor/lib", true)
+	worktree := repo("clients/a/api", true)
+	known := repo("known", false)
+	repo("node_modules/pkg", false)
+	repo("scratch-1/tool", false)
+	repo("old/archived", false)
+	repo("a/b/c/too-deep", false)
+
+	found := Discover(context.Background(), ScanOptions{
+		Roots:    []string{src},
+		Depth:    3,
+		Ignore:   []string{"scratch-*", filepath.Join(src, "old")},
+		DataRoot: "/data",
+	}, []Project{{Path: known}})
+
+	var paths []string
+	for _, p := range found {
+		paths = append(paths, p.Path)
+		if !p.New || p.PathState != PathFound || p.Root != "/data" || p.EncodedDir != encodePath(p.Path) {
+			t.Errorf("discovered project = %+v, want New, found, in /data", p)
+		}
+	}
+	if want := []string{worktree, app}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Discover() = %v, want %v", paths, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := Discover(ctx, ScanOptions{Roots: []string{src}}, nil); len(got) != 0 {
+		t.Errorf("Discover() after cancel = %d projects, want none", len(got))
+	}
+}
+
+func TestNewLast(t *testing.T) {
+	list := []Project{
+		{Name: "new-a", New: true},
+		{Name: "known-a"},
+		{Name: "new-b", New: true},
+		{Name: "known-b"},
+	}
+	NewLast(list)
+
+	want := []string{"known-a", "known-b", "new-a", "new-b"}
+	for i, name := range want {
+		if list[i].Name != name {
+			t.Errorf("position %d = %s, want %s", i, list[i].Name, name)
+		}
+	}
+}
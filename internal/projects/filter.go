@@ -0,0 +1,136 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter holds the qualifiers of a search query, such as tag:oss or
+// has:mcp, which narrow the list before the rest of the query is matched
+type Filter struct {
+	Tags []string // The project must have every one of these tags
+	// Has and HasNot name ContextFeatures the project must have, or lack.
+	// A project whose context is unknown matches neither.
+	Has    []string
+	HasNot []string
+}
+
+// ParseFilter takes the qualifiers out of a query and returns them with
+// the remaining words. A has: or -has: naming something other than one of
+// ContextFeatures is an error, since it would match no project, or all; the
+// filter and words are returned without it.
+func ParseFilter(query string) (Filter, string, error) {
+	var f Filter
+	var rest []string
+	var unknown []string
+	for _, word := range strings.Fields(query) {
+		if tag, ok := cutQualifier(word, "tag:"); ok {
+			if tag != "" {
+				f.Tags = append(f.Tags, tag)
+			}
+			continue
+		}
+		if feature, ok := cutQualifier(word, "has:"); ok {
+			if feature != "" && !isContextFeature(feature) {
+				unknown = append(unknown, word)
+			} else if feature != "" {
+				f.Has = append(f.Has, feature)
+			}
+			continue
+		}
+		if feature, ok := cutQualifier(word, "-has:"); ok {
+			if feature != "" && !isContextFeature(feature) {
+				unknown = append(unknown, word)
+			} else if feature != "" {
+				f.HasNot = append(f.HasNot, feature)
+			}
+			continue
+		}
+		rest = append(rest, word)
+	}
+	var err error
+	if len(unknown) > 0 {
+		err = fmt.Errorf("unknown feature in %s, want one of %s",
+			strings.Join(unknown, " "), strings.Join(ContextFeatures, ", "))
+	}
+	return f, strings.Join(rest, " "), err
+}
+
+// isContextFeature reports whether name is one of ContextFeatures, ignoring
+// its case
+func isContextFeature(name string) bool {
+	for _, feature := range ContextFeatures {
+		if strings.EqualFold(feature, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// cutQualifier returns what follows prefix in word, ignoring its case
+func cutQualifier(word, prefix string) (string, bool) {
+	if len(word) < len(prefix) || !strings.EqualFold(word[:len(prefix)], prefix) {
+		return "", false
+	}
+	return word[len(prefix):], true
+}
+
+// IsEmpty reports whether the filter lets every project through
+func (f Filter) IsEmpty() bool {
+	return len(f.Tags) == 0 && len(f.Has) == 0 && len(f.HasNot) == 0
+}
+
+// Match reports whether p passes every qualifier of the filter
+func (f Filter) Match(p Project) bool {
+	for _, tag := range f.Tags {
+		if !p.HasTag(tag) {
+			return false
+		}
+	}
+	if (len(f.Has) > 0 || len(f.HasNot) > 0) && p.Context == nil {
+		return false
+	}
+	for _, feature := range f.Has {
+		if !p.Context.Has(feature) {
+			return false
+		}
+	}
+	for _, feature := range f.HasNot {
+		if p.Context.Has(feature) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasTag reports whether the project has the tag, ignoring case
+func (p Project) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchText returns what a search for the project is matched against: its
+// name and alias, path, git branch, tags and note
+func (p Project) SearchText() string {
+	parts := []string{p.Name}
+	if p.Alias != "" {
+		parts = append(parts, p.Alias)
+	}
+	parts = append(parts, p.Path)
+	if p.Git != nil && p.Git.Branch != "" {
+		// Lets a branch name find the checkout that is on it
+		parts = append(parts, p.Git.Branch)
+	}
+	parts = append(parts, p.Tags...)
+	if p.Note != "" {
+		parts = append(parts, p.Note)
+	}
+	return strings.Join(parts, " ")
+}
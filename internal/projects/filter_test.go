@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantTags []string
+		wantRest string
+	}{
+		{"api", nil, "api"},
+		{"tag:client-a api", []string{"client-a"}, "api"},
+		{"web TAG:oss tag:go", []string{"oss", "go"}, "web"},
+		{"tag:", nil, ""},
+		{"  tag:oss  ", []string{"oss"}, ""},
+	}
+	for _, tt := range tests {
+		f, rest, err := ParseFilter(tt.query)
+		if err != nil || !reflect.DeepEqual(f.Tags, tt.wantTags) || rest != tt.wantRest {
+			t.Errorf("ParseFilter(%q) = %v, %q; want %v, %q", tt.query, f.Tags, rest, tt.wantTags, tt.wantRest)
+		}
+	}
+
+	f, rest, err := ParseFilter("has:mcp api -HAS:claudemd")
+	if err != nil || !reflect.DeepEqual(f.Has, []string{"mcp"}) || !reflect.DeepEqual(f.HasNot, []string{"claudemd"}) || rest != "api" {
+		t.Errorf("ParseFilter(has:) = %+v, %q, %v", f, rest, err)
+	}
+
+	// A misspelt feature would match nothing, or with -has: everything
+	for _, query := range []string{"has:mpc api", "-has:claude-md"} {
+		f, _, err := ParseFilter(query)
+		if err == nil || !strings.Contains(err.Error(), "claudemd, settings, mcp") {
+			t.Errorf("ParseFilter(%q) error = %v, want the known features", query, err)
+		}
+		if len(f.Has) != 0 || len(f.HasNot) != 0 {
+			t.Errorf("ParseFilter(%q) kept the unknown feature: %+v", query, f)
+		}
+	}
+}
+
+func TestFilterMatchContext(t *testing.T) {
+	onboarded := Project{Path: "/a", Context: &Context{ClaudeMD: true, MCP: true}}
+	bare := Project{Path: "/b", Context: &Context{}}
+	missing := Project{Path: "/c"}
+
+	tests := []struct {
+		query string
+		want  []bool // onboarded, bare, missing
+	}{
+		{"has:mcp", []bool{true, false, false}},
+		{"-has:claudemd", []bool{false, true, false}},
+		{"has:claudemd -has:agents", []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		f, _, _ := ParseFilter(tt.query)
+		for i, p := range []Project{onboarded, bare, missing} {
+			if got := f.Match(p); got != tt.want[i] {
+				t.Errorf("%q matches %s = %v, want %v", tt.query, p.Path, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	p := Project{Name: "api", Path: "/clients/a/api", Tags: []string{"Client-A", "go"}}
+
+	tests := []struct {
+		tags []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"client-a"}, true},
+		{[]string{"client-a", "go"}, true},
+		{[]string{"client-a", "oss"}, false},
+		{[]string{"client"}, false},
+	}
+	for _, tt := range tests {
+		if got := (Filter{Tags: tt.tags}).Match(p); got != tt.want {
+			t.Errorf("Match(%v) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayNameAndSearchText(t *testing.T) {
+	p := Project{Name: "api", Path: "/clients/a/api", Tags: []string{"client-a"}}
+	if p.DisplayName() != "api" {
+		t.Errorf("DisplayName() = %q, want the folder name", p.DisplayName())
+	}
+	p.Alias = "Client A API"
+	if p.DisplayName() != "Client A API" {
+		t.Errorf("DisplayName() = %q, want the alias", p.DisplayName())
+	}
+	if got, want := p.SearchText(), "api Client A API /clients/a/api client-a"; got != want {
+		t.Errorf("SearchText() = %q, want %q", got, want)
+	}
+	p.Note = "waiting on PR #412"
+	if got := p.SearchText(); !strings.HasSuffix(got, " waiting on PR #412") {
+		t.Errorf("SearchText() = %q, want the note at the end", got)
+	}
+	p.Note = ""
+
+	list := []Project{p, {Name: "billing", Path: "/clients/b/billing"}}
+	SortByName(list)
+	if list[0].Name != "billing" {
+		t.Errorf("SortByName() put %s first, want billing before the alias Client A API", list[0].Name)
+	}
+}
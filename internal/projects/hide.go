@@ -0,0 +1,120 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// HideRules decide which projects are left out of the list: those whose
+// path matches an ignore pattern and those hidden one by one
+type HideRules struct {
+	patterns []*regexp.Regexp
+	hidden   map[string]bool
+}
+
+// NewHideRules compiles the ignore patterns and the paths of hidden
+// projects. A pattern starting with re: is a regular expression searched for
+// in the path. Any other pattern is a glob in which * and ? don't cross
+// folder separators and ** does; a glob without a separator matches any one
+// folder name in the path, so node_modules hides everything inside a
+// node_modules folder. Case is ignored on Windows. Patterns that don't
+// compile are skipped and reported in the error.
+func NewHideRules(patterns, hidden []string) (*HideRules, error) {
+	r := &HideRules{hidden: make(map[string]bool, len(hidden))}
+	for _, path := range hidden {
+		r.hidden[fsutil.PathKey(path)] = true
+	}
+
+	var bad []string
+	for _, pattern := range patterns {
+		re, err := compileHidePattern(pattern)
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("%q: %v", pattern, err))
+			continue
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	if len(bad) > 0 {
+		return r, fmt.Errorf("invalid ignore patterns: %s", strings.Join(bad, "; "))
+	}
+	return r, nil
+}
+
+// compileHidePattern turns an ignore pattern into a regular expression
+// matched against a path with forward slashes
+func compileHidePattern(pattern string) (*regexp.Regexp, error) {
+	flags := ""
+	if runtime.GOOS == "windows" {
+		flags = "(?i)"
+	}
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		return regexp.Compile(flags + expr)
+	}
+
+	glob := filepath.ToSlash(strings.TrimSpace(expandHome(pattern)))
+	if glob == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if strings.Contains(glob, "/") {
+		return regexp.Compile(flags + "^" + b.String() + "$")
+	}
+	return regexp.Compile(flags + "(^|/)" + b.String() + "(/|$)")
+}
+
+// Hides reports whether the rules hide the project at path
+func (r *HideRules) Hides(path string) bool {
+	if r == nil {
+		return false
+	}
+	if r.hidden[fsutil.PathKey(path)] {
+		return true
+	}
+	// Globs are written for forward slashes, regular expressions may be
+	// written for the platform's separator
+	for _, re := range r.patterns {
+		if re.MatchString(filepath.ToSlash(path)) || re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply sets Hidden on every project, as the rules decide
+func (r *HideRules) Apply(list []Project) {
+	for i := range list {
+		list[i].Hidden = r.Hides(list[i].Path)
+	}
+}
+
+// Visible returns the projects that aren't hidden
+func Visible(list []Project) []Project {
+	visible := make([]Project, 0, len(list))
+	for _, p := range list {
+		if !p.Hidden {
+			visible = append(visible, p)
+		}
+	}
+	return visible
+}
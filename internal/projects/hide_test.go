@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestHideRules(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths below are Unix paths")
+	}
+	rules, err := NewHideRules([]string{
+		"node_modules",
+		"/tmp/*",
+		"/scratch/**",
+		"re:-(old|bak)$",
+		"re:(",
+	}, []string{"/work/secret/"})
+	if err == nil {
+		t.Error("NewHideRules accepted an invalid regular expression")
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/work/api", false},
+		{"/work/api/node_modules/pkg", true},
+		{"/work/node_modules_backup", false},
+		{"/tmp/try", true},
+		{"/tmp/try/deeper", false},
+		{"/scratch/a/b", true},
+		{"/work/api-old", true},
+		{"/work/api-older", false},
+		{"/work/secret", true},
+		{"/work/secret/sub", false},
+	}
+	for _, tt := range tests {
+		if got := rules.Hides(tt.path); got != tt.want {
+			t.Errorf("Hides(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	var none *HideRules
+	if none.Hides("/work/api") {
+		t.Error("nil rules hide a project")
+	}
+}
+
+func TestVisible(t *testing.T) {
+	list := []Project{{Path: "/a"}, {Path: "/b"}, {Path: "/c"}}
+	rules, err := NewHideRules([]string{"b"}, []string{"/c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules.Apply(list)
+	if !list[1].Hidden || !list[2].Hidden || list[0].Hidden {
+		t.Fatalf("Apply marked %+v", list)
+	}
+	if got := Visible(list); len(got) != 1 || got[0].Path != "/a" {
+		t.Errorf("Visible() = %+v, want only /a", got)
+	}
+}
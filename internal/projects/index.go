@@ -0,0 +1,70 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrUnknownIndexVersion reports a sessions index in a format this version
+// of the switcher can't read. Callers fall back to the transcripts.
+var ErrUnknownIndexVersion = errors.New("unknown sessions index version")
+
+// indexDecoder turns a sessions index of one version into a SessionsIndex
+type indexDecoder func(data []byte) (SessionsIndex, error)
+
+// indexDecoders holds a decoder for every sessions index version Claude Code
+// is known to write. When the format changes, add a decoder for the new
+// version here rather than changing an existing one.
+var indexDecoders = map[int]indexDecoder{
+	1: decodeIndexV1,
+}
+
+// decodeIndexV1 reads the format SessionsIndex mirrors
+func decodeIndexV1(data []byte) (SessionsIndex, error) {
+	var index SessionsIndex
+	err := json.Unmarshal(data, &index)
+	return index, err
+}
+
+// indexVersion reads the version of a sessions index. An index without one
+// is version 0, which no decoder claims.
+func indexVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	return header.Version, nil
+}
+
+// decodeSessionsIndex decodes a sessions index with the decoder for its
+// version
+func decodeSessionsIndex(data []byte) (SessionsIndex, error) {
+	version, err := indexVersion(data)
+	if err != nil {
+		return SessionsIndex{}, err
+	}
+	decode, ok := indexDecoders[version]
+	if !ok {
+		return SessionsIndex{}, fmt.Errorf("%w %d", ErrUnknownIndexVersion, version)
+	}
+	index, err := decode(data)
+	index.Version = version
+	return index, err
+}
+
+// ReadSessionsIndex reads the sessions-index.json at path. It returns an
+// error wrapping ErrUnknownIndexVersion for a version it has no decoder for.
+func ReadSessionsIndex(path string) (SessionsIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SessionsIndex{}, err
+	}
+	return decodeSessionsIndex(data)
+}
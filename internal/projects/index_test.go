@@ -0,0 +1,163 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSessionsIndexContract pins the fields read from sessions indexes kept
+// in testdata, including the fields this package ignores. The fixtures are
+// written by hand after the version 1 format, not captured from Claude Code;
+// testdata/sessions-index/README.md says how to replace them with sanitized
+// captures. When Claude Code writes a new version, add a captured index as a
+// new fixture next to a new decoder; the fixtures of older versions stay.
+func TestSessionsIndexContract(t *testing.T) {
+	tests := []struct {
+		file         string
+		version      int
+		originalPath string
+		entries      []SessionEntry
+	}{
+		{
+			file:         "v1-windows.json",
+			version:      1,
+			originalPath: `C:\work\shop-api`,
+			entries: []SessionEntry{
+				{
+					SessionID:    "0b6f2c1e-5d4a-4e2b-9c1f-3a7d8e9f0a1b",
+					FullPath:     `C:\Users\dev\.claude\projects\C--work-shop-api\0b6f2c1e-5d4a-4e2b-9c1f-3a7d8e9f0a1b.jsonl`,
+					FirstPrompt:  "The checkout test fails on CI but not locally, can you look?",
+					Summary:      "Flaky checkout test fixed by freezing time",
+					MessageCount: 24,
+					Created:      "2026-01-21T09:12:03.417Z",
+					Modified:     "2026-01-21T10:40:00.000Z",
+					ProjectPath:  `C:\work\shop-api`,
+				},
+				{
+					SessionID:    "7c9d0e1f-2a3b-4c5d-8e6f-9a0b1c2d3e4f",
+					FullPath:     `C:\Users\dev\.claude\projects\C--work-shop-api\7c9d0e1f-2a3b-4c5d-8e6f-9a0b1c2d3e4f.jsonl`,
+					FirstPrompt:  "No prompt",
+					Summary:      "Explore payment module",
+					MessageCount: 6,
+					Created:      "2026-01-22T11:00:00.000Z",
+					Modified:     "2026-01-22T12:00:00.000Z",
+					ProjectPath:  `C:\work\shop-api`,
+					IsSidechain:  true,
+				},
+			},
+		},
+		{
+			file:    "v1-posix.json",
+			version: 1,
+			entries: []SessionEntry{
+				{
+					SessionID:    "e1d2c3b4-a596-4877-8899-aabbccddeeff",
+					FullPath:     "/home/dev/.claude/projects/-home-dev-src-notes/e1d2c3b4-a596-4877-8899-aabbccddeeff.jsonl",
+					FirstPrompt:  "add a search command",
+					MessageCount: 3,
+					Created:      "2026-02-03T12:00:00Z",
+					Modified:     "2026-02-03T12:30:00Z",
+					ProjectPath:  "/home/dev/src/notes",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			index, err := ReadSessionsIndex(filepath.Join("testdata", "sessions-index", tt.file))
+			if err != nil {
+				t.Fatalf("ReadSessionsIndex() error = %v", err)
+			}
+			if index.Version != tt.version || index.OriginalPath != tt.originalPath {
+				t.Errorf("version %d, originalPath %q; want %d, %q", index.Version, index.OriginalPath, tt.version, tt.originalPath)
+			}
+			if len(index.Entries) != len(tt.entries) {
+				t.Fatalf("%d entries, want %d", len(index.Entries), len(tt.entries))
+			}
+			for i, want := range tt.entries {
+				if index.Entries[i] != want {
+					t.Errorf("entry %d = %+v\nwant %+v", i, index.Entries[i], want)
+				}
+				// Times carry milliseconds, which the RFC 3339 layout accepts
+				for _, ts := range []string{want.Created, want.Modified} {
+					if _, err := time.Parse(time.RFC3339, ts); err != nil {
+						t.Errorf("entry %d: %v", i, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownIndexVersion(t *testing.T) {
+	for _, index := range []string{`{"version":2,"sessions":{}}`, `{"entries":[]}`} {
+		if _, err := decodeSessionsIndex([]byte(index)); !errors.Is(err, ErrUnknownIndexVersion) {
+			t.Errorf("decodeSessionsIndex(%s) error = %v, want ErrUnknownIndexVersion", index, err)
+		}
+	}
+
+	// A project with an index of an unknown version is read from its
+	// transcripts, and the index is reported
+	root := t.TempDir()
+	dir := writeTestProject(t, root, "/work/future", 1)
+	future := `{"version":2,"originalPath":"/work/elsewhere","sessions":{"session-0":{"modified":"2030-01-01T00:00:00Z"}}}`
+	os.WriteFile(filepath.Join(dir, "sessions-index.json"), []byte(future), 0644)
+
+	var diag Diagnostics
+	list, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, Diagnostics: &diag})
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Path != "/work/future" || list[0].LastUsed.Year() == 2030 {
+		t.Fatalf("projects = %+v, want /work/future read from its transcript", list)
+	}
+	if len(diag.Problems) != 1 || !strings.Contains(diag.Problems[0].Reason, "unknown sessions index version 2") {
+		t.Errorf("problems = %v, want the unknown index version", diag.Problems)
+	}
+
+	sessions, err := LoadSessions(list[0])
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "session-0" {
+		t.Errorf("LoadSessions() = %+v, %v; want the session from its transcript", sessions, err)
+	}
+}
+
+func TestRewriteUnknownIndexVersion(t *testing.T) {
+	root := t.TempDir()
+	dir := writeTestProject(t, root, "/work/old", 1)
+	path := filepath.Join(dir, "sessions-index.json")
+	future := `{"version":2,"sessions":{}}`
+	os.WriteFile(path, []byte(future), 0644)
+
+	// An index that can't be edited is left alone and reported
+	var diag Diagnostics
+	if err := rewriteSessionsIndex(dir, "/work/new", &diag); err != nil {
+		t.Fatalf("rewriteSessionsIndex() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != future {
+		t.Errorf("index = %q, want it left as it was", data)
+	}
+	if len(diag.Problems) != 1 || !strings.Contains(diag.Problems[0].Reason, "unknown sessions index version 2") {
+		t.Errorf("problems = %v, want the unknown index version", diag.Problems)
+	}
+
+	// Without an index, one is built from the transcripts
+	os.Remove(path)
+	if err := rewriteSessionsIndex(dir, "/work/new", nil); err != nil {
+		t.Fatalf("rewriteSessionsIndex() error = %v", err)
+	}
+	index, err := ReadSessionsIndex(path)
+	if err != nil {
+		t.Fatalf("ReadSessionsIndex() error = %v", err)
+	}
+	if index.Version != 1 || index.OriginalPath != "/work/new" || len(index.Entries) != 1 || index.Entries[0].ProjectPath != "/work/new" {
+		t.Errorf("rewritten index = %+v", index)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrReadLimit reports that a LineReader stopped at its read limit before the
+// end of the file
+var ErrReadLimit = errors.New("read limit reached")
+
+// LineReader reads a JSON Lines transcript one line at a time. A line longer
+// than the maximum is skipped rather than held in memory, so one huge tool
+// result doesn't hide the lines after it, and at most limit bytes of the file
+// are read in total.
+type LineReader struct {
+	r       *bufio.Reader
+	maxLine int
+	limit   int64 // 0 for no limit
+	read    int64
+
+	line    []byte
+	number  int
+	skipped int
+	err     error
+}
+
+// NewLineReader returns a reader of lines up to maxLine bytes long from r,
+// stopping after limit bytes unless limit is 0
+func NewLineReader(r io.Reader, maxLine int, limit int64) *LineReader {
+	return &LineReader{r: bufio.NewReaderSize(r, 64*1024), maxLine: maxLine, limit: limit}
+}
+
+// Scan advances to the next line that isn't blank or too long, returning
+// false at the end of the input, at the read limit or on an error. A last
+// line without a newline is returned as well.
+func (lr *LineReader) Scan() bool {
+	for lr.err == nil {
+		if lr.limit > 0 && lr.read >= lr.limit {
+			lr.err = ErrReadLimit
+			return false
+		}
+		lr.line = lr.line[:0]
+		tooLong := false
+		for {
+			chunk, err := lr.r.ReadSlice('\n')
+			lr.read += int64(len(chunk))
+			if !tooLong {
+				if len(lr.line)+len(chunk) > lr.maxLine+1 {
+					// Drop what was kept and skip to the end of the line
+					tooLong = true
+					lr.line = lr.line[:0]
+				} else {
+					lr.line = append(lr.line, chunk...)
+				}
+			}
+			if err == bufio.ErrBufferFull {
+				if lr.limit > 0 && lr.read >= lr.limit {
+					lr.err = ErrReadLimit
+					return false
+				}
+				continue
+			}
+			if err != nil {
+				lr.err = err
+			}
+			break
+		}
+		if lr.err != nil && !tooLong && len(lr.line) == 0 {
+			return false
+		}
+		lr.number++
+		if tooLong {
+			lr.skipped++
+			continue
+		}
+		lr.line = bytes.TrimSpace(lr.line)
+		if len(lr.line) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes returns the current line without its newline. It is overwritten by
+// the next call to Scan.
+func (lr *LineReader) Bytes() []byte {
+	return lr.line
+}
+
+// Line returns the number of the current line, counting from 1
+func (lr *LineReader) Line() int {
+	return lr.number
+}
+
+// Skipped returns the number of lines skipped for being too long so far
+func (lr *LineReader) Skipped() int {
+	return lr.skipped
+}
+
+// Err returns the error that ended the scan: nil at the end of the input,
+// ErrReadLimit at the read limit
+func (lr *LineReader) Err() error {
+	if lr.err == io.EOF {
+		return nil
+	}
+	return lr.err
+}
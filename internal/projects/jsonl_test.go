@@ -0,0 +1,101 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	input := "first\n\n" + strings.Repeat("x", 200*1024) + "\n  third  \r\nlast"
+	lr := NewLineReader(strings.NewReader(input), 1024, 0)
+
+	var lines []string
+	var numbers []int
+	for lr.Scan() {
+		lines = append(lines, string(lr.Bytes()))
+		numbers = append(numbers, lr.Line())
+	}
+	if fmt.Sprint(lines) != "[first third last]" || fmt.Sprint(numbers) != "[1 4 5]" {
+		t.Errorf("lines = %q at %v, want first, third and last at 1, 4 and 5", lines, numbers)
+	}
+	if lr.Skipped() != 1 {
+		t.Errorf("Skipped() = %d, want 1", lr.Skipped())
+	}
+	if lr.Err() != nil {
+		t.Errorf("Err() = %v, want nil", lr.Err())
+	}
+
+	lr = NewLineReader(strings.NewReader(strings.Repeat("line\n", 1000)), 1024, 100)
+	count := 0
+	for lr.Scan() {
+		count++
+	}
+	if count == 0 || count > 20 || lr.Err() != ErrReadLimit {
+		t.Errorf("read %d lines with a 100 byte limit, Err() = %v; want a few and ErrReadLimit", count, lr.Err())
+	}
+}
+
+func TestLoadDiagnostics(t *testing.T) {
+	root := t.TempDir()
+
+	// The cwd follows a line longer than the old 4 KB read
+	big := filepath.Join(root, "projects", "-work-big")
+	os.MkdirAll(big, 0755)
+	transcript := `{"type":"summary","summary":"` + strings.Repeat("s", 10000) + `"}` + "\n" +
+		"not json\n" +
+		`{"type":"user","cwd":"/work/big-project","sessionId":"s1"}` + "\n"
+	os.WriteFile(filepath.Join(big, "s1.jsonl"), []byte(transcript), 0644)
+
+	broken := filepath.Join(root, "projects", "-work-broken")
+	os.MkdirAll(broken, 0755)
+	os.WriteFile(filepath.Join(broken, "sessions-index.json"), []byte("{"), 0644)
+	os.WriteFile(filepath.Join(broken, "s2.jsonl"), []byte(`{"type":"user","cwd":"/work/broken","sessionId":"s2"}`), 0644)
+
+	indexed := writeTestProject(t, root, "/work/indexed", 1)
+
+	var diag Diagnostics
+	list, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, Diagnostics: &diag})
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("LoadProjectsContext() returned %d projects, want 3", len(list))
+	}
+
+	sources := make(map[string]string)
+	for _, p := range diag.Projects {
+		sources[p.Dir] = p.Path + " from " + strings.Join(p.Sources, ", ")
+	}
+	want := map[string]string{
+		big:     "/work/big-project from transcript cwd, transcript modification times",
+		broken:  "/work/broken from transcript cwd, transcript modification times",
+		indexed: "/work/indexed from sessions index, transcript modification times",
+	}
+	for dir, w := range want {
+		if sources[dir] != w {
+			t.Errorf("sources of %s = %q, want %q", filepath.Base(dir), sources[dir], w)
+		}
+	}
+
+	var problems []string
+	for _, p := range diag.Problems {
+		problems = append(problems, strings.TrimPrefix(p.File, root))
+	}
+	wantProblems := []string{
+		filepath.Join("/projects", "-work-big", "s1.jsonl"),
+		filepath.Join("/projects", "-work-broken", "sessions-index.json"),
+	}
+	if fmt.Sprint(problems) != fmt.Sprint(wantProblems) {
+		t.Errorf("problems in %v, want %v", diag.Problems, wantProblems)
+	}
+	if len(diag.Problems) > 0 && diag.Problems[0].Line != 2 {
+		t.Errorf("problem reported at line %d, want 2", diag.Problems[0].Line)
+	}
+}
@@ -0,0 +1,408 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/git"
+)
+
+// LoadOptions controls how LoadProjectsContext loads projects
+type LoadOptions struct {
+	// Roots are the Claude data roots to load from, each containing a
+	// projects/ folder. Empty means DefaultRoots().
+	Roots []string
+	// Workers is the number of project folders processed concurrently
+	Workers int
+	// StatTimeout bounds how long the existence check of a single project
+	// path, and reading what Git and Context ask for from its directory, may
+	// take before the project is reported as PathUnknown
+	StatTimeout time.Duration
+	// CachePath is the project cache file. Empty disables caching.
+	CachePath string
+	// Git enables reading git metadata for projects found on disk
+	Git bool
+	// Context enables looking for CLAUDE.md, settings, MCP servers, commands
+	// and agents in projects found on disk
+	Context bool
+	// OnUpdate, if set, receives the result of each existence check that
+	// outlived StatTimeout, together with what Git and Context ask to read
+	// from a directory it found. It is called from a background goroutine,
+	// possibly after LoadProjectsContext has returned, and not at all once
+	// the context is cancelled.
+	OnUpdate func(Update)
+	// Pinned lists project paths that are always returned, as placeholders
+	// without sessions if no data root has a folder for them
+	Pinned []string
+	// ClaudeJSON enables reading each root's .claude.json, which sets
+	// Project.Config and adds the projects it lists that have no folder
+	ClaudeJSON bool
+	// Diagnostics, if set, receives where each project was read from and
+	// every file that couldn't be read or parsed
+	Diagnostics *Diagnostics
+}
+
+// Update is what is learned about a project after it was loaded: the result
+// of an existence check that LoadProjectsContext returned as PathUnknown, or
+// the git status ReadGitStatus found
+type Update struct {
+	Root string // The data root of the project
+	Path string // The path of the project, as returned
+	// PathState is PathUnknown if the update isn't about the path
+	PathState PathState
+	// Git and Context are read from a directory that was found, if the
+	// LoadOptions ask for them. Git includes the status for ReadGitStatus.
+	Git     *git.Info
+	Context *Context
+}
+
+// Key returns the Key of the project u is about
+func (u Update) Key() string {
+	return Project{Root: u.Root, Path: u.Path}.Key()
+}
+
+// Apply records what u learned on p
+func (u Update) Apply(p *Project) {
+	if u.PathState != PathUnknown {
+		p.PathState = u.PathState
+	}
+	if u.Git != nil {
+		setGit(p, u.Git)
+	}
+	if u.Context != nil {
+		p.Context = u.Context
+	}
+}
+
+// Defaults used when the corresponding LoadOptions field is zero
+const (
+	defaultWorkers     = 8
+	defaultStatTimeout = 300 * time.Millisecond
+)
+
+// DefaultLoadOptions returns the options used by LoadProjects
+func DefaultLoadOptions() LoadOptions {
+	// A missing cache location only costs speed
+	cachePath, _ := defaultCachePath()
+	return LoadOptions{
+		Workers:     defaultWorkers,
+		StatTimeout: defaultStatTimeout,
+		CachePath:   cachePath,
+		Git:         true,
+		Context:     true,
+		ClaudeJSON:  true,
+	}
+}
+
+// LoadProjectsContext loads all Claude Code projects from the projects/ folder
+// of every root in opts.Roots, processing project folders on a bounded pool
+// of workers. Each project records the root it came from. A project whose
+// path can't be checked within opts.StatTimeout is returned as PathUnknown and
+// its final state and description are delivered later through opts.OnUpdate,
+// so one unreachable drive doesn't hold up the whole list.
+func LoadProjectsContext(ctx context.Context, opts LoadOptions) ([]Project, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.StatTimeout <= 0 {
+		opts.StatTimeout = defaultStatTimeout
+	}
+	if len(opts.Roots) == 0 {
+		opts.Roots = DefaultRoots()
+	}
+
+	// A project folder to load: <root>/projects/<name>
+	type folder struct {
+		root, name string
+	}
+
+	var folders []folder
+	var readErr error
+	// The roots whose folders are all known, so cache entries of others are kept
+	var read []string
+	for _, root := range opts.Roots {
+		entries, err := os.ReadDir(filepath.Join(root, "projects"))
+		if os.IsNotExist(err) {
+			// A root without projects yet is not an error
+			read = append(read, root)
+			continue
+		}
+		if err != nil {
+			opts.Diagnostics.addProblem(filepath.Join(root, "projects"), 0, err)
+			if readErr == nil {
+				readErr = err
+			}
+			continue
+		}
+		read = append(read, root)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				folders = append(folders, folder{root, entry.Name()})
+			}
+		}
+	}
+	if len(folders) == 0 && readErr != nil {
+		return nil, readErr
+	}
+
+	cache := loadCache(opts.CachePath)
+
+	// Each worker writes only its own slots, so results need no locking
+	results := make([]Project, len(folders))
+	loaded := make([]bool, len(folders))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers && w < len(folders); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				project, ok := loadProject(folders[i].root, folders[i].name, cache, opts.Diagnostics)
+				if !ok {
+					continue
+				}
+				checkProject(ctx, &project, opts)
+				if project.Git != nil && project.Git.RemoteURL != "" {
+					cache.setRemoteURL(filepath.Join(folders[i].root, "projects", folders[i].name), project.RemoteURL)
+				}
+				results[i] = project
+				loaded[i] = true
+			}
+		}()
+	}
+
+feed:
+	for i := range folders {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var projects []Project
+	for i, project := range results {
+		if loaded[i] {
+			projects = append(projects, project)
+		}
+	}
+
+	// Several encoded folders can belong to the same directory
+	projects = mergeDuplicates(projects)
+	if opts.ClaudeJSON {
+		projects = append(projects, loadClaudeJSON(ctx, projects, opts)...)
+	}
+	projects = append(projects, pinnedPlaceholders(ctx, projects, opts)...)
+	if len(projects) == 0 {
+		return nil, ErrNoProjects
+	}
+
+	if opts.CachePath != "" {
+		// Failing to write the cache only costs speed on the next run
+		cache.save(opts.CachePath, read)
+	}
+
+	// Sort by last used (most recent first) by default
+	SortByLastUsed(projects)
+	opts.Diagnostics.sort()
+
+	return projects, nil
+}
+
+// describe reads what LoadOptions asks for from the directory of a project
+// known to exist
+func describe(p *Project, opts LoadOptions) {
+	p.Path = resolvePath(p.Path)
+	p.Name = filepath.Base(p.Path)
+	if opts.Context {
+		p.Context = ReadContext(p.Path)
+	}
+	if opts.Git {
+		// Not being in a repository is the common case, not an error
+		if info, err := git.Read(p.Path); err == nil {
+			setGit(p, info)
+		}
+	}
+}
+
+// Describe reads what opts asks for from the directory of p, which must
+// exist. It is for callers that load with Git and Context off and read them
+// only for the projects they need.
+func (opts LoadOptions) Describe(p *Project) {
+	describe(p, opts)
+}
+
+// ReadGitStatus reads the git status of every project in list that is in a
+// repository, which LoadProjectsContext leaves out as it takes a walk of the
+// history and working tree, on a pool of workers. Each status is reported
+// as an Update from a worker goroutine as soon as it is read; list isn't
+// changed. It returns once all are read or ctx is cancelled.
+func ReadGitStatus(ctx context.Context, list []Project, workers int, report func(Update)) {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	jobs := make(chan Project)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				info := *p.Git
+				info.Status = info.ReadStatus()
+				if ctx.Err() == nil {
+					report(Update{Root: p.Root, Path: p.Path, Git: &info})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, p := range list {
+		if p.Git == nil || p.Git.Checked {
+			continue
+		}
+		select {
+		case jobs <- p:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// setGit sets the git state of p and what is derived from it
+func setGit(p *Project, info *git.Info) {
+	p.Git = info
+	p.RepoRoot = info.WorkTree
+	p.WorktreeOf = info.MainWorkTree
+	if info.RemoteURL != "" {
+		p.RemoteURL = info.RemoteURL
+	}
+}
+
+// onLate returns the function statPath reports late checks of projects in
+// root to, which passes them on to OnUpdate along with the description of a
+// directory found late, or nil if there is no OnUpdate
+func (opts LoadOptions) onLate(root string) func(string, PathState) {
+	if opts.OnUpdate == nil {
+		return nil
+	}
+	return func(path string, state PathState) {
+		u := Update{Root: root, Path: path, PathState: state}
+		if state == PathFound {
+			found := Project{Path: path}
+			describe(&found, opts)
+			u.Git, u.Context = found.Git, found.Context
+		}
+		opts.OnUpdate(u)
+	}
+}
+
+// stat is os.Stat, replaced in tests to stand in for an unreachable drive
+var stat = os.Stat
+
+// pathState checks whether path exists
+func pathState(path string) PathState {
+	_, err := stat(path)
+	switch {
+	case err == nil:
+		return PathFound
+	case os.IsNotExist(err):
+		return PathMissing
+	default:
+		return PathUnknown
+	}
+}
+
+// checkProject sets the PathState of p and, if its directory is found, reads
+// what opts asks for from it, waiting at most opts.StatTimeout for both: a
+// slow drive is as slow to describe as to check. A check that takes longer
+// leaves p PathUnknown and undescribed, and keeps running in the background
+// to report its result through opts.OnUpdate, unless ctx is cancelled first.
+func checkProject(ctx context.Context, p *Project, opts LoadOptions) {
+	done := make(chan Project, 1)
+	go func(q Project) {
+		q.PathState = pathState(q.Path)
+		if q.PathState == PathFound {
+			describe(&q, opts)
+		}
+		done <- q
+	}(*p)
+
+	timer := time.NewTimer(opts.StatTimeout)
+	defer timer.Stop()
+
+	select {
+	case q := <-done:
+		*p = q
+		return
+	case <-ctx.Done():
+		p.PathState = PathUnknown
+		return
+	case <-timer.C:
+	}
+
+	p.PathState = PathUnknown
+	if opts.OnUpdate == nil {
+		return
+	}
+	root, path := p.Root, p.Path
+	go func() {
+		select {
+		case q := <-done:
+			if ctx.Err() == nil {
+				opts.OnUpdate(Update{Root: root, Path: path, PathState: q.PathState, Git: q.Git, Context: q.Context})
+			}
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// statPath checks whether path exists, waiting at most timeout. A check that
+// takes longer keeps running in the background and reports its result through
+// onLate, unless ctx is cancelled first.
+func statPath(ctx context.Context, path string, timeout time.Duration, onLate func(string, PathState)) PathState {
+	done := make(chan PathState, 1)
+	go func() {
+		done <- pathState(path)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case state := <-done:
+		return state
+	case <-ctx.Done():
+		return PathUnknown
+	case <-timer.C:
+	}
+
+	if onLate != nil {
+		go func() {
+			select {
+			case state := <-done:
+				if ctx.Err() == nil {
+					onLate(path, state)
+				}
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return PathUnknown
+}
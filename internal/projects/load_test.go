@@ -0,0 +1,163 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadProjectsContextPathStates(t *testing.T) {
+	root := t.TempDir()
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "gone")
+
+	writeTestProject(t, root, existing, 1)
+	writeTestProject(t, root, missing, 1)
+	for i := 0; i < 20; i++ {
+		writeTestProject(t, root, fmt.Sprintf("%s-%d", missing, i), 1)
+	}
+
+	projects, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}, Workers: 4, StatTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(projects) != 22 {
+		t.Fatalf("LoadProjectsContext() returned %d projects, want 22", len(projects))
+	}
+
+	states := make(map[string]PathState)
+	for _, p := range projects {
+		states[p.Path] = p.PathState
+	}
+	if states[existing] != PathFound {
+		t.Errorf("existing project state = %v, want PathFound", states[existing])
+	}
+	if states[missing] != PathMissing {
+		t.Errorf("missing project state = %v, want PathMissing", states[missing])
+	}
+}
+
+func TestLoadProjectsContextCancelled(t *testing.T) {
+	root := t.TempDir()
+	writeTestProject(t, root, "/work/cancelled", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := LoadProjectsContext(ctx, LoadOptions{Roots: []string{root}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadProjectsContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestLoadProjectsContextNoProjects(t *testing.T) {
+	if _, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{filepath.Join(t.TempDir(), "none")}}); !errors.Is(err, ErrNoProjects) {
+		t.Errorf("LoadProjectsContext() error = %v, want ErrNoProjects", err)
+	}
+
+	empty := t.TempDir()
+	os.MkdirAll(filepath.Join(empty, "projects"), 0755)
+	os.WriteFile(filepath.Join(empty, "projects", "stray.txt"), nil, 0644)
+	if _, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{empty}}); !errors.Is(err, ErrNoProjects) {
+		t.Errorf("LoadProjectsContext() error = %v, want ErrNoProjects", err)
+	}
+}
+
+func TestLoadProjectsContextLateUpdate(t *testing.T) {
+	root := t.TempDir()
+	slow := t.TempDir()
+	os.WriteFile(filepath.Join(slow, "CLAUDE.md"), []byte("# Notes"), 0644)
+	writeTestProject(t, root, slow, 1)
+
+	// The project's drive answers only once released
+	release := make(chan struct{})
+	stat = func(path string) (os.FileInfo, error) {
+		if path == slow {
+			<-release
+		}
+		return os.Stat(path)
+	}
+	defer func() { stat = os.Stat }()
+
+	updates := make(chan Update, 1)
+	opts := LoadOptions{
+		Roots:       []string{root},
+		StatTimeout: 20 * time.Millisecond,
+		Context:     true,
+		OnUpdate:    func(u Update) { updates <- u },
+	}
+	list, err := LoadProjectsContext(context.Background(), opts)
+	close(release)
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(list) != 1 || list[0].PathState != PathUnknown || list[0].Context != nil {
+		t.Fatalf("projects = %+v, want the slow one unknown and undescribed", list)
+	}
+
+	select {
+	case u := <-updates:
+		if u.Key() != list[0].Key() || u.PathState != PathFound || u.Context == nil || !u.Context.ClaudeMD {
+			t.Errorf("update = %+v, want the project found with its context", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update for the slow project")
+	}
+}
+
+func TestLoadOptionsOnLate(t *testing.T) {
+	if (LoadOptions{}).onLate("/root") != nil {
+		t.Error("onLate() without OnUpdate should be nil")
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte("# Notes"), 0644)
+	var got []Update
+	opts := LoadOptions{Context: true, OnUpdate: func(u Update) { got = append(got, u) }}
+	opts.onLate("/root")(dir, PathFound)
+	opts.onLate("/root")(filepath.Join(dir, "gone"), PathMissing)
+
+	if len(got) != 2 || got[0].Context == nil || !got[0].Context.ClaudeMD || got[1].Context != nil {
+		t.Fatalf("updates = %+v, want the context of the found directory only", got)
+	}
+	if got[0].Key() != (Project{Root: "/root", Path: dir}).Key() {
+		t.Errorf("Key() = %q, want the key of the project in /root", got[0].Key())
+	}
+	p := Project{Path: dir, PathState: PathUnknown}
+	got[0].Apply(&p)
+	if p.PathState != PathFound || p.Context != got[0].Context {
+		t.Errorf("Apply() = %+v", p)
+	}
+}
+
+func TestReadGitStatus(t *testing.T) {
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	p := Project{Path: repo, PathState: PathFound}
+	describe(&p, LoadOptions{Git: true})
+	if p.Git == nil || p.Git.Branch != "main" || p.Git.Checked {
+		t.Fatalf("describe() Git = %+v, want the branch without the status", p.Git)
+	}
+
+	var got []Update
+	list := []Project{p, {Path: t.TempDir()}}
+	ReadGitStatus(context.Background(), list, 2, func(u Update) { got = append(got, u) })
+	if len(got) != 1 || got[0].Path != repo || !got[0].Git.Checked || !got[0].Git.DirtyKnown {
+		t.Fatalf("ReadGitStatus() reported %+v, want the status of the repository", got)
+	}
+	if list[0].Git.Checked {
+		t.Error("ReadGitStatus() changed the list")
+	}
+	got[0].Apply(&p)
+	if !p.Git.Checked || p.PathState != PathFound {
+		t.Errorf("Apply() = %+v, want the status set and the path state kept", p)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// canonicalPath cleans a project path so that spellings of the same folder
+// compare equal where possible: redundant and trailing separators are
+// removed and, on Windows, the drive letter is upper-cased. It never touches
+// the filesystem.
+func canonicalPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" && len(path) >= 2 && path[1] == ':' {
+		path = strings.ToUpper(path[:1]) + path[1:]
+	}
+	return path
+}
+
+// resolvePath follows symlinks in a project path that is known to exist, so
+// a folder reached through a link and through its real location merge into
+// one project. Windows paths are compared case-insensitively instead, as
+// junctions there are rarely used for project folders. On error the path is
+// returned unchanged.
+func resolvePath(path string) string {
+	if runtime.GOOS == "windows" {
+		return path
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+// Key identifies a project: the same directory in two data roots is two
+// projects, which are never merged
+func (p Project) Key() string {
+	return fsutil.PathKey(p.Root) + "\x00" + fsutil.PathKey(p.Path)
+}
+
+// mergeDuplicates folds projects from the same data root that point at the
+// same folder into one. The most recently used duplicate provides the path
+// spelling and EncodedDir; the merged project carries every EncodedDir, the
+// latest LastUsed and the most certain PathState. Projects from different
+// roots are never merged, since they belong to different Claude Code
+// configurations. The order of first appearance is kept.
+func mergeDuplicates(list []Project) []Project {
+	merged := make([]Project, 0, len(list))
+	index := make(map[string]int, len(list))
+
+	for _, p := range list {
+		key := p.Key()
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, p)
+			continue
+		}
+
+		existing := merged[i]
+		primary, other := existing, p
+		if p.LastUsed.After(existing.LastUsed) {
+			primary, other = p, existing
+		}
+		primary.EncodedDirs = append(append([]string(nil), primary.EncodedDirs...), other.EncodedDirs...)
+		primary.PathState = mergePathState(primary.PathState, other.PathState)
+		if primary.RemoteURL == "" {
+			primary.RemoteURL = other.RemoteURL
+		}
+		merged[i] = primary
+	}
+	return merged
+}
+
+// mergePathState returns the more certain of two checks of the same folder:
+// found beats unknown, which beats missing
+func mergePathState(a, b PathState) PathState {
+	if a == PathFound || b == PathFound {
+		return PathFound
+	}
+	if a == PathUnknown || b == PathUnknown {
+		return PathUnknown
+	}
+	return PathMissing
+}
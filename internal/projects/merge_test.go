@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMergeDuplicates(t *testing.T) {
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	list := []Project{
+		{Path: "/work/app", Root: "/r", EncodedDir: "a", EncodedDirs: []string{"a"}, LastUsed: older, PathState: PathUnknown},
+		{Path: "/work/other", Root: "/r", EncodedDir: "o", EncodedDirs: []string{"o"}, LastUsed: older},
+		{Path: "/work/app", Root: "/r", EncodedDir: "b", EncodedDirs: []string{"b"}, LastUsed: newer, PathState: PathFound},
+		{Path: "/work/app", Root: "/other-root", EncodedDir: "c", EncodedDirs: []string{"c"}, LastUsed: newer},
+	}
+
+	merged := mergeDuplicates(list)
+	if len(merged) != 3 {
+		t.Fatalf("mergeDuplicates() returned %d projects, want 3", len(merged))
+	}
+
+	app := merged[0]
+	if app.EncodedDir != "b" {
+		t.Errorf("merged EncodedDir = %q, want the most recent %q", app.EncodedDir, "b")
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(app.EncodedDirs, want) {
+		t.Errorf("merged EncodedDirs = %v, want %v", app.EncodedDirs, want)
+	}
+	if !app.LastUsed.Equal(newer) {
+		t.Errorf("merged LastUsed = %v, want %v", app.LastUsed, newer)
+	}
+	if app.PathState != PathFound {
+		t.Errorf("merged PathState = %v, want PathFound", app.PathState)
+	}
+	if merged[2].Root != "/other-root" {
+		t.Errorf("project from another root was merged")
+	}
+}
+
+func TestLoadProjectsContextMergesDuplicates(t *testing.T) {
+	root := t.TempDir()
+	target := t.TempDir()
+
+	// The same folder recorded with a trailing separator and through a symlink
+	writeTestProject(t, root, target, 1)
+	trailing := writeTestProject(t, root, target+string(filepath.Separator), 1)
+	os.Rename(filepath.Join(trailing, "session-0.jsonl"), filepath.Join(trailing, "session-1.jsonl"))
+	if runtime.GOOS != "windows" {
+		link := filepath.Join(t.TempDir(), "link")
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		writeTestProject(t, root, link, 1)
+	}
+
+	projects, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{root}})
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("LoadProjectsContext() returned %d projects, want 1: %+v", len(projects), projects)
+	}
+	if projects[0].Path != resolvePath(target) {
+		t.Errorf("merged Path = %q, want %q", projects[0].Path, resolvePath(target))
+	}
+
+	sessions, err := LoadSessions(projects[0])
+	if err != nil {
+		t.Fatalf("LoadSessions() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("LoadSessions() returned %d sessions, want 2 from all merged folders", len(sessions))
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// PinFirst moves the projects whose path is pinned to the front of the list,
+// keeping the order among pinned and among other projects
+func PinFirst(list []Project, pinned func(path string) bool) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return pinned(list[i].Path) && !pinned(list[j].Path)
+	})
+}
+
+// pinnedPlaceholders returns a project for every pinned path that isn't in
+// list, so a pin stays visible while Claude Code has no data for it, e.g.
+// because the data root is on a drive that isn't connected. They belong to
+// the first root and are checked like loaded projects.
+func pinnedPlaceholders(ctx context.Context, list []Project, opts LoadOptions) []Project {
+	known := make(map[string]bool, len(list))
+	for _, p := range list {
+		known[fsutil.PathKey(p.Path)] = true
+	}
+	var placeholders []Project
+	for _, path := range opts.Pinned {
+		path = filepath.Clean(path)
+		if known[fsutil.PathKey(path)] {
+			continue
+		}
+		known[fsutil.PathKey(path)] = true
+		placeholders = append(placeholders, newProject(path, opts.Roots[0], encodePath(path), time.Time{}))
+		opts.Diagnostics.addProject(ProjectSources{Path: path, Sources: []string{SourcePinned}})
+	}
+
+	// Check the folders together, so that pins on an unreachable drive take
+	// one StatTimeout rather than one each. Pins are picked by hand, so
+	// there are few of them.
+	var wg sync.WaitGroup
+	for i := range placeholders {
+		wg.Add(1)
+		go func(p *Project) {
+			defer wg.Done()
+			p.PathState = statPath(ctx, p.Path, opts.StatTimeout, opts.onLate(p.Root))
+		}(&placeholders[i])
+	}
+	wg.Wait()
+	return placeholders
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPinFirst(t *testing.T) {
+	list := []Project{
+		{Name: "a", Path: "/work/a"},
+		{Name: "b", Path: "/work/b"},
+		{Name: "c", Path: "/work/c"},
+		{Name: "d", Path: "/work/d"},
+	}
+	pinned := map[string]bool{"/work/b": true, "/work/d": true}
+	PinFirst(list, func(path string) bool { return pinned[path] })
+
+	want := []string{"b", "d", "a", "c"}
+	for i, name := range want {
+		if list[i].Name != name {
+			t.Errorf("position %d = %s, want %s", i, list[i].Name, name)
+		}
+	}
+}
+
+func TestLoadProjectsContextPinned(t *testing.T) {
+	root := t.TempDir()
+	existing := t.TempDir()
+	writeTestProject(t, root, existing, 1)
+	// A pinned project on a drive that isn't mounted, without any sessions
+	unmounted := filepath.Join(t.TempDir(), "unmounted", "app")
+
+	list, err := LoadProjectsContext(context.Background(), LoadOptions{
+		Roots:       []string{root},
+		StatTimeout: time.Second,
+		Pinned:      []string{existing, unmounted, unmounted + string(filepath.Separator)},
+	})
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("LoadProjectsContext() returned %d projects, want 2: %+v", len(list), list)
+	}
+
+	var placeholder *Project
+	for i := range list {
+		if list[i].Path == unmounted {
+			placeholder = &list[i]
+		}
+	}
+	if placeholder == nil {
+		t.Fatalf("pinned path %s missing from %+v", unmounted, list)
+	}
+	if placeholder.Name != "app" || placeholder.PathState != PathMissing || !placeholder.LastUsed.IsZero() {
+		t.Errorf("placeholder = %+v, want app, PathMissing, never used", placeholder)
+	}
+	if placeholder.Root != root {
+		t.Errorf("placeholder root = %s, want %s", placeholder.Root, root)
+	}
+}
@@ -4,13 +4,18 @@
 package projects
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/git"
 )
 
 // Project represents a Claude Code project
@@ -18,10 +23,65 @@ type Project struct {
 	Name       string    // Last component of the path
 	Path       string    // Full path to the project directory
 	LastUsed   time.Time // Last modified time
-	PathExists bool      // Whether the project directory exists on disk
-	EncodedDir string    // The encoded directory name in .claude/projects/
+	PathState  PathState // Whether the project directory exists on disk
+	EncodedDir string    // The encoded directory name in <Root>/projects/
+	Root       string    // Claude data root the project was found in, e.g. ~/.claude
+	// EncodedDirs lists every encoded directory in <Root>/projects/ holding
+	// sessions for this path, starting with EncodedDir. It has more than one
+	// entry when Claude Code recorded the same folder under different
+	// spellings, e.g. with a different drive letter case.
+	EncodedDirs []string
+	// Git holds the state of the git checkout at Path, or nil if the project
+	// isn't in a repository or git metadata wasn't loaded
+	Git *git.Info
+	// RepoRoot is the top of the git working tree containing Path. For a
+	// linked worktree, WorktreeOf is the main checkout it belongs to.
+	RepoRoot   string
+	WorktreeOf string
+	// RemoteURL is the origin URL of the project's repository, remembered
+	// from when the directory last existed so a moved checkout can be found
+	RemoteURL string
+	// Alias replaces Name in the list and Tags label the project, both set
+	// by the user in the switcher's config
+	Alias string
+	Tags  []string
+	// Note is the user's free-text note on the project, kept by the notes
+	// package
+	Note string
+	// New marks a repository found under a scan root that Claude Code has
+	// no data for yet
+	New bool
+	// Hidden marks a project the user's ignore rules leave out of the list
+	Hidden bool
+	// Context is the Claude Code configuration found in the project
+	// directory, or nil if it wasn't looked for or the directory is missing
+	Context *Context
+	// Config is the project's entry in Claude Code's .claude.json, or nil
+	// if it has none or the file wasn't read
+	Config *ProjectConfig
 }
 
+// DisplayName returns the alias of the project, or its folder name
+func (p Project) DisplayName() string {
+	if p.Alias != "" {
+		return p.Alias
+	}
+	return p.Name
+}
+
+// PathState is the result of checking whether a project directory exists
+type PathState int
+
+const (
+	// PathUnknown means the check didn't finish in time or failed for a
+	// reason other than the path not existing (e.g. an offline network share)
+	PathUnknown PathState = iota
+	// PathFound means the directory exists
+	PathFound
+	// PathMissing means the directory doesn't exist
+	PathMissing
+)
+
 // SessionsIndex represents the sessions-index.json structure
 type SessionsIndex struct {
 	Version      int            `json:"version"`
@@ -31,95 +91,115 @@ type SessionsIndex struct {
 
 // SessionEntry represents a single session entry
 type SessionEntry struct {
-	SessionID   string `json:"sessionId"`
-	FullPath    string `json:"fullPath"`
-	Summary     string `json:"summary"`
-	Modified    string `json:"modified"`
-	ProjectPath string `json:"projectPath"`
+	SessionID    string `json:"sessionId"`
+	FullPath     string `json:"fullPath"`
+	FirstPrompt  string `json:"firstPrompt"`
+	Summary      string `json:"summary"`
+	MessageCount int    `json:"messageCount"`
+	Created      string `json:"created"`
+	Modified     string `json:"modified"`
+	ProjectPath  string `json:"projectPath"`
+	IsSidechain  bool   `json:"isSidechain"`
 }
 
 // ErrNoProjects indicates the .claude/projects directory doesn't exist
 var ErrNoProjects = fmt.Errorf("no Claude Code projects found")
 
-// LoadProjects loads all Claude Code projects from ~/.claude/projects/
+// LoadProjects loads all Claude Code projects from the default data roots
+// using the default options. Path checks that time out are left as PathUnknown.
 func LoadProjects() ([]Project, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
+	return LoadProjectsContext(context.Background(), DefaultLoadOptions())
+}
 
-	projectsDir := filepath.Join(homeDir, ".claude", "projects")
-	entries, err := os.ReadDir(projectsDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, ErrNoProjects
+// loadProject reads the project stored in <root>/projects/<encodedName>,
+// using the cached result when the folder's fingerprint is unchanged. It
+// returns false if no project path could be determined.
+// Where the path and last use came from, and the files that couldn't be
+// used, are recorded in diag if it isn't nil.
+func loadProject(root, encodedName string, cache *projectCache, diag *Diagnostics) (Project, bool) {
+	projectDir := filepath.Join(root, "projects", encodedName)
+
+	fingerprint, fpErr := fingerprintDir(projectDir)
+	if fpErr == nil {
+		if cached, ok := cache.lookup(projectDir, fingerprint); ok {
+			project := newProject(cached.Path, root, encodedName, cached.LastUsed)
+			project.RemoteURL = cached.RemoteURL
+			diag.addProject(ProjectSources{Dir: projectDir, Path: project.Path, Sources: []string{SourceCache}})
+			return project, true
 		}
-		return nil, err
+	} else {
+		diag.addProblem(projectDir, 0, fpErr)
 	}
 
-	if len(entries) == 0 {
-		return nil, ErrNoProjects
-	}
+	sessionsFile := filepath.Join(projectDir, "sessions-index.json")
 
-	var projects []Project
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+	// Try to get project path and last used time from sessions-index.json
+	var sources []string
+	projectPath, lastUsed, err := loadProjectInfo(sessionsFile)
+	if err == nil {
+		sources = append(sources, SourceIndex)
+	} else {
+		if !os.IsNotExist(err) {
+			diag.addProblem(sessionsFile, 0, err)
 		}
-
-		encodedName := entry.Name()
-		projectDir := filepath.Join(projectsDir, encodedName)
-		sessionsFile := filepath.Join(projectDir, "sessions-index.json")
-
-		// Try to get project path and last used time from sessions-index.json
-		projectPath, lastUsed, err := loadProjectInfo(sessionsFile)
-		if err != nil {
-			// Try reading cwd from a session .jsonl file
-			projectPath = extractCwdFromSessions(projectDir)
-			if projectPath == "" {
-				// Last resort: decode the path from directory name
-				projectPath = decodePath(encodedName)
-			}
-			if projectPath == "" {
-				continue
-			}
+		// Try reading cwd from a session .jsonl file
+		projectPath = extractCwdFromSessions(projectDir, diag)
+		if projectPath != "" {
+			sources = append(sources, SourceTranscript)
+		} else {
+			// Last resort: decode the path from directory name
+			projectPath = decodePath(encodedName)
+			sources = append(sources, SourceFolderName)
 		}
-
-		// Always check .jsonl modtimes - sessions-index.json may be stale
-		if jsonlTime := latestJsonlModTime(projectDir); jsonlTime.After(lastUsed) {
-			lastUsed = jsonlTime
+		if projectPath == "" {
+			diag.addProblem(projectDir, 0, errNoProjectPath)
+			return Project{}, false
 		}
+	}
 
-		_, statErr := os.Stat(projectPath)
-		project := Project{
-			Name:       filepath.Base(projectPath),
-			Path:       projectPath,
-			EncodedDir: encodedName,
-			LastUsed:   lastUsed,
-			PathExists: statErr == nil,
-		}
+	// Always check .jsonl modtimes - sessions-index.json may be stale
+	if jsonlTime := latestJsonlModTime(projectDir); jsonlTime.After(lastUsed) {
+		lastUsed = jsonlTime
+		sources = append(sources, SourceModTimes)
+	}
 
-		projects = append(projects, project)
+	if fpErr == nil {
+		cache.store(projectDir, cacheEntry{
+			Fingerprint: fingerprint,
+			Path:        projectPath,
+			LastUsed:    lastUsed,
+		})
 	}
 
-	// Sort by last used (most recent first) by default
-	SortByLastUsed(projects)
+	project := newProject(projectPath, root, encodedName, lastUsed)
+	project.RemoteURL = cache.remoteURL(projectDir)
+	diag.addProject(ProjectSources{Dir: projectDir, Path: project.Path, Sources: sources})
+	return project, true
+}
 
-	return projects, nil
+// errNoProjectPath reports a project folder whose path couldn't be found
+var errNoProjectPath = errors.New("no project path in the sessions index, the transcripts or the folder name")
+
+// newProject builds a Project for a resolved path. PathState is left for the caller.
+func newProject(projectPath, root, encodedName string, lastUsed time.Time) Project {
+	projectPath = canonicalPath(projectPath)
+	return Project{
+		Name:        filepath.Base(projectPath),
+		Path:        projectPath,
+		EncodedDir:  encodedName,
+		EncodedDirs: []string{encodedName},
+		Root:        root,
+		LastUsed:    lastUsed,
+	}
 }
 
 // loadProjectInfo reads sessions-index.json and returns the project path and last used time
 func loadProjectInfo(filePath string) (string, time.Time, error) {
-	data, err := os.ReadFile(filePath)
+	index, err := ReadSessionsIndex(filePath)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
-	var index SessionsIndex
-	if err := json.Unmarshal(data, &index); err != nil {
-		return "", time.Time{}, err
-	}
-
 	// Get project path from originalPath or first entry's projectPath
 	projectPath := index.OriginalPath
 	if projectPath == "" && len(index.Entries) > 0 {
@@ -176,12 +256,22 @@ func latestJsonlModTime(projectDir string) time.Time {
 	return latest
 }
 
-// extractCwdFromSessions reads the first .jsonl file in the project directory
-// and extracts the cwd field, which contains the actual project path.
-// This is used when sessions-index.json is not available.
-func extractCwdFromSessions(projectDir string) string {
+// Limits on reading a transcript for its cwd. The first lines may be a
+// summary or a tool result many megabytes long, so those are skipped rather
+// than given up on.
+const (
+	maxCwdLine = 4 << 20
+	maxCwdRead = 32 << 20
+)
+
+// extractCwdFromSessions reads the .jsonl files in the project directory
+// until one has a cwd field, which contains the actual project path.
+// This is used when sessions-index.json is not available. Files that can't
+// be read or parsed are recorded in diag if it isn't nil.
+func extractCwdFromSessions(projectDir string, diag *Diagnostics) string {
 	entries, err := os.ReadDir(projectDir)
 	if err != nil {
+		diag.addProblem(projectDir, 0, err)
 		return ""
 	}
 
@@ -190,105 +280,154 @@ func extractCwdFromSessions(projectDir string) string {
 			continue
 		}
 
-		f, err := os.Open(filepath.Join(projectDir, entry.Name()))
-		if err != nil {
-			continue
+		path := filepath.Join(projectDir, entry.Name())
+		if cwd := readCwd(path, diag); cwd != "" {
+			return cwd
 		}
+	}
 
-		// Read enough to find the cwd field in the first few lines
-		buf := make([]byte, 4096)
-		n, _ := f.Read(buf)
-		f.Close()
+	return ""
+}
 
-		if n == 0 {
+// readCwd returns the first cwd field in a transcript, or "" if there is
+// none within maxCwdRead bytes
+func readCwd(path string, diag *Diagnostics) string {
+	f, err := os.Open(path)
+	if err != nil {
+		diag.addProblem(path, 0, err)
+		return ""
+	}
+	defer f.Close()
+
+	lr := NewLineReader(f, maxCwdLine, maxCwdRead)
+	reported := false
+	for lr.Scan() {
+		var msg sessionMessage
+		if err := json.Unmarshal(lr.Bytes(), &msg); err != nil {
+			// One bad line says enough about a file
+			if !reported {
+				diag.addProblem(path, lr.Line(), err)
+				reported = true
+			}
 			continue
 		}
-
-		// Parse each line as JSON looking for cwd
-		for _, line := range strings.Split(string(buf[:n]), "\n") {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			var msg sessionMessage
-			if err := json.Unmarshal([]byte(line), &msg); err == nil && msg.Cwd != "" {
-				return msg.Cwd
-			}
+		if msg.Cwd != "" {
+			return msg.Cwd
 		}
 	}
 
+	if n := lr.Skipped(); n > 0 {
+		diag.addProblem(path, 0, fmt.Errorf("%d lines longer than %d MB skipped", n, maxCwdLine>>20))
+	}
+	switch err := lr.Err(); {
+	case err == ErrReadLimit:
+		diag.addProblem(path, 0, fmt.Errorf("no cwd in the first %d MB", maxCwdRead>>20))
+	case err != nil:
+		diag.addProblem(path, 0, err)
+	}
 	return ""
 }
 
-// decodePath converts an encoded path like "c--work-root-project" to a path
-// This is a fallback when sessions-index.json is not available
-// Since we can't distinguish path separators from literal hyphens in folder names,
-// we try multiple interpretations and verify against the filesystem
+// decodePath converts an encoded directory name back to a path.
+// This is a fallback when sessions-index.json is not available.
+//
+// Claude Code encodes a path by replacing every character that isn't an ASCII
+// letter or digit with a hyphen, so the encoding depends on the platform the
+// session was recorded on:
+//
+//	"c:\work\root\fanis.dev"  -> "c--work-root-fanis-dev"   (Windows drive)
+//	"\\server\share\repo"     -> "--server-share-repo"      (Windows UNC)
+//	"/home/alice/src/my.app"   -> "-home-alice-src-my-app"   (POSIX)
+//	"/home/alice/.config"      -> "-home-alice--config"      (POSIX dot-folder)
+//
+// Since we can't distinguish path separators from literal hyphens or dots in
+// folder names, we try multiple interpretations and verify against the filesystem
 func decodePath(encoded string) string {
+	return decodePathFor(encoded, runtime.GOOS)
+}
+
+// decodePathFor decodes an encoded path as if running on goos. The filesystem
+// is only probed when goos is the current platform, since a Windows path can't
+// be verified on Linux and vice versa.
+func decodePathFor(encoded, goos string) string {
 	if encoded == "" {
 		return ""
 	}
+	probe := goos == runtime.GOOS
+
+	switch {
+	case goos == "windows" && strings.HasPrefix(encoded, "--"):
+		// UNC path: both leading backslashes became hyphens
+		return findValidPath(`\`, `\`, encoded[2:], probe)
+	case strings.HasPrefix(encoded, "-"):
+		// POSIX absolute path: the root slash became the leading hyphen
+		return findValidPath("", "/", encoded[1:], probe && goos != "windows")
+	}
 
 	// The encoding uses double dashes to separate the drive from the rest
-	// Single dashes could be path separators OR literal hyphens in folder names
 	// Example: "c--work-root-project" -> "c:\work\root\project"
-	// Example: "c--install-headlines-neutralizer" could be:
-	//   "c:\install\headlines\neutralizer" OR "c:\install\headlines-neutralizer"
-
-	parts := strings.Split(encoded, "--")
-	if len(parts) < 1 {
-		return ""
+	drive, pathPart, found := strings.Cut(encoded, "--")
+	if !found {
+		// Drive only case (e.g., "c")
+		return drive + ":"
 	}
+	return findValidPath(drive+":", `\`, pathPart, probe && goos == "windows")
+}
 
-	// Drive only case (e.g., "c")
-	if len(parts) == 1 {
-		return parts[0] + ":"
-	}
-
-	// First part is the drive letter
-	drive := parts[0] + ":"
-
-	// Get the path portion (everything after --)
-	pathPart := strings.Join(parts[1:], "--") // Rejoin in case there were multiple --
-
-	// Try to find a valid path by testing different interpretations
-	path := findValidPath(drive, pathPart)
-	if path != "" {
-		return path
+// findValidPath tries different interpretations of the encoded path below
+// base and returns the first one that exists on disk. If none does (or probe
+// is false), every hyphen is taken as a path separator.
+//
+// Claude's path encoding converts both path separators and dots (and any
+// other punctuation) to hyphens. For example, "c:\work\root\fanis.dev"
+// becomes "c--work-root-fanis-dev". This function walks the filesystem
+// recursively, trying each hyphen as a path separator, literal hyphen, dot,
+// underscore or space to find the actual path.
+func findValidPath(base, sep, pathPart string, probe bool) string {
+	segments := splitEncodedSegments(pathPart)
+	if len(segments) == 0 {
+		if base == "" {
+			return sep
+		}
+		return base
 	}
 
-	// Fallback: simple dash-to-separator conversion (original behavior)
-	result := drive
-	segments := strings.Split(pathPart, "-")
-	for _, seg := range segments {
-		if seg != "" {
-			result += string(filepath.Separator) + seg
+	if probe {
+		if path := resolveSegments(base, sep, segments); path != "" {
+			return path
 		}
 	}
-	return result
+
+	// Fallback: simple dash-to-separator conversion
+	return base + sep + strings.Join(segments, sep)
 }
 
-// findValidPath tries different interpretations of the encoded path
-// and returns the first one that exists on disk.
-//
-// Claude's path encoding converts both path separators (\) and dots (.)
-// to hyphens. For example, "c:\work\root\fanis.dev" becomes
-// "c--work-root-fanis-dev". This function walks the filesystem recursively,
-// trying each hyphen as a path separator, literal hyphen, or dot to find
-// the actual path.
-func findValidPath(drive, pathPart string) string {
-	segments := strings.Split(pathPart, "-")
-	if len(segments) == 0 {
-		return ""
+// splitEncodedSegments splits an encoded path on hyphens. An empty segment
+// means two punctuation characters were adjacent, which in practice is a
+// separator followed by a dot-folder, so it is folded into the next segment:
+// "alice--config" -> ["alice", ".config"].
+func splitEncodedSegments(pathPart string) []string {
+	var segments []string
+	dots := ""
+	for _, seg := range strings.Split(pathPart, "-") {
+		if seg == "" {
+			dots += "."
+			continue
+		}
+		segments = append(segments, dots+seg)
+		dots = ""
 	}
-
-	return resolveSegments(drive, segments)
+	return segments
 }
 
+// segmentJoiners are the characters a hyphen inside a single folder name may
+// stand for, in order of preference
+var segmentJoiners = []string{"-", ".", "_", " "}
+
 // resolveSegments recursively resolves path segments by trying different
-// joiners (path separator, hyphen, dot) at each level, validating against
+// joiners (path separator, hyphen, dot, ...) at each level, validating against
 // the filesystem at each step.
-func resolveSegments(basePath string, segments []string) string {
+func resolveSegments(basePath, sep string, segments []string) string {
 	if len(segments) == 0 {
 		// All segments consumed - check if this path exists
 		if _, err := os.Stat(basePath); err == nil {
@@ -306,24 +445,25 @@ func resolveSegments(basePath string, segments []string) string {
 		// Try different joiners between segments in this group:
 		// - hyphen (literal hyphen in folder name)
 		// - dot (e.g., "fanis.dev")
+		// - underscore and space, which are encoded the same way
 		// For single segments, no joiner needed.
 		var names []string
 		if count == 1 {
 			names = []string{group[0]}
 		} else {
-			hyphenName := strings.Join(group, "-")
-			dotName := strings.Join(group, ".")
-			names = []string{hyphenName, dotName}
+			for _, joiner := range segmentJoiners {
+				names = append(names, strings.Join(group, joiner))
+			}
 		}
 
 		for _, name := range names {
-			candidate := basePath + string(filepath.Separator) + name
+			candidate := basePath + sep + name
 			if _, err := os.Stat(candidate); err == nil {
 				if len(rest) == 0 {
 					return candidate
 				}
 				// This level exists, recurse for remaining segments
-				result := resolveSegments(candidate, rest)
+				result := resolveSegments(candidate, sep, rest)
 				if result != "" {
 					return result
 				}
@@ -334,6 +474,20 @@ func resolveSegments(basePath string, segments []string) string {
 	return ""
 }
 
+// encodePath converts a path to the directory name Claude Code stores its
+// data under: every character that isn't an ASCII letter or digit becomes a hyphen
+func encodePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
 // SortByLastUsed sorts projects by last used time (most recent first)
 func SortByLastUsed(projects []Project) {
 	sort.Slice(projects, func(i, j int) bool {
@@ -341,9 +495,9 @@ func SortByLastUsed(projects []Project) {
 	})
 }
 
-// SortByName sorts projects alphabetically by name
+// SortByName sorts projects alphabetically by the name they are shown with
 func SortByName(projects []Project) {
 	sort.Slice(projects, func(i, j int) bool {
-		return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+		return strings.ToLower(projects[i].DisplayName()) < strings.ToLower(projects[j].DisplayName())
 	})
 }
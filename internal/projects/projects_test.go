@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -14,46 +15,138 @@ import (
 func TestDecodePath(t *testing.T) {
 	tests := []struct {
 		name     string
+		goos     string
 		encoded  string
 		expected string
 	}{
 		{
 			name:     "simple path",
+			goos:     "windows",
 			encoded:  "c--work",
 			expected: "c:\\work",
 		},
 		{
 			name:     "nested path with single dashes",
+			goos:     "windows",
 			encoded:  "c--work-root-project",
 			expected: "c:\\work\\root\\project",
 		},
 		{
 			name:     "user directory",
+			goos:     "windows",
 			encoded:  "C--Users-micro",
 			expected: "C:\\Users\\micro",
 		},
+		{
+			name:     "windows dot-folder",
+			goos:     "windows",
+			encoded:  "C--Users-micro--config",
+			expected: "C:\\Users\\micro\\.config",
+		},
+		{
+			name:     "windows UNC path",
+			goos:     "windows",
+			encoded:  "--server-share-repo",
+			expected: "\\\\server\\share\\repo",
+		},
 		{
 			name:     "empty string",
+			goos:     "windows",
 			encoded:  "",
 			expected: "",
 		},
 		{
 			name:     "drive only",
+			goos:     "windows",
 			encoded:  "c",
 			expected: "c:",
 		},
+		{
+			name:     "windows encoding decoded on linux",
+			goos:     "linux",
+			encoded:  "c--work-root-project",
+			expected: "c:\\work\\root\\project",
+		},
+		{
+			name:     "posix home directory",
+			goos:     "linux",
+			encoded:  "-home-alice",
+			expected: "/home/alice",
+		},
+		{
+			name:     "posix nested path",
+			goos:     "linux",
+			encoded:  "-home-alice-src-my-app",
+			expected: "/home/alice/src/my/app",
+		},
+		{
+			name:     "posix dot-folder",
+			goos:     "darwin",
+			encoded:  "-Users-alice--config-nvim",
+			expected: "/Users/alice/.config/nvim",
+		},
+		{
+			name:     "posix root",
+			goos:     "linux",
+			encoded:  "-",
+			expected: "/",
+		},
+		{
+			name:     "posix encoding decoded on windows",
+			goos:     "windows",
+			encoded:  "-home-alice-src",
+			expected: "/home/alice/src",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := decodePath(tt.encoded)
+			result := decodePathFor(tt.encoded, tt.goos)
 			if result != tt.expected {
-				t.Errorf("decodePath(%q) = %q, want %q", tt.encoded, result, tt.expected)
+				t.Errorf("decodePathFor(%q, %q) = %q, want %q", tt.encoded, tt.goos, result, tt.expected)
 			}
 		})
 	}
 }
 
+func TestDecodePathResolvesFilesystem(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX encoding is only probed on POSIX systems")
+	}
+
+	base := t.TempDir()
+	tests := []string{
+		filepath.Join(base, "my.app"),
+		filepath.Join(base, "headlines-neutralizer"),
+		filepath.Join(base, ".config", "gh-cli"),
+		filepath.Join(base, "snake_case", "src"),
+		filepath.Join(base, "fanis.dev", "v2.1"),
+	}
+
+	for _, path := range tests {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+		encoded := encodePath(path)
+		if result := decodePath(encoded); result != path {
+			t.Errorf("decodePath(%q) = %q, want %q", encoded, result, path)
+		}
+	}
+}
+
+func TestEncodePath(t *testing.T) {
+	tests := map[string]string{
+		"c:\\work\\root\\fanis.dev": "c--work-root-fanis-dev",
+		"/home/alice/.config":       "-home-alice--config",
+		"/srv/my_app v2":            "-srv-my-app-v2",
+	}
+	for path, expected := range tests {
+		if result := encodePath(path); result != expected {
+			t.Errorf("encodePath(%q) = %q, want %q", path, result, expected)
+		}
+	}
+}
+
 func TestLoadProjectInfo(t *testing.T) {
 	// Create a temporary directory for test files
 	tmpDir, err := os.MkdirTemp("", "claude-test-*")
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Orphan is a Claude Code data folder whose project directory is gone
+type Orphan struct {
+	Project  Project
+	Dir      string // Full path of the data folder
+	Size     int64  // Bytes used by the folder
+	Sessions int    // Number of session transcripts in it
+}
+
+// Orphans returns the data folders of projects whose directory is missing.
+// Projects on a drive that isn't connected are left out, since their
+// directory may well still exist, as are projects with a path of another
+// platform, such as C:\work on Linux, which can't be checked from here.
+func Orphans(list []Project) []Orphan {
+	var orphans []Orphan
+	for _, p := range list {
+		if p.PathState != PathMissing || !filepath.IsAbs(p.Path) || !volumeAvailable(p.Path) {
+			continue
+		}
+		dirs, err := p.DataDirs()
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			size, sessions, err := DirStats(dir)
+			if err != nil {
+				continue
+			}
+			orphans = append(orphans, Orphan{Project: p, Dir: dir, Size: size, Sessions: sessions})
+		}
+	}
+	return orphans
+}
+
+// DirStats returns the total size of the files under a project data folder
+// and the number of session transcripts in it, not counting sub-agents
+func DirStats(dir string) (size int64, sessions int, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		name := d.Name()
+		if filepath.Dir(path) == dir && strings.HasSuffix(name, ".jsonl") && !strings.HasPrefix(name, "agent-") {
+			sessions++
+		}
+		return nil
+	})
+	return size, sessions, err
+}
+
+// volumeAvailable reports whether the drive or share a path is on can be
+// reached. Paths without a volume name (Unix paths) always can.
+func volumeAvailable(path string) bool {
+	volume := filepath.VolumeName(path)
+	if volume == "" {
+		return true
+	}
+	_, err := os.Stat(volume + string(filepath.Separator))
+	return err == nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// foreignPath returns an absolute path of the other platform
+func foreignPath() string {
+	if runtime.GOOS == "windows" {
+		return "/work/gone"
+	}
+	return `C:\work\gone`
+}
+
+func TestOrphans(t *testing.T) {
+	root := t.TempDir()
+	gone := filepath.Join(t.TempDir(), "gone")
+	goneDir := writeTestProject(t, root, gone, 2)
+	os.WriteFile(filepath.Join(goneDir, "agent-a1.jsonl"), []byte("{}\n"), 0644)
+	here := t.TempDir()
+	writeTestProject(t, root, here, 1)
+
+	list := []Project{
+		{Path: gone, Root: root, EncodedDir: encodePath(gone), PathState: PathMissing},
+		{Path: here, Root: root, EncodedDir: encodePath(here), PathState: PathFound},
+		// Unknown isn't missing: the check may just have been slow
+		{Path: "/elsewhere", Root: root, EncodedDir: encodePath(here), PathState: PathUnknown},
+		// A path of another platform can't be checked from here
+		{Path: foreignPath(), Root: root, EncodedDir: encodePath(here), PathState: PathMissing},
+	}
+	orphans := Orphans(list)
+	if len(orphans) != 1 {
+		t.Fatalf("Orphans() = %+v, want only %s", orphans, gone)
+	}
+	o := orphans[0]
+	if o.Dir != goneDir || o.Sessions != 2 || o.Size == 0 {
+		t.Errorf("orphan = %+v, want its data folder with 2 sessions and a size", o)
+	}
+}
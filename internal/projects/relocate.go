@@ -0,0 +1,369 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/git"
+)
+
+// sessionsIndexFile is the name of Claude Code's index in a project folder
+const sessionsIndexFile = "sessions-index.json"
+
+// Candidate is a directory a missing project may have moved to
+type Candidate struct {
+	Path       string
+	SameName   bool // The folder has the project's directory name
+	SameOrigin bool // The folder is a git checkout with the project's origin
+}
+
+// Reason says why the folder was suggested
+func (c Candidate) Reason() string {
+	switch {
+	case c.SameOrigin && c.SameName:
+		return "same name and git origin"
+	case c.SameOrigin:
+		return "same git origin"
+	}
+	return "same name"
+}
+
+// FindMoved returns the directories under the scan roots that the missing
+// project p may have moved to, best matches first: folders with the same
+// name and git checkouts of the same origin. The nearest existing parent of
+// the old path is searched as well, which finds a project renamed in place.
+func FindMoved(p Project, scan ScanOptions) []Candidate {
+	search := scan.roots()
+	if parent := existingParent(p.Path); parent != "" {
+		search = append(search, parent)
+	}
+
+	name := filepath.Base(p.Path)
+	origin := normalizeRemote(p.RemoteURL)
+	seen := make(map[string]bool)
+	var candidates []Candidate
+	for _, root := range search {
+		scan.walk(root, func(dir string) bool {
+			key := fsutil.PathKey(dir)
+			if seen[key] || key == fsutil.PathKey(p.Path) {
+				return true
+			}
+			seen[key] = true
+
+			c := Candidate{Path: dir, SameName: strings.EqualFold(filepath.Base(dir), name)}
+			// Only the top of a checkout counts, not every folder inside it
+			if origin != "" {
+				if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+					if info, err := git.Read(dir); err == nil && normalizeRemote(info.RemoteURL) == origin {
+						c.SameOrigin = true
+					}
+				}
+			}
+			if c.SameName || c.SameOrigin {
+				candidates = append(candidates, c)
+			}
+			return true
+		})
+	}
+
+	rank := func(c Candidate) int {
+		switch {
+		case c.SameOrigin && c.SameName:
+			return 0
+		case c.SameOrigin:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return rank(candidates[i]) < rank(candidates[j])
+	})
+	return candidates
+}
+
+// existingParent returns the nearest ancestor of path that exists, or "" if
+// there is none below the volume root
+func existingParent(path string) string {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+}
+
+// normalizeRemote reduces a git remote URL to host/path, so the HTTPS and
+// SSH forms of the same repository compare equal
+func normalizeRemote(url string) string {
+	u := strings.ToLower(strings.TrimSpace(url))
+	u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	}
+	if i := strings.Index(u, "@"); i >= 0 {
+		u = u[i+1:]
+	}
+	// scp-like syntax: host:owner/repo
+	return strings.Replace(u, ":", "/", 1)
+}
+
+// Relocate moves the Claude Code history of p into the data folder for
+// newPath, merging it with any sessions already recorded there, and points
+// the sessions index at newPath so Claude Code resumes the sessions in the
+// new location. The directory must already have been moved to newPath. A
+// sessions index that can't be merged or edited is left where it is and
+// reported to diag, which may be nil.
+func Relocate(p Project, newPath string, diag *Diagnostics) (Project, error) {
+	newPath, err := filepath.Abs(newPath)
+	if err != nil {
+		return p, err
+	}
+	info, err := os.Stat(newPath)
+	if err != nil {
+		return p, err
+	}
+	if !info.IsDir() {
+		return p, fmt.Errorf("%s is not a directory", newPath)
+	}
+	newPath = canonicalPath(newPath)
+
+	dirs, err := p.DataDirs()
+	if err != nil {
+		return p, err
+	}
+	encoded := encodePath(newPath)
+	target := filepath.Join(filepath.Dir(dirs[0]), encoded)
+	for _, dir := range dirs {
+		// Spellings that differ only in case are the same folder on Windows
+		if fsutil.SamePath(dir, target) {
+			continue
+		}
+		if err := moveData(dir, target, diag); err != nil {
+			return p, err
+		}
+	}
+	if err := rewriteSessionsIndex(target, newPath, diag); err != nil {
+		return p, err
+	}
+
+	moved := newProject(newPath, p.Root, encoded, p.LastUsed)
+	moved.PathState = PathFound
+	moved.RemoteURL = p.RemoteURL
+	return moved, nil
+}
+
+// moveData moves a project data folder to dst, or into dst if it exists.
+// Nothing is moved if any file would be overwritten, apart from the
+// sessions index, whose entries are merged. An index that can't be merged
+// stays in src, which is then kept.
+func moveData(src, dst string, diag *Diagnostics) error {
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		return os.Rename(src, dst)
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == sessionsIndexFile {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dst, entry.Name())); err == nil {
+			return fmt.Errorf("%s exists in both %s and %s", entry.Name(), src, dst)
+		}
+	}
+
+	kept := false
+	for _, entry := range entries {
+		from, to := filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())
+		if entry.Name() == sessionsIndexFile {
+			err := mergeSessionsIndex(from, to)
+			if errors.Is(err, ErrUnknownIndexVersion) {
+				// The transcripts hold the sessions, and rewriteSessionsIndex
+				// rebuilds dst's index if need be; what this one holds can't
+				// be read safely, so it is left for the user
+				diag.addProblem(from, 0, fmt.Errorf("not merged into %s: %w", dst, err))
+				kept = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	if kept {
+		return nil
+	}
+	return os.Remove(src)
+}
+
+// rawIndex is a sessions index decoded just enough to edit it. Fields this
+// package doesn't know about are written back unchanged, but an index of a
+// version without a decoder isn't edited at all.
+type rawIndex struct {
+	fields  map[string]json.RawMessage
+	entries []map[string]json.RawMessage
+}
+
+func readRawIndex(path string) (*rawIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	idx := &rawIndex{}
+	if err := json.Unmarshal(data, &idx.fields); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var version int
+	json.Unmarshal(idx.fields["version"], &version)
+	if _, ok := indexDecoders[version]; !ok {
+		return nil, fmt.Errorf("%s: %w %d", path, ErrUnknownIndexVersion, version)
+	}
+	if raw, ok := idx.fields["entries"]; ok {
+		if err := json.Unmarshal(raw, &idx.entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return idx, nil
+}
+
+// write replaces the index file at path atomically
+func (idx *rawIndex) write(path string) error {
+	if idx.entries == nil {
+		idx.entries = []map[string]json.RawMessage{}
+	}
+	entries, err := json.Marshal(idx.entries)
+	if err != nil {
+		return err
+	}
+	idx.fields["entries"] = entries
+	data, err := json.MarshalIndent(idx.fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data)
+}
+
+// setString sets a string field of an index or entry
+func setString(fields map[string]json.RawMessage, key, value string) {
+	data, _ := json.Marshal(value)
+	fields[key] = data
+}
+
+// getString reads a string field of an index or entry, "" if absent
+func getString(fields map[string]json.RawMessage, key string) string {
+	var s string
+	json.Unmarshal(fields[key], &s)
+	return s
+}
+
+// mergeSessionsIndex adds the entries of the index at src that the index at
+// dst lacks, then removes src. Either index being of an unknown version is an
+// error wrapping ErrUnknownIndexVersion, and leaves both as they were.
+func mergeSessionsIndex(src, dst string) error {
+	from, err := readRawIndex(src)
+	if err != nil {
+		return err
+	}
+	to, err := readRawIndex(dst)
+	if os.IsNotExist(err) {
+		return os.Rename(src, dst)
+	}
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(to.entries))
+	for _, e := range to.entries {
+		known[getString(e, "sessionId")] = true
+	}
+	for _, e := range from.entries {
+		if id := getString(e, "sessionId"); !known[id] {
+			known[id] = true
+			to.entries = append(to.entries, e)
+		}
+	}
+	if err := to.write(dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// rewriteSessionsIndex points the sessions index in dir at projectPath. A
+// folder without an index gets one built from its transcripts, since the
+// transcripts still name the old directory. An index of a version that can't
+// be edited safely is left as it is and reported to diag.
+func rewriteSessionsIndex(dir, projectPath string, diag *Diagnostics) error {
+	path := filepath.Join(dir, sessionsIndexFile)
+	idx, err := readRawIndex(path)
+	if os.IsNotExist(err) {
+		idx, err = indexFromTranscripts(dir)
+	}
+	if errors.Is(err, ErrUnknownIndexVersion) {
+		diag.addProblem(path, 0, fmt.Errorf("not pointed at %s: %w", projectPath, err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	setString(idx.fields, "originalPath", projectPath)
+	for _, e := range idx.entries {
+		setString(e, "projectPath", projectPath)
+		if full := getString(e, "fullPath"); full != "" {
+			setString(e, "fullPath", filepath.Join(dir, filepath.Base(full)))
+		}
+	}
+	return idx.write(path)
+}
+
+// indexFromTranscripts builds a sessions index for the transcripts in dir
+func indexFromTranscripts(dir string) (*rawIndex, error) {
+	sessions, err := loadSessionsFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	idx := &rawIndex{fields: make(map[string]json.RawMessage)}
+	idx.fields["version"] = json.RawMessage("1")
+	for _, s := range sessions {
+		created := s.Started
+		if created.IsZero() {
+			created = s.Ended
+		}
+		data, err := json.Marshal(SessionEntry{
+			SessionID:    s.ID,
+			FullPath:     s.FilePath,
+			FirstPrompt:  s.FirstPrompt,
+			Summary:      s.Summary,
+			MessageCount: s.MessageCount,
+			Created:      created.UTC().Format(time.RFC3339),
+			Modified:     s.Ended.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return nil, err
+		}
+		var entry map[string]json.RawMessage
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		idx.entries = append(idx.entries, entry)
+	}
+	return idx, nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindMoved(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"src/my-app", "src/other", "archive/2025/my-app", "src/.hidden/my-app", "renamed"} {
+		os.MkdirAll(filepath.Join(base, dir), 0755)
+	}
+	p := Project{Path: filepath.Join(base, "old", "my-app")}
+
+	got := FindMoved(p, ScanOptions{Roots: []string{filepath.Join(base, "src"), filepath.Join(base, "archive")}})
+	var paths []string
+	for _, c := range got {
+		if !c.SameName {
+			t.Errorf("candidate %s doesn't share the name", c.Path)
+		}
+		paths = append(paths, c.Path)
+	}
+	want := []string{filepath.Join(base, "src", "my-app"), filepath.Join(base, "archive", "2025", "my-app")}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("FindMoved() = %v, want %v", paths, want)
+	}
+
+	if got := FindMoved(p, ScanOptions{Roots: []string{filepath.Join(base, "archive")}, Depth: 1}); len(got) != 0 {
+		t.Errorf("FindMoved() with depth 1 = %+v, want nothing", got)
+	}
+}
+
+func TestNormalizeRemote(t *testing.T) {
+	want := "github.com/fanis/claude-code-switcher"
+	for _, url := range []string{
+		"https://github.com/fanis/claude-code-switcher.git",
+		"git@github.com:fanis/claude-code-switcher.git",
+		"ssh://git@github.com/Fanis/claude-code-switcher/",
+	} {
+		if got := normalizeRemote(url); got != want {
+			t.Errorf("normalizeRemote(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	root := t.TempDir()
+	oldPath := filepath.Join(t.TempDir(), "old-name")
+	oldDir := writeTestProject(t, root, oldPath, 2)
+	// Keep fields this package doesn't know about
+	var index map[string]any
+	data, _ := os.ReadFile(filepath.Join(oldDir, "sessions-index.json"))
+	json.Unmarshal(data, &index)
+	index["entries"].([]any)[0].(map[string]any)["futureField"] = "kept"
+	data, _ = json.Marshal(index)
+	os.WriteFile(filepath.Join(oldDir, "sessions-index.json"), data, 0644)
+
+	// Claude Code was already run once in the new location
+	newPath := t.TempDir()
+	newDir := writeTestProject(t, root, newPath, 0)
+	os.WriteFile(filepath.Join(newDir, "new-session.jsonl"), []byte(`{"type":"user","sessionId":"new-session"}`+"\n"), 0644)
+
+	p := Project{Path: oldPath, Root: root, EncodedDir: encodePath(oldPath), PathState: PathMissing, RemoteURL: "git@example.com:a/b.git"}
+	moved, err := Relocate(p, newPath, nil)
+	if err != nil {
+		t.Fatalf("Relocate() error = %v", err)
+	}
+	if moved.Path != newPath || moved.EncodedDir != encodePath(newPath) || moved.PathState != PathFound || moved.RemoteURL != p.RemoteURL {
+		t.Errorf("Relocate() = %+v", moved)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("old data folder still exists: %v", err)
+	}
+
+	sessions, err := LoadSessions(moved)
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("LoadSessions() after relocation = %d sessions, %v, want 3", len(sessions), err)
+	}
+
+	data, _ = os.ReadFile(filepath.Join(newDir, "sessions-index.json"))
+	var got SessionsIndex
+	json.Unmarshal(data, &got)
+	if got.OriginalPath != newPath || len(got.Entries) != 2 {
+		t.Fatalf("index = %+v, want originalPath %s and the 2 moved entries", got, newPath)
+	}
+	for _, e := range got.Entries {
+		if e.ProjectPath != newPath {
+			t.Errorf("entry %s projectPath = %s, want %s", e.SessionID, e.ProjectPath, newPath)
+		}
+	}
+	var raw struct{ Entries []map[string]any }
+	json.Unmarshal(data, &raw)
+	if raw.Entries[0]["futureField"] != "kept" {
+		t.Errorf("unknown entry field lost: %v", raw.Entries[0])
+	}
+
+	// The project now loads at its new path
+	list, err := LoadProjectsContext(t.Context(), LoadOptions{Roots: []string{root}})
+	if err != nil || len(list) != 1 || list[0].Path != newPath {
+		t.Errorf("projects after relocation = %+v, %v", list, err)
+	}
+}
+
+func TestRelocateWithoutIndex(t *testing.T) {
+	root := t.TempDir()
+	oldPath := filepath.Join(t.TempDir(), "gone")
+	oldDir := writeTestProject(t, root, oldPath, 1)
+	os.Remove(filepath.Join(oldDir, "sessions-index.json"))
+
+	newPath := t.TempDir()
+	p := Project{Path: oldPath, Root: root, EncodedDir: encodePath(oldPath)}
+	if _, err := Relocate(p, newPath, nil); err != nil {
+		t.Fatalf("Relocate() error = %v", err)
+	}
+
+	// The transcripts still name the old directory, so an index is written
+	list, err := LoadProjectsContext(t.Context(), LoadOptions{Roots: []string{root}})
+	if err != nil || len(list) != 1 || list[0].Path != newPath {
+		t.Errorf("projects after relocation = %+v, %v", list, err)
+	}
+}
+
+func TestRelocateMovedUnknownIndex(t *testing.T) {
+	root := t.TempDir()
+	oldPath := filepath.Join(t.TempDir(), "gone")
+	oldDir := writeTestProject(t, root, oldPath, 1)
+	future := `{"version":2,"sessions":{}}`
+	os.WriteFile(filepath.Join(oldDir, "sessions-index.json"), []byte(future), 0644)
+	// The new location has no data folder, so the old one is renamed
+	newPath := t.TempDir()
+
+	var diag Diagnostics
+	p := Project{Path: oldPath, Root: root, EncodedDir: encodePath(oldPath)}
+	moved, err := Relocate(p, newPath, &diag)
+	if err != nil {
+		t.Fatalf("Relocate() error = %v", err)
+	}
+	dirs, _ := moved.DataDirs()
+	if _, err := os.Stat(filepath.Join(dirs[0], "session-0.jsonl")); err != nil {
+		t.Errorf("session not moved: %v", err)
+	}
+	// The index that can't be read moves with the folder, unchanged
+	if data, _ := os.ReadFile(filepath.Join(dirs[0], "sessions-index.json")); string(data) != future {
+		t.Errorf("moved index = %q, want it left as it was", data)
+	}
+	if len(diag.Problems) != 1 || !strings.Contains(diag.Problems[0].Reason, "unknown sessions index version 2") {
+		t.Errorf("problems = %v, want the index reported", diag.Problems)
+	}
+}
+
+func TestRelocateKeepsUnknownIndex(t *testing.T) {
+	root := t.TempDir()
+	oldPath := filepath.Join(t.TempDir(), "gone")
+	oldDir := writeTestProject(t, root, oldPath, 1)
+	future := `{"version":2,"sessions":{}}`
+	os.WriteFile(filepath.Join(oldDir, "sessions-index.json"), []byte(future), 0644)
+	// The new location already has a data folder, so the indexes are merged
+	newPath := t.TempDir()
+	newDir := writeTestProject(t, root, newPath, 0)
+
+	var diag Diagnostics
+	p := Project{Path: oldPath, Root: root, EncodedDir: encodePath(oldPath)}
+	if _, err := Relocate(p, newPath, &diag); err != nil {
+		t.Fatalf("Relocate() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(newDir, "session-0.jsonl")); err != nil {
+		t.Errorf("session not moved: %v", err)
+	}
+	// The index that can't be read is neither merged nor deleted
+	if data, _ := os.ReadFile(filepath.Join(oldDir, "sessions-index.json")); string(data) != future {
+		t.Errorf("old index = %q, want it left as it was", data)
+	}
+	if len(diag.Problems) != 1 || !strings.Contains(diag.Problems[0].Reason, "unknown sessions index version 2") {
+		t.Errorf("problems = %v, want the index left behind", diag.Problems)
+	}
+}
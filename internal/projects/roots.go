@@ -0,0 +1,105 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// ConfigDirEnv is the environment variable Claude Code reads its data
+// location from. When unset, Claude Code uses ~/.claude.
+const ConfigDirEnv = "CLAUDE_CONFIG_DIR"
+
+// DefaultRoot returns Claude Code's default data root, ~/.claude
+func DefaultRoot() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".claude"), nil
+}
+
+// DefaultRoots returns the Claude data roots to load projects from: the
+// directories in CLAUDE_CONFIG_DIR (or ~/.claude if it isn't set), followed
+// by the given extra roots, e.g. from the switcher config. Duplicates and
+// empty entries are dropped.
+func DefaultRoots(extra ...string) []string {
+	var candidates []string
+	if env := os.Getenv(ConfigDirEnv); env != "" {
+		candidates = append(candidates, filepath.SplitList(env)...)
+	} else if root, err := DefaultRoot(); err == nil {
+		candidates = append(candidates, root)
+	}
+	candidates = append(candidates, extra...)
+
+	var roots []string
+	for _, root := range candidates {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		root = expandHome(filepath.Clean(root))
+		duplicate := false
+		for _, existing := range roots {
+			if fsutil.SamePath(existing, root) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// ConfigDir returns the CLAUDE_CONFIG_DIR value that makes Claude Code use
+// the project's root, or "" for the default root. The default root must be
+// selected by leaving the variable unset rather than naming ~/.claude, since
+// Claude Code then also looks for its settings file in a different place.
+func (p Project) ConfigDir() string {
+	if p.Root == "" {
+		return ""
+	}
+	if root, err := DefaultRoot(); err == nil && fsutil.SamePath(root, p.Root) {
+		return ""
+	}
+	return p.Root
+}
+
+// DataDirs returns the folders in the project's data root holding its sessions
+func (p Project) DataDirs() ([]string, error) {
+	root := p.Root
+	if root == "" {
+		var err error
+		if root, err = DefaultRoot(); err != nil {
+			return nil, err
+		}
+	}
+	encoded := p.EncodedDirs
+	if len(encoded) == 0 {
+		encoded = []string{p.EncodedDir}
+	}
+	dirs := make([]string, len(encoded))
+	for i, name := range encoded {
+		dirs[i] = filepath.Join(root, "projects", name)
+	}
+	return dirs, nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~"+string(filepath.Separator)) && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[1:])
+}
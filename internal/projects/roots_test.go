@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultRoots(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	work := filepath.Join(t.TempDir(), "work")
+	personal := filepath.Join(t.TempDir(), "personal")
+
+	t.Setenv(ConfigDirEnv, "")
+	got := DefaultRoots(work, "", "~/.claude", work+string(filepath.Separator))
+	want := []string{filepath.Join(home, ".claude"), work}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultRoots() without %s = %v, want %v", ConfigDirEnv, got, want)
+	}
+
+	t.Setenv(ConfigDirEnv, work+string(filepath.ListSeparator)+personal)
+	got = DefaultRoots(personal)
+	want = []string{work, personal}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultRoots() with %s = %v, want %v", ConfigDirEnv, got, want)
+	}
+}
+
+func TestProjectConfigDir(t *testing.T) {
+	defaultRoot, err := DefaultRoot()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	other := t.TempDir()
+
+	tests := []struct {
+		root string
+		want string
+	}{
+		{"", ""},
+		{defaultRoot, ""},
+		{other, other},
+	}
+	for _, tt := range tests {
+		if got := (Project{Root: tt.root}).ConfigDir(); got != tt.want {
+			t.Errorf("Project{Root: %q}.ConfigDir() = %q, want %q", tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestLoadProjectsContextMultipleRoots(t *testing.T) {
+	work := t.TempDir()
+	personal := t.TempDir()
+	writeTestProject(t, work, "/work/api", 1)
+	writeTestProject(t, personal, "/home/me/blog", 2)
+
+	projects, err := LoadProjectsContext(context.Background(), LoadOptions{Roots: []string{work, personal}})
+	if err != nil {
+		t.Fatalf("LoadProjectsContext() error = %v", err)
+	}
+	roots := make(map[string]string)
+	for _, p := range projects {
+		roots[p.Path] = p.Root
+	}
+	if roots["/work/api"] != work {
+		t.Errorf("/work/api Root = %q, want %q", roots["/work/api"], work)
+	}
+	if roots["/home/me/blog"] != personal {
+		t.Errorf("/home/me/blog Root = %q, want %q", roots["/home/me/blog"], personal)
+	}
+
+	for _, p := range projects {
+		if p.Path != "/home/me/blog" {
+			continue
+		}
+		sessions, err := LoadSessions(p)
+		if err != nil {
+			t.Fatalf("LoadSessions() error = %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Errorf("LoadSessions() returned %d sessions, want 2 from the project's own root", len(sessions))
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Session represents a single Claude Code conversation within a project
+type Session struct {
+	ID           string    // Session ID, as accepted by claude --resume
+	Summary      string    // Summary written by Claude Code, if any
+	FirstPrompt  string    // First prompt typed by the user
+	MessageCount int       // Number of user and assistant messages, excluding meta lines
+	Started      time.Time // Time of the first message
+	Ended        time.Time // Time of the last message
+	FilePath     string    // Full path to the session .jsonl file
+}
+
+// Title returns the best available one-line description of the session
+func (s Session) Title() string {
+	if s.Summary != "" {
+		return s.Summary
+	}
+	if s.FirstPrompt != "" {
+		return s.FirstPrompt
+	}
+	return s.ID
+}
+
+// maxPromptLength caps the length of FirstPrompt, in runes
+const maxPromptLength = 200
+
+// LoadSessions returns every session of the given project, most recent first.
+// Entries from sessions-index.json are used where available; .jsonl files the
+// index doesn't know about (or that changed after it was written) are parsed
+// directly. Sessions from all of the project's encoded directories are
+// combined.
+func LoadSessions(p Project) ([]Session, error) {
+	if p.EncodedDir == "" {
+		return nil, fmt.Errorf("project %s has no Claude Code data directory", p.Path)
+	}
+	dataDirs, err := p.DataDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	seen := make(map[string]int)
+	for i, dir := range dataDirs {
+		dirSessions, err := loadSessionsFromDir(dir)
+		if err != nil {
+			// Only the primary folder is required; the others are extra history
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		for _, s := range dirSessions {
+			// A session copied between folders is listed once, newest copy wins
+			if j, ok := seen[s.ID]; ok {
+				if s.Ended.After(sessions[j].Ended) {
+					sessions[j] = s
+				}
+				continue
+			}
+			seen[s.ID] = len(sessions)
+			sessions = append(sessions, s)
+		}
+	}
+
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+// loadSessionsFromDir merges sessions-index.json with the .jsonl files in projectDir
+func loadSessionsFromDir(projectDir string) ([]Session, error) {
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := loadIndexedSessions(filepath.Join(projectDir, "sessions-index.json"))
+
+	var sessions []Session
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		// Sub-agent transcripts can't be resumed on their own
+		if strings.HasPrefix(name, "agent-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		id := strings.TrimSuffix(name, ".jsonl")
+		filePath := filepath.Join(projectDir, name)
+
+		// Trust the index unless the file was written to after the index entry
+		if s, ok := indexed[id]; ok && !info.ModTime().After(s.Ended.Add(time.Second)) {
+			s.FilePath = filePath
+			sessions = append(sessions, s)
+			continue
+		}
+
+		// A read error part-way through still leaves usable metadata
+		s, _ := parseSessionFile(filePath)
+		if s.MessageCount == 0 {
+			continue
+		}
+		// The file name is what claude --resume looks up
+		s.ID = id
+		if s.Ended.IsZero() {
+			s.Ended = info.ModTime()
+		}
+		if s.Summary == "" {
+			s.Summary = indexed[id].Summary
+		}
+		sessions = append(sessions, s)
+	}
+
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+// sortSessions orders sessions most recent first
+func sortSessions(sessions []Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Ended.After(sessions[j].Ended)
+	})
+}
+
+// loadIndexedSessions reads sessions-index.json into sessions keyed by ID.
+// A missing or unreadable index, or one of an unknown version, yields an
+// empty map, so every transcript is parsed instead.
+func loadIndexedSessions(filePath string) map[string]Session {
+	sessions := make(map[string]Session)
+
+	index, err := ReadSessionsIndex(filePath)
+	if err != nil {
+		return sessions
+	}
+
+	for _, entry := range index.Entries {
+		if entry.SessionID == "" || entry.IsSidechain {
+			continue
+		}
+		started, _ := time.Parse(time.RFC3339, entry.Created)
+		ended, _ := time.Parse(time.RFC3339, entry.Modified)
+		sessions[entry.SessionID] = Session{
+			ID:           entry.SessionID,
+			Summary:      entry.Summary,
+			FirstPrompt:  oneLine(entry.FirstPrompt),
+			MessageCount: entry.MessageCount,
+			Started:      started,
+			Ended:        ended,
+			FilePath:     entry.FullPath,
+		}
+	}
+	return sessions
+}
+
+// transcriptLine is the subset of a session .jsonl line needed for listing sessions
+type transcriptLine struct {
+	Type      string             `json:"type"`
+	SessionID string             `json:"sessionId"`
+	Timestamp string             `json:"timestamp"`
+	IsMeta    bool               `json:"isMeta"`
+	Summary   string             `json:"summary"`
+	Message   *transcriptMessage `json:"message"`
+}
+
+// transcriptMessage is the message payload of a user or assistant line
+type transcriptMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// contentBlock is a single element of an array-valued message content
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// parseSessionFile reads a session .jsonl file and collects its metadata
+func parseSessionFile(filePath string) (Session, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return Session{}, err
+	}
+	defer f.Close()
+
+	s := Session{FilePath: filePath}
+
+	// A line too long to hold is skipped, not the rest of the session
+	lr := NewLineReader(f, maxSessionLine, 0)
+	for lr.Scan() {
+		var line transcriptLine
+		if err := json.Unmarshal(lr.Bytes(), &line); err != nil {
+			continue
+		}
+
+		switch line.Type {
+		case "summary":
+			if line.Summary != "" {
+				s.Summary = line.Summary
+			}
+			continue
+		case "user", "assistant":
+		default:
+			continue
+		}
+
+		if s.ID == "" {
+			s.ID = line.SessionID
+		}
+		if !line.IsMeta {
+			s.MessageCount++
+		}
+
+		if t, err := time.Parse(time.RFC3339, line.Timestamp); err == nil {
+			if s.Started.IsZero() || t.Before(s.Started) {
+				s.Started = t
+			}
+			if t.After(s.Ended) {
+				s.Ended = t
+			}
+		}
+
+		if s.FirstPrompt == "" && line.Type == "user" && !line.IsMeta && line.Message != nil {
+			s.FirstPrompt = oneLine(MessageText(line.Message.Content))
+		}
+	}
+
+	return s, lr.Err()
+}
+
+// maxSessionLine is the longest transcript line parseSessionFile decodes
+const maxSessionLine = 64 << 20
+
+// MessageText extracts the plain text of a message content, which is either
+// a string or an array of blocks. Tool results and other non-text blocks are ignored.
+func MessageText(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// oneLine collapses whitespace and truncates text for single-line display
+func oneLine(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxPromptLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxPromptLength]) + "..."
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSessionsFromDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Indexed session with an up-to-date entry
+	indexedFile := filepath.Join(tmpDir, "aaaa-1111.jsonl")
+	if err := os.WriteFile(indexedFile, []byte(`{"type":"user"}`+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+	indexedTime := time.Date(2026, 1, 26, 10, 0, 0, 0, time.UTC)
+	os.Chtimes(indexedFile, indexedTime, indexedTime)
+
+	index := SessionsIndex{
+		Version: 1,
+		Entries: []SessionEntry{
+			{
+				SessionID:    "aaaa-1111",
+				Summary:      "Indexed summary",
+				FirstPrompt:  "fix the build",
+				MessageCount: 12,
+				Created:      "2026-01-26T09:00:00Z",
+				Modified:     "2026-01-26T10:00:00Z",
+			},
+		},
+	}
+	data, _ := json.Marshal(index)
+	if err := os.WriteFile(filepath.Join(tmpDir, "sessions-index.json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write sessions index: %v", err)
+	}
+
+	// Session the index doesn't know about
+	lines := []string{
+		`{"type":"user","sessionId":"bbbb-2222","timestamp":"2026-01-27T08:00:00Z","isMeta":true,"message":{"role":"user","content":"Caveat"}}`,
+		`{"type":"user","sessionId":"bbbb-2222","timestamp":"2026-01-27T08:00:01Z","message":{"role":"user","content":[{"type":"text","text":"add a\n  session browser"}]}}`,
+		`{"type":"assistant","sessionId":"bbbb-2222","timestamp":"2026-01-27T08:05:00Z","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}`,
+		`not json`,
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "bbbb-2222.jsonl"), []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+
+	// Sub-agent transcripts and empty files are skipped
+	os.WriteFile(filepath.Join(tmpDir, "agent-1234.jsonl"), []byte(lines[1]), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "cccc-3333.jsonl"), []byte(`{"type":"summary","summary":"orphan"}`), 0644)
+
+	sessions, err := loadSessionsFromDir(tmpDir)
+	if err != nil {
+		t.Fatalf("loadSessionsFromDir() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("loadSessionsFromDir() returned %d sessions, want 2", len(sessions))
+	}
+
+	unindexed := sessions[0]
+	if unindexed.ID != "bbbb-2222" {
+		t.Errorf("First session ID = %q, want %q", unindexed.ID, "bbbb-2222")
+	}
+	if unindexed.FirstPrompt != "add a session browser" {
+		t.Errorf("FirstPrompt = %q, want %q", unindexed.FirstPrompt, "add a session browser")
+	}
+	if unindexed.MessageCount != 2 {
+		t.Errorf("MessageCount = %d, want 2", unindexed.MessageCount)
+	}
+	if want := time.Date(2026, 1, 27, 8, 5, 0, 0, time.UTC); !unindexed.Ended.Equal(want) {
+		t.Errorf("Ended = %v, want %v", unindexed.Ended, want)
+	}
+
+	indexed := sessions[1]
+	if indexed.ID != "aaaa-1111" || indexed.Summary != "Indexed summary" || indexed.MessageCount != 12 {
+		t.Errorf("Indexed session = %+v, want values from sessions-index.json", indexed)
+	}
+	if indexed.FilePath != indexedFile {
+		t.Errorf("FilePath = %q, want %q", indexed.FilePath, indexedFile)
+	}
+}
+
+func TestReadTranscript(t *testing.T) {
+	turns, err := ReadTranscript(filepath.Join("testdata", "transcript.jsonl"))
+	if err != nil {
+		t.Fatalf("ReadTranscript() error = %v", err)
+	}
+
+	var got []string
+	for _, turn := range turns {
+		parts := []string{turn.Role}
+		for _, b := range turn.Blocks {
+			switch b.Type {
+			case BlockToolUse:
+				parts = append(parts, b.Type+":"+b.Name+":"+b.ID)
+			case BlockToolResult:
+				parts = append(parts, fmt.Sprintf("%s:%s:%v:%s", b.Type, b.ID, b.IsError, b.Text))
+			default:
+				parts = append(parts, b.Type+":"+b.Text)
+			}
+		}
+		got = append(got, strings.Join(parts, " | "))
+	}
+	want := []string{
+		"user | text:The checkout test fails on CI but not locally, can you look?",
+		"assistant | thinking:Probably time-dependent. | text:Let me run it. | tool_use:Bash:toolu_1",
+		"user | tool_result:toolu_1:true:--- FAIL: TestCheckout (0.01s)\n    expired coupon",
+		"assistant | text:The coupon expires at a fixed date. Freezing the clock fixes it.",
+		"user | text:Thanks!",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("turns =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	first := turns[1]
+	if first.Model != "claude-sonnet-4-5-20250929" || !first.Time.Equal(time.Date(2026, 1, 21, 9, 12, 9, 0, time.UTC)) {
+		t.Errorf("assistant turn model %q at %v", first.Model, first.Time)
+	}
+	if input := string(first.Blocks[2].Input); !strings.Contains(input, `"command":"go test ./checkout/..."`) {
+		t.Errorf("tool input = %s", input)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Turn is one message of a session: a prompt, a response of the model, or
+// the results of the tools a response called, which Claude Code records as
+// a user message
+type Turn struct {
+	Role   string // "user" or "assistant"
+	Time   time.Time
+	Model  string // The model that wrote an assistant turn
+	Blocks []Block
+}
+
+// The kinds of Block
+const (
+	BlockText       = "text"
+	BlockThinking   = "thinking"
+	BlockToolUse    = "tool_use"
+	BlockToolResult = "tool_result"
+)
+
+// Block is one part of a turn
+type Block struct {
+	Type string
+	// Text is the text of a text or thinking block, or the output of a tool
+	Text string
+	// Name and Input are the tool a tool_use block calls and its arguments
+	Name  string
+	Input json.RawMessage
+	// ID is the ID of a tool_use block, or of the call a tool_result answers
+	ID      string
+	IsError bool // The tool reported an error
+}
+
+// turnLine is the part of a transcript line ReadTranscript needs
+type turnLine struct {
+	Type        string `json:"type"`
+	Timestamp   string `json:"timestamp"`
+	IsMeta      bool   `json:"isMeta"`
+	IsSidechain bool   `json:"isSidechain"`
+	Message     *struct {
+		ID      string          `json:"id"`
+		Role    string          `json:"role"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// rawBlock is a content block as Claude Code writes it
+type rawBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	Thinking  string          `json:"thinking"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// ReadTranscript reads the messages of a session transcript in order. Meta
+// messages and those of sub-agents are left out, as are block kinds other
+// than those of Block. Claude Code writes each content block of a response
+// on a line of its own; they are joined into one turn again. Lines that
+// can't be parsed are skipped, and a read error still returns the turns
+// read before it.
+func ReadTranscript(path string) ([]Turn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var turns []Turn
+	lastID := ""
+	lr := NewLineReader(f, maxSessionLine, 0)
+	for lr.Scan() {
+		var line turnLine
+		if json.Unmarshal(lr.Bytes(), &line) != nil || line.Message == nil || line.IsMeta || line.IsSidechain {
+			continue
+		}
+		if line.Type != "user" && line.Type != "assistant" {
+			continue
+		}
+		blocks := parseBlocks(line.Message.Content)
+		if len(blocks) == 0 {
+			continue
+		}
+
+		// Another block of the response the previous line started
+		if line.Type == "assistant" && line.Message.ID != "" && line.Message.ID == lastID {
+			last := &turns[len(turns)-1]
+			last.Blocks = append(last.Blocks, blocks...)
+			continue
+		}
+		lastID = ""
+		if line.Type == "assistant" {
+			lastID = line.Message.ID
+		}
+		t, _ := time.Parse(time.RFC3339, line.Timestamp)
+		turns = append(turns, Turn{Role: line.Type, Time: t, Model: line.Message.Model, Blocks: blocks})
+	}
+	return turns, lr.Err()
+}
+
+// parseBlocks reads a message content, which is a string or an array of
+// blocks
+func parseBlocks(content json.RawMessage) []Block {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		if text == "" {
+			return nil
+		}
+		return []Block{{Type: BlockText, Text: text}}
+	}
+
+	var raw []rawBlock
+	if json.Unmarshal(content, &raw) != nil {
+		return nil
+	}
+	var blocks []Block
+	for _, r := range raw {
+		switch r.Type {
+		case BlockText:
+			if r.Text != "" {
+				blocks = append(blocks, Block{Type: BlockText, Text: r.Text})
+			}
+		case BlockThinking:
+			if r.Thinking != "" {
+				blocks = append(blocks, Block{Type: BlockThinking, Text: r.Thinking})
+			}
+		case BlockToolUse:
+			blocks = append(blocks, Block{Type: BlockToolUse, Name: r.Name, Input: r.Input, ID: r.ID})
+		case BlockToolResult:
+			blocks = append(blocks, Block{Type: BlockToolResult, Text: MessageText(r.Content), ID: r.ToolUseID, IsError: r.IsError})
+		}
+	}
+	return blocks
+}
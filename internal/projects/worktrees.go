@@ -0,0 +1,48 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import "github.com/fanis/claude-code-switcher/internal/fsutil"
+
+// IsWorktree reports whether the project is a linked git worktree
+func (p Project) IsWorktree() bool {
+	return p.WorktreeOf != ""
+}
+
+// GroupWorktrees reorders projects so that linked worktrees directly follow
+// the main checkout they were created from. Each group takes the position of
+// its first member in list, so the current sort order is kept between
+// groups; inside a group the main checkout comes first and the worktrees
+// keep their relative order. Worktrees whose main checkout isn't a project
+// are still kept together.
+func GroupWorktrees(list []Project) []Project {
+	// Group key: the main checkout for worktrees, the project path otherwise
+	keyOf := func(p Project) string {
+		if p.IsWorktree() {
+			return fsutil.PathKey(p.WorktreeOf)
+		}
+		return fsutil.PathKey(p.Path)
+	}
+
+	var order []string
+	groups := make(map[string][]Project)
+	for _, p := range list {
+		key := keyOf(p)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		if p.IsWorktree() {
+			groups[key] = append(groups[key], p)
+			continue
+		}
+		// The main checkout leads its group
+		groups[key] = append([]Project{p}, groups[key]...)
+	}
+
+	grouped := make([]Project, 0, len(list))
+	for _, key := range order {
+		grouped = append(grouped, groups[key]...)
+	}
+	return grouped
+}
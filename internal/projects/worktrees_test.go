@@ -0,0 +1,29 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package projects
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupWorktrees(t *testing.T) {
+	list := []Project{
+		{Name: "app-fix", Path: "/src/app-fix", WorktreeOf: "/src/app"},
+		{Name: "other", Path: "/src/other"},
+		{Name: "lib-wip", Path: "/src/lib-wip", WorktreeOf: "/src/lib"},
+		{Name: "app", Path: "/src/app"},
+		{Name: "app-feature", Path: "/src/app-feature", WorktreeOf: "/src/app"},
+		{Name: "lib-next", Path: "/src/lib-next", WorktreeOf: "/src/lib"},
+	}
+
+	var got []string
+	for _, p := range GroupWorktrees(list) {
+		got = append(got, p.Name)
+	}
+	want := []string{"app", "app-fix", "app-feature", "other", "lib-wip", "lib-next"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupWorktrees() = %v, want %v", got, want)
+	}
+}
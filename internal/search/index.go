@@ -0,0 +1,348 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package search keeps a full-text index of the messages in Claude Code
+// session transcripts, so a conversation can be found by what was said in it.
+package search
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// indexVersion is bumped whenever the index format or tokenizer changes.
+// Index files with any other version are discarded and rebuilt.
+const indexVersion = 2
+
+// IndexFileName is the name of the index in the switcher's config directory
+const IndexFileName = "search-index.gob"
+
+// Term length limits, in runes. Shorter terms match too much to be useful
+// and longer ones are mostly hashes and encoded data.
+const (
+	minTermLength = 2
+	maxTermLength = 40
+)
+
+// Index is an inverted index from terms to the transcript messages that
+// contain them. Messages are identified by file and line offset, and their
+// text is read back from the transcript when a snippet is needed. It is
+// stored with gob rather than JSON because it grows large.
+type Index struct {
+	Version int
+	NextID  int32
+	Files   map[string]indexedFile // By transcript path
+	Terms   map[string][]posting
+
+	path  string
+	dirty bool
+}
+
+// indexedFile is a transcript as far as it has been indexed
+type indexedFile struct {
+	ID      int32
+	Size    int64
+	ModTime time.Time
+	Offset  int64 // Where indexing stopped; only complete lines are indexed
+	Session string
+}
+
+// posting is one message containing a term
+type posting struct {
+	File   int32
+	Offset int64 // Start of the transcript line holding the message
+}
+
+// Load reads the index file at path. A missing, unreadable or outdated file
+// yields an empty index; an empty path yields one that is never saved.
+func Load(path string) *Index {
+	ix := &Index{
+		Version: indexVersion,
+		Files:   make(map[string]indexedFile),
+		Terms:   make(map[string][]posting),
+		path:    path,
+	}
+	if path == "" {
+		return ix
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ix
+	}
+	defer f.Close()
+
+	var stored Index
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&stored); err != nil || stored.Version != indexVersion {
+		ix.dirty = true
+		return ix
+	}
+	// gob leaves out empty maps
+	if stored.Files == nil {
+		stored.Files = make(map[string]indexedFile)
+	}
+	if stored.Terms == nil {
+		stored.Terms = make(map[string][]posting)
+	}
+	stored.path = path
+	return &stored
+}
+
+// Save writes the index if it changed since it was loaded
+func (ix *Index) Save() error {
+	if ix.path == "" || !ix.dirty {
+		return nil
+	}
+	err := fsutil.WriteFileFunc(ix.path, func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(ix)
+	})
+	if err != nil {
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
+
+// scanned is what indexing one transcript, or the part added to it, found
+type scanned struct {
+	path    string
+	size    int64
+	modTime time.Time
+	prev    *indexedFile // Set when continuing an appended transcript
+	offset  int64
+	session string
+	terms   map[string][]int64 // Line offsets by term
+}
+
+// Update brings the index up to date with the transcripts of the given
+// projects, which should be all of them: files of other projects are
+// dropped. Transcripts that only grew are indexed from where they were left
+// off; sub-agent transcripts are not indexed.
+func (ix *Index) Update(list []projects.Project) {
+	var jobs []*scanned
+	seen := make(map[string]bool)
+	dead := make(map[int32]bool)
+	for _, path := range transcriptFiles(list) {
+		seen[path] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		prev, ok := ix.Files[path]
+		if ok && prev.Size == info.Size() && prev.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		job := &scanned{path: path, size: info.Size(), modTime: info.ModTime()}
+		// Transcripts are append-only: if the file grew, continue after what was read
+		if ok && info.Size() >= prev.Offset && !info.ModTime().Before(prev.ModTime) {
+			job.prev = &prev
+		} else if ok {
+			dead[prev.ID] = true
+			delete(ix.Files, path)
+		}
+		jobs = append(jobs, job)
+	}
+	for path, f := range ix.Files {
+		if !seen[path] {
+			dead[f.ID] = true
+			delete(ix.Files, path)
+		}
+	}
+
+	if len(dead) > 0 {
+		ix.dropFiles(dead)
+		ix.dirty = true
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	// Read a few files at a time, merging into the index on this goroutine
+	next := make(chan *scanned)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range next {
+				scanFile(job)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		next <- job
+	}
+	close(next)
+	wg.Wait()
+
+	for _, job := range jobs {
+		if job.terms == nil {
+			continue
+		}
+		entry := indexedFile{Size: job.size, ModTime: job.modTime, Offset: job.offset, Session: job.session}
+		if job.prev != nil {
+			entry.ID = job.prev.ID
+		} else {
+			entry.ID = ix.NextID
+			ix.NextID++
+		}
+		ix.Files[job.path] = entry
+		for term, offsets := range job.terms {
+			for _, offset := range offsets {
+				ix.Terms[term] = append(ix.Terms[term], posting{File: entry.ID, Offset: offset})
+			}
+		}
+		ix.dirty = true
+	}
+}
+
+// dropFiles removes the postings of the given files
+func (ix *Index) dropFiles(dead map[int32]bool) {
+	for term, list := range ix.Terms {
+		kept := list[:0]
+		for _, p := range list {
+			if !dead[p.File] {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			delete(ix.Terms, term)
+		} else {
+			ix.Terms[term] = kept
+		}
+	}
+}
+
+// transcriptFiles lists the session transcripts of the given projects
+func transcriptFiles(list []projects.Project) []string {
+	var files []string
+	for _, p := range list {
+		dirs, err := p.DataDirs()
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") || strings.HasPrefix(name, "agent-") {
+					continue
+				}
+				files = append(files, filepath.Join(dir, name))
+			}
+		}
+	}
+	return files
+}
+
+// messageLine is the part of a transcript line that holds message text
+type messageLine struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	IsMeta    bool   `json:"isMeta"`
+	Message   *struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// parseMessage returns the time and text of a user or assistant
+// line. ok is false for other lines and for messages without text.
+func parseMessage(line []byte) (ml messageLine, text string, ok bool) {
+	if json.Unmarshal(line, &ml) != nil || ml.Message == nil || ml.IsMeta {
+		return ml, "", false
+	}
+	if ml.Type != "user" && ml.Type != "assistant" {
+		return ml, "", false
+	}
+	text = projects.MessageText(ml.Message.Content)
+	return ml, text, text != ""
+}
+
+// scanFile indexes a transcript, or what was appended to it since job.prev.
+// Only complete lines are consumed, so a transcript that is being written to
+// is picked up where it left off. On failure job.terms stays nil.
+func scanFile(job *scanned) {
+	f, err := os.Open(job.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// The file name is the session ID that resumes it. A resumed transcript
+	// starts with the lines of the session it continues, under that ID.
+	job.session = strings.TrimSuffix(filepath.Base(job.path), ".jsonl")
+	if job.prev != nil {
+		job.offset = job.prev.Offset
+		if _, err := f.Seek(job.offset, io.SeekStart); err != nil {
+			return
+		}
+	}
+
+	terms := make(map[string][]int64)
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// A partial last line is read again next time
+			if err == io.EOF {
+				break
+			}
+			return
+		}
+		offset := job.offset
+		job.offset += int64(len(line))
+
+		_, text, ok := parseMessage(line)
+		if !ok {
+			continue
+		}
+		inLine := make(map[string]bool)
+		tokenize(text, func(term string) {
+			if !inLine[term] {
+				inLine[term] = true
+				terms[term] = append(terms[term], offset)
+			}
+		})
+	}
+	job.terms = terms
+}
+
+// tokenize calls fn with every term of text: runs of letters and digits,
+// lowercased, of a useful length
+func tokenize(text string, fn func(term string)) {
+	start := -1
+	emit := func(end int) {
+		word := text[start:end]
+		if n := utf8.RuneCountInString(word); n >= minTermLength && n <= maxTermLength {
+			fn(strings.ToLower(word))
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			emit(i)
+		}
+	}
+	if start >= 0 {
+		emit(len(text))
+	}
+}
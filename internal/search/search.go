@@ -0,0 +1,210 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package search
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// Hit is a session with messages matching a query
+type Hit struct {
+	Project   projects.Project
+	SessionID string
+	FilePath  string
+	Time      time.Time // Time of the message the snippet is from
+	Matches   int       // Number of matching messages in the session
+	Snippet   string    // Excerpt of the latest matching message
+}
+
+// Snippet length around the first matching term, in runes
+const (
+	snippetBefore = 40
+	snippetLength = 160
+)
+
+// Search returns the sessions of the given projects with a message that
+// contains every term of the query, most recently active first, at most
+// limit of them (0 for all). The last term also matches longer words, so
+// results can be shown while a word is still being typed.
+func (ix *Index) Search(list []projects.Project, query string, limit int) []Hit {
+	var terms []string
+	tokenize(query, func(term string) { terms = append(terms, term) })
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches map[posting]bool
+	for i, term := range terms {
+		found := ix.lookup(term, i == len(terms)-1)
+		if matches == nil {
+			matches = found
+			continue
+		}
+		for p := range matches {
+			if !found[p] {
+				delete(matches, p)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// Group matching messages by transcript, keeping the latest of each
+	type fileMatch struct {
+		path    string
+		file    indexedFile
+		latest  int64
+		matches int
+	}
+	paths := make(map[int32]string, len(ix.Files))
+	for path, f := range ix.Files {
+		paths[f.ID] = path
+	}
+	byFile := make(map[int32]*fileMatch)
+	for p := range matches {
+		fm := byFile[p.File]
+		if fm == nil {
+			path, ok := paths[p.File]
+			if !ok {
+				continue
+			}
+			fm = &fileMatch{path: path, file: ix.Files[path], latest: -1}
+			byFile[p.File] = fm
+		}
+		fm.matches++
+		fm.latest = max(fm.latest, p.Offset)
+	}
+
+	// Transcripts belong to the project whose data directory holds them
+	owners := make(map[string]int)
+	for i, p := range list {
+		dirs, err := p.DataDirs()
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			owners[dir] = i
+		}
+	}
+
+	var found []*fileMatch
+	for _, fm := range byFile {
+		if _, ok := owners[filepath.Dir(fm.path)]; ok {
+			found = append(found, fm)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].file.ModTime.Equal(found[j].file.ModTime) {
+			return found[i].file.ModTime.After(found[j].file.ModTime)
+		}
+		return found[i].path < found[j].path
+	})
+
+	// A session copied between data directories is listed once
+	var hits []Hit
+	seen := make(map[string]bool)
+	for _, fm := range found {
+		if limit > 0 && len(hits) >= limit {
+			break
+		}
+		project := list[owners[filepath.Dir(fm.path)]]
+		key := strings.ToLower(project.Path) + "\x00" + fm.file.Session
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		hit := Hit{
+			Project:   project,
+			SessionID: fm.file.Session,
+			FilePath:  fm.path,
+			Time:      fm.file.ModTime,
+			Matches:   fm.matches,
+		}
+		if ml, text, ok := readMessage(fm.path, fm.latest); ok {
+			hit.Snippet = snippet(text, terms)
+			if t, err := time.Parse(time.RFC3339, ml.Timestamp); err == nil {
+				hit.Time = t
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+// lookup returns the messages containing term, or with prefix also any
+// term starting with it
+func (ix *Index) lookup(term string, prefix bool) map[posting]bool {
+	found := make(map[posting]bool)
+	for _, p := range ix.Terms[term] {
+		found[p] = true
+	}
+	if prefix {
+		for t, list := range ix.Terms {
+			if len(t) > len(term) && strings.HasPrefix(t, term) {
+				for _, p := range list {
+					found[p] = true
+				}
+			}
+		}
+	}
+	return found
+}
+
+// readMessage reads the message on the transcript line starting at offset
+func readMessage(path string, offset int64) (messageLine, string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return messageLine{}, "", false
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return messageLine{}, "", false
+	}
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return messageLine{}, "", false
+	}
+	return parseMessage(line)
+}
+
+// snippet returns a one-line excerpt of text around the first occurrence of
+// any of the terms
+func snippet(text string, terms []string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	// Lowercasing maps rune to rune, so rune positions carry over
+	lower := strings.ToLower(text)
+	first := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+
+	runes := []rune(text)
+	start := 0
+	if first > 0 {
+		start = max(0, utf8.RuneCountInString(lower[:first])-snippetBefore)
+	}
+	end := min(len(runes), start+snippetLength)
+
+	excerpt := string(runes[start:end])
+	if start > 0 {
+		excerpt = "..." + excerpt
+	}
+	if end < len(runes) {
+		excerpt += "..."
+	}
+	return excerpt
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// messageJSON returns a transcript line for a message with plain text content
+func messageJSON(role, session, timestamp, text string) string {
+	return fmt.Sprintf(`{"type":%q,"sessionId":%q,"timestamp":%q,"message":{"role":%q,"content":[{"type":"text","text":%q}]}}`+"\n",
+		role, session, timestamp, role, text)
+}
+
+// writeProject creates <root>/projects/<encoded> with the given files
+func writeProject(t *testing.T, root, projectPath string, files map[string]string) projects.Project {
+	t.Helper()
+	encoded := strings.ReplaceAll(projectPath, "/", "-")
+	dir := filepath.Join(root, "projects", encoded)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return projects.Project{Name: filepath.Base(projectPath), Path: projectPath, Root: root, EncodedDir: encoded, EncodedDirs: []string{encoded}}
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	app := writeProject(t, root, "/work/app", map[string]string{
+		"s1.jsonl": messageJSON("user", "s1", "2026-10-01T09:00:00Z", "Why is the migration failing?") +
+			messageJSON("assistant", "s1", "2026-10-01T09:01:00Z", "The migration drops the index before the flaky test runs."),
+		"s2.jsonl": messageJSON("user", "s2", "2026-10-02T09:00:00Z", "Add a flaky retry to the upload"),
+		// A resumed session repeats the lines of the one it continues
+		"s4.jsonl": messageJSON("user", "s2", "2026-10-02T09:00:00Z", "Add a retry to the upload") +
+			messageJSON("user", "s4", "2026-10-04T09:00:00Z", "Now make the retry backoff exponential"),
+		// Sub-agent transcripts are not searched
+		"agent-a1.jsonl": messageJSON("assistant", "s2", "2026-10-02T09:00:00Z", "migration flaky index"),
+	})
+	lib := writeProject(t, root, "/work/lib", map[string]string{
+		"s3.jsonl": `{"type":"user","sessionId":"s3","timestamp":"2026-10-03T09:00:00Z","message":{"role":"user","content":"Rebuild the Index after the migration"}}` + "\n" +
+			// Meta lines and tool output are not messages
+			`{"type":"user","sessionId":"s3","isMeta":true,"message":{"role":"user","content":"flaky"}}` + "\n" +
+			`{"type":"user","sessionId":"s3","message":{"role":"user","content":[{"type":"tool_result","content":"flaky"}]}}` + "\n",
+	})
+	list := []projects.Project{app, lib}
+
+	ix := Load("")
+	ix.Update(list)
+
+	hits := ix.Search(list, "migration flaky index", 0)
+	if len(hits) != 1 || hits[0].SessionID != "s1" || hits[0].Project.Path != "/work/app" {
+		t.Fatalf("Search(migration flaky index) = %+v, want session s1 of /work/app", hits)
+	}
+	if !strings.Contains(hits[0].Snippet, "drops the index") {
+		t.Errorf("Snippet = %q, want the matching message", hits[0].Snippet)
+	}
+	if want := time.Date(2026, 10, 1, 9, 1, 0, 0, time.UTC); !hits[0].Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", hits[0].Time, want)
+	}
+
+	// The last word matches as a prefix
+	if hits := ix.Search(list, "migra", 0); len(hits) != 2 {
+		t.Errorf("Search(migra) found %d sessions, want 2", len(hits))
+	}
+	if hits := ix.Search(list, "flaky", 0); len(hits) != 2 {
+		t.Errorf("Search(flaky) found %d sessions, want 2 (not meta, tool or sub-agent lines)", len(hits))
+	}
+	if hits := ix.Search(list, "flaky", 0); len(hits) == 2 && hits[0].Matches != 1 {
+		t.Errorf("Matches = %d, want 1", hits[0].Matches)
+	}
+	if hits := ix.Search(list, "backoff", 0); len(hits) != 1 || hits[0].SessionID != "s4" {
+		t.Errorf("Search(backoff) = %+v, want the resumed session s4 by its file name", hits)
+	}
+	if hits := ix.Search([]projects.Project{lib}, "migration", 0); len(hits) != 1 || hits[0].SessionID != "s3" {
+		t.Errorf("Search limited to lib = %+v, want s3", hits)
+	}
+	if hits := ix.Search(list, "nothing here", 0); len(hits) != 0 {
+		t.Errorf("Search(nothing here) = %+v, want none", hits)
+	}
+}
+
+func TestUpdateIncremental(t *testing.T) {
+	root := t.TempDir()
+	first := messageJSON("user", "s1", "2026-10-01T09:00:00Z", "hello world")
+	p := writeProject(t, root, "/work/app", map[string]string{"s1.jsonl": first})
+	list := []projects.Project{p}
+	file := filepath.Join(root, "projects", p.EncodedDir, "s1.jsonl")
+	indexPath := filepath.Join(t.TempDir(), IndexFileName)
+
+	ix := Load(indexPath)
+	ix.Update(list)
+	if err := ix.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A new message and a line that is still being written
+	f, _ := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(messageJSON("assistant", "s1", "2026-10-01T09:01:00Z", "goodbye moon") + `{"type":"user","message":{"content":"partial`)
+	f.Close()
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(file, later, later)
+
+	ix = Load(indexPath)
+	ix.Update(list)
+	if hits := ix.Search(list, "hello", 0); len(hits) != 1 {
+		t.Errorf("Search(hello) after append found %d sessions, want 1", len(hits))
+	}
+	if hits := ix.Search(list, "moon", 0); len(hits) != 1 {
+		t.Errorf("Search(moon) after append found %d sessions, want 1", len(hits))
+	}
+	if got := len(ix.Terms["hello"]); got != 1 {
+		t.Errorf("hello has %d postings after append, want 1", got)
+	}
+
+	// A rewritten transcript replaces what was indexed
+	os.WriteFile(file, []byte(messageJSON("user", "s1", "2026-10-01T10:00:00Z", "fresh start")), 0644)
+	later = later.Add(time.Minute)
+	os.Chtimes(file, later, later)
+	ix.Update(list)
+	if hits := ix.Search(list, "hello", 0); len(hits) != 0 {
+		t.Errorf("Search(hello) after rewrite = %+v, want none", hits)
+	}
+	if hits := ix.Search(list, "fresh", 0); len(hits) != 1 {
+		t.Errorf("Search(fresh) after rewrite found %d sessions, want 1", len(hits))
+	}
+
+	// Transcripts that are gone are dropped
+	os.Remove(file)
+	ix.Update(list)
+	if len(ix.Files) != 0 || len(ix.Terms) != 0 {
+		t.Errorf("index after removal has %d files and %d terms, want none", len(ix.Files), len(ix.Terms))
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("word ", 30) + "needle " + strings.Repeat("word ", 60)
+	got := snippet(long, []string{"needle"})
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") || !strings.Contains(got, "needle") {
+		t.Errorf("snippet() = %q, want an excerpt around the match", got)
+	}
+	if got := snippet("short\ntext", []string{"text"}); got != "short text" {
+		t.Errorf("snippet() = %q, want %q", got, "short text")
+	}
+}
@@ -7,12 +7,15 @@ package terminal
 
 import (
 	"fmt"
+	"hash/fnv"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"syscall"
 	"unsafe"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
 )
 
 func utf16PtrFromString(s string) *uint16 {
@@ -34,9 +37,30 @@ func SetParentHwnd(hwnd uintptr) {
 //   - "wt": Windows Terminal
 //   - "wezterm": WezTerm
 //   - "cmd": cmd.exe
-//   - anything else: custom command with optional {dir} and {claude} placeholders
-func OpenProject(projectPath, terminalSetting string) error {
-	logDebug("OpenProject called for: %s (terminal=%q)", projectPath, terminalSetting)
+//   - anything else: custom command with optional {dir}, {claude} and {args} placeholders
+//
+// configDir is passed to claude as CLAUDE_CONFIG_DIR so it uses the data root
+// the project was found in; "" clears the variable so claude uses ~/.claude.
+// The variable is set by the command the terminal runs, since Windows
+// Terminal and WezTerm may hand the new tab to an instance that is already
+// running and doesn't share this process's environment.
+func OpenProject(projectPath, configDir, terminalSetting string) error {
+	return open(projectPath, configDir, nil, terminalSetting)
+}
+
+// OpenSession opens a terminal in the given directory and resumes a specific
+// Claude Code session with claude --resume.
+func OpenSession(projectPath, configDir, sessionID, terminalSetting string) error {
+	// Session IDs are UUIDs; reject anything else before it reaches a command line
+	if sessionID == "" || strings.Trim(sessionID, "0123456789abcdefABCDEF-") != "" {
+		return fmt.Errorf("invalid session ID: %s", sessionID)
+	}
+	return open(projectPath, configDir, []string{"--resume", sessionID}, terminalSetting)
+}
+
+// open launches claude with the given extra arguments in projectPath
+func open(projectPath, configDir string, claudeArgs []string, terminalSetting string) error {
+	logDebug("open called for: %s (configDir=%q, args=%v, terminal=%q)", projectPath, configDir, claudeArgs, terminalSetting)
 
 	// Reject paths containing double quotes to prevent command injection
 	if strings.Contains(projectPath, `"`) {
@@ -45,14 +69,14 @@ func OpenProject(projectPath, terminalSetting string) error {
 
 	switch terminalSetting {
 	case "", "wt", "wezterm", "cmd":
-		return openWithPreset(projectPath, terminalSetting)
+		return openWithPreset(projectPath, configDir, claudeArgs, terminalSetting)
 	default:
-		return openWithCustom(projectPath, terminalSetting)
+		return openWithCustom(projectPath, configDir, claudeArgs, terminalSetting)
 	}
 }
 
 // openWithPreset handles built-in terminal presets and auto-detection.
-func openWithPreset(projectPath, preset string) error {
+func openWithPreset(projectPath, configDir string, claudeArgs []string, preset string) error {
 	claudePath := findClaude()
 	if claudePath == "" {
 		showErrorDialog("Claude Code Not Found",
@@ -67,12 +91,17 @@ func openWithPreset(projectPath, preset string) error {
 	}
 	logDebug("Found claude at: %s", claudePath)
 
+	claudePath, err := writeLauncher(claudePath, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to prepare the claude command: %w", err)
+	}
+
 	if preset == "wt" {
 		wtPath := findWindowsTerminal()
 		if wtPath == "" {
 			return fmt.Errorf("Windows Terminal not found")
 		}
-		return launchWithWT(wtPath, projectPath, claudePath)
+		return launchWithWT(wtPath, projectPath, claudePath, claudeArgs)
 	}
 
 	if preset == "wezterm" {
@@ -80,18 +109,18 @@ func openWithPreset(projectPath, preset string) error {
 		if weztermPath == "" {
 			return fmt.Errorf("WezTerm not found")
 		}
-		return launchWithWezTerm(weztermPath, projectPath, claudePath)
+		return launchWithWezTerm(weztermPath, projectPath, claudePath, claudeArgs)
 	}
 
 	if preset == "cmd" {
-		return launchWithCmd(projectPath, claudePath, false)
+		return launchWithCmd(projectPath, claudePath, claudeArgs, false)
 	}
 
 	// Auto-detect: wt -> wezterm -> cmd
 	wtPath := findWindowsTerminal()
 	if wtPath != "" {
 		logDebug("Auto: found Windows Terminal: %s", wtPath)
-		if err := launchWithWT(wtPath, projectPath, claudePath); err == nil {
+		if err := launchWithWT(wtPath, projectPath, claudePath, claudeArgs); err == nil {
 			return nil
 		}
 		logDebug("Auto: Windows Terminal failed, trying next")
@@ -100,46 +129,54 @@ func openWithPreset(projectPath, preset string) error {
 	weztermPath := findWezTerm()
 	if weztermPath != "" {
 		logDebug("Auto: found WezTerm: %s", weztermPath)
-		if err := launchWithWezTerm(weztermPath, projectPath, claudePath); err == nil {
+		if err := launchWithWezTerm(weztermPath, projectPath, claudePath, claudeArgs); err == nil {
 			return nil
 		}
 		logDebug("Auto: WezTerm failed, trying next")
 	}
 
 	logDebug("Auto: falling back to cmd.exe")
-	return launchWithCmd(projectPath, claudePath, wtPath != "" || weztermPath != "")
+	return launchWithCmd(projectPath, claudePath, claudeArgs, wtPath != "" || weztermPath != "")
 }
 
 // openWithCustom launches a custom terminal command with placeholder substitution.
-// Supported placeholders: {dir} for project path, {claude} for claude executable path.
-// If no placeholders are present, the command is run as-is.
-func openWithCustom(projectPath, command string) error {
+// Supported placeholders: {dir} for project path, {claude} for the claude command
+// (a batch file from writeLauncher that sets CLAUDE_CONFIG_DIR and runs claude),
+// {args} for extra claude arguments (e.g. --resume <id>). If {args} is absent, the
+// arguments follow {claude}. If no placeholders are present, the command is run as-is.
+func openWithCustom(projectPath, configDir string, claudeArgs []string, command string) error {
 	// Only find claude if the command uses {claude}
-	expandedCmd := command
+	claudePath := ""
 	if strings.Contains(command, "{claude}") {
-		claudePath := findClaude()
+		claudePath = findClaude()
 		if claudePath == "" {
 			showErrorDialog("Claude Code Not Found",
 				"Claude Code executable was not found.\n\n"+
 					"Please install Claude Code and try again.")
 			return fmt.Errorf("claude executable not found")
 		}
-		expandedCmd = strings.ReplaceAll(expandedCmd, "{claude}", claudePath)
+		var err error
+		if claudePath, err = writeLauncher(claudePath, configDir); err != nil {
+			return fmt.Errorf("failed to prepare the claude command: %w", err)
+		}
 	}
-	expandedCmd = strings.ReplaceAll(expandedCmd, "{dir}", projectPath)
-
-	logDebug("Custom terminal: %s", expandedCmd)
 
-	// Split into executable and arguments
+	// Split into executable and arguments before substituting, so paths with
+	// spaces stay in one argument.
 	// Handles quoted paths: "C:\Program Files\term.exe" -arg1 -arg2
-	parts := splitCommand(expandedCmd)
+	parts := expandCommand(splitCommand(command), projectPath, claudePath, claudeArgs)
 	if len(parts) == 0 {
 		return fmt.Errorf("empty terminal command")
 	}
 
+	logDebug("Custom terminal: %v", parts)
+
 	cmd := exec.Command(parts[0], parts[1:]...)
+	// A command without {claude} that starts claude itself gets the variable
+	// from here, as long as it doesn't hand over to a running instance
+	cmd.Env = configEnv(configDir)
 	if isWezTermStart(parts) {
-		cmd.Env = append(os.Environ(), "WEZTERM_LOG=error")
+		cmd.Env = append(cmd.Env, "WEZTERM_LOG=error")
 		cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 	}
 	logDebug("exec.Command: %s %v", parts[0], parts[1:])
@@ -147,6 +184,90 @@ func openWithCustom(projectPath, command string) error {
 	return cmd.Start()
 }
 
+// configEnv returns the environment of the switcher with CLAUDE_CONFIG_DIR
+// set to configDir, or left out if configDir is "" so that Claude Code uses
+// its default root
+func configEnv(configDir string) []string {
+	prefix := projects.ConfigDirEnv + "="
+	var env []string
+	for _, kv := range os.Environ() {
+		// Variable names aren't case-sensitive on Windows
+		if len(kv) >= len(prefix) && strings.EqualFold(kv[:len(prefix)], prefix) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	if configDir != "" {
+		env = append(env, prefix+configDir)
+	}
+	return env
+}
+
+// expandCommand substitutes the {dir}, {claude} and {args} placeholders in
+// already-split command parts.
+func expandCommand(parts []string, projectPath, claudePath string, claudeArgs []string) []string {
+	hasArgs := false
+	for _, part := range parts {
+		if strings.Contains(part, "{args}") {
+			hasArgs = true
+		}
+	}
+	joinedArgs := strings.Join(claudeArgs, " ")
+
+	var expanded []string
+	for _, part := range parts {
+		if part == "{args}" {
+			expanded = append(expanded, claudeArgs...)
+			continue
+		}
+		if part == "{claude}" {
+			expanded = append(expanded, claudePath)
+			if !hasArgs {
+				expanded = append(expanded, claudeArgs...)
+			}
+			continue
+		}
+
+		// Placeholders embedded in a larger argument, e.g. cmd /k "cd {dir} && {claude}"
+		claudeText := claudePath
+		if !hasArgs && joinedArgs != "" {
+			claudeText += " " + joinedArgs
+		}
+		part = strings.ReplaceAll(part, "{claude}", claudeText)
+		part = strings.ReplaceAll(part, "{args}", joinedArgs)
+		part = strings.ReplaceAll(part, "{dir}", projectPath)
+		expanded = append(expanded, part)
+	}
+	return expanded
+}
+
+// writeLauncher writes a batch file that sets CLAUDE_CONFIG_DIR to configDir,
+// or clears it if configDir is "", and runs claude with the arguments it was
+// given, and returns its path. Terminals run it in place of claude. Writing
+// the variable into a file rather than onto a "cmd /c set ...&&" command line
+// keeps it out of the quoting rules of each terminal.
+func writeLauncher(claudePath, configDir string) (string, error) {
+	// % is the only character a batch file expands inside set "..." and
+	// quotes; paths can't contain quotes
+	escape := strings.NewReplacer("%", "%%").Replace
+	script := "@echo off\r\n" +
+		`set "` + projects.ConfigDirEnv + "=" + escape(configDir) + "\"\r\n" +
+		`"` + escape(claudePath) + `" %*` + "\r\n"
+
+	h := fnv.New64a()
+	h.Write([]byte(script))
+	dir := filepath.Join(os.TempDir(), "claude-code-switcher")
+	path := filepath.Join(dir, fmt.Sprintf("claude-%016x.cmd", h.Sum64()))
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == script {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	logDebug("Writing launcher %s for configDir=%q", path, configDir)
+	return path, os.WriteFile(path, []byte(script), 0644)
+}
+
 func isWezTermStart(parts []string) bool {
 	if len(parts) < 2 {
 		return false
@@ -184,11 +305,12 @@ func splitCommand(s string) []string {
 }
 
 // launchWithWT launches Windows Terminal directly using exec.Command
-func launchWithWT(wtPath, projectPath, claudePath string) error {
+func launchWithWT(wtPath, projectPath, claudePath string, claudeArgs []string) error {
 	// -w 0: reuse the most recent WT window instead of opening a new one
 	// nt: explicitly open a new tab
 	// --: separator to prevent wt from misinterpreting the command as options
-	cmd := exec.Command(wtPath, "-w", "0", "nt", "-d", projectPath, "--", claudePath)
+	args := append([]string{"-w", "0", "nt", "-d", projectPath, "--", "cmd", "/d", "/c", claudePath}, claudeArgs...)
+	cmd := exec.Command(wtPath, args...)
 	logDebug("exec.Command: %s %v", wtPath, cmd.Args[1:])
 
 	err := cmd.Start()
@@ -201,8 +323,9 @@ func launchWithWT(wtPath, projectPath, claudePath string) error {
 
 // launchWithWezTerm launches WezTerm, opening a new tab in an existing GUI
 // window or starting a new GUI window if none exists.
-func launchWithWezTerm(weztermPath, projectPath, claudePath string) error {
-	cmd := exec.Command(weztermPath, "start", "--new-tab", "--cwd", projectPath, "--", claudePath)
+func launchWithWezTerm(weztermPath, projectPath, claudePath string, claudeArgs []string) error {
+	args := append([]string{"start", "--new-tab", "--cwd", projectPath, "--", "cmd", "/d", "/c", claudePath}, claudeArgs...)
+	cmd := exec.Command(weztermPath, args...)
 	cmd.Env = append(os.Environ(), "WEZTERM_LOG=error")
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 	logDebug("exec.Command: %s %v", weztermPath, cmd.Args[1:])
@@ -216,12 +339,15 @@ func launchWithWezTerm(weztermPath, projectPath, claudePath string) error {
 }
 
 // launchWithCmd launches plain cmd.exe as fallback
-func launchWithCmd(projectPath, claudePath string, otherWasFound bool) error {
+func launchWithCmd(projectPath, claudePath string, claudeArgs []string, otherWasFound bool) error {
 	shell32 := syscall.NewLazyDLL("shell32.dll")
 	shellExecute := shell32.NewProc("ShellExecuteW")
 
 	cmdPath := `C:\Windows\System32\cmd.exe`
 	args := `/k cd /d "` + projectPath + `" && "` + claudePath + `"`
+	for _, arg := range claudeArgs {
+		args += " " + arg
+	}
 
 	logDebug("ShellExecute (cmd fallback): %s %s", cmdPath, args)
 
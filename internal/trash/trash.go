@@ -0,0 +1,317 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package trash keeps project data folders removed by prune, so they can be
+// restored until they expire.
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// DirName is the name of the trash in the switcher's config directory
+const DirName = "trash"
+
+// DefaultRetention is how long trashed folders are kept unless configured
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Retention returns the retention for a configured number of days, or
+// DefaultRetention if it isn't set
+func Retention(days int) time.Duration {
+	if days <= 0 {
+		return DefaultRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Each trashed folder is kept as <trash>/<id>/data, described by
+// <trash>/<id>/entry.json
+const (
+	entryFileName = "entry.json"
+	dataDirName   = "data"
+)
+
+// Entry describes a trashed data folder
+type Entry struct {
+	ID          string    `json:"id"`
+	OriginalDir string    `json:"originalDir"` // Where the folder is restored to
+	ProjectPath string    `json:"projectPath"`
+	Size        int64     `json:"size"`
+	Sessions    int       `json:"sessions"`
+	TrashedAt   time.Time `json:"trashedAt"`
+}
+
+// Trash is a directory of trashed data folders
+type Trash struct {
+	dir string
+}
+
+// Open returns the trash kept in dir, which is created when first needed
+func Open(dir string) *Trash {
+	return &Trash{dir: dir}
+}
+
+// PartialMoveError is returned when a folder was copied whole to another
+// volume but could not be fully deleted afterwards. The copy is complete; what
+// is left at Src is a partial duplicate to delete by hand.
+type PartialMoveError struct {
+	Src string
+	Err error
+}
+
+func (e *PartialMoveError) Error() string {
+	return fmt.Sprintf("copied, but the original was only partly removed: %v", e.Err)
+}
+
+func (e *PartialMoveError) Unwrap() error {
+	return e.Err
+}
+
+// Move moves the folder src into the trash, recording e with the ID and
+// original location filled in. If src was copied but not fully deleted, the
+// entry is kept and a *PartialMoveError returned along with it.
+func (t *Trash) Move(src string, e Entry) (Entry, error) {
+	if _, err := os.Stat(src); err != nil {
+		return e, err
+	}
+	if e.TrashedAt.IsZero() {
+		e.TrashedAt = time.Now()
+	}
+	e.OriginalDir = src
+
+	// IDs sort by time and say what they were; a counter keeps them unique
+	base := e.TrashedAt.Format("20060102-150405") + "-" + filepath.Base(src)
+	e.ID = base
+	for n := 2; ; n++ {
+		err := os.MkdirAll(t.dir, 0755)
+		if err == nil {
+			err = os.Mkdir(filepath.Join(t.dir, e.ID), 0755)
+		}
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return e, err
+		}
+		e.ID = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	entryDir := filepath.Join(t.dir, e.ID)
+	if err := writeEntry(entryDir, e); err != nil {
+		os.RemoveAll(entryDir)
+		return e, err
+	}
+	if err := moveDir(src, filepath.Join(entryDir, dataDirName)); err != nil {
+		// After a complete copy the trash holds the only whole folder
+		var partial *PartialMoveError
+		if !errors.As(err, &partial) {
+			os.RemoveAll(entryDir)
+		}
+		return e, err
+	}
+	return e, nil
+}
+
+// Prune moves the data folders of orphaned projects into the trash. It
+// carries on past folders that can't be moved and returns the first error.
+// Folders that were only partly removed after being copied are trashed.
+func (t *Trash) Prune(orphans []projects.Orphan) ([]Entry, error) {
+	var moved []Entry
+	var firstErr error
+	for _, o := range orphans {
+		e, err := t.Move(o.Dir, Entry{ProjectPath: o.Project.Path, Size: o.Size, Sessions: o.Sessions})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", o.Dir, err)
+			}
+			var partial *PartialMoveError
+			if !errors.As(err, &partial) {
+				continue
+			}
+		}
+		moved = append(moved, e)
+	}
+	return moved, firstErr
+}
+
+// List returns the trashed folders, most recently trashed first. A missing
+// trash is empty.
+func (t *Trash) List() ([]Entry, error) {
+	dirs, err := os.ReadDir(t.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []Entry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		e, err := readEntry(filepath.Join(t.dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		e.ID = d.Name()
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].TrashedAt.After(entries[j].TrashedAt)
+	})
+	return entries, nil
+}
+
+// Restore moves a trashed folder back where it came from. It fails rather
+// than overwrite a folder that has been created there since.
+func (t *Trash) Restore(id string) (Entry, error) {
+	entryDir := filepath.Join(t.dir, filepath.Base(id))
+	e, err := readEntry(entryDir)
+	if err != nil {
+		return e, fmt.Errorf("no trashed folder %q", id)
+	}
+	e.ID = filepath.Base(id)
+	if _, err := os.Stat(e.OriginalDir); err == nil {
+		return e, fmt.Errorf("%s already exists", e.OriginalDir)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.OriginalDir), 0755); err != nil {
+		return e, err
+	}
+	if err := moveDir(filepath.Join(entryDir, dataDirName), e.OriginalDir); err != nil {
+		// The folder is back whole; what is left in the trash goes below
+		var partial *PartialMoveError
+		if !errors.As(err, &partial) {
+			return e, err
+		}
+	}
+	return e, os.RemoveAll(entryDir)
+}
+
+// Purge permanently deletes the folders trashed before cutoff and returns
+// what was deleted
+func (t *Trash) Purge(cutoff time.Time) ([]Entry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+	var purged []Entry
+	for _, e := range entries {
+		if !e.TrashedAt.Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(t.dir, e.ID)); err != nil {
+			return purged, err
+		}
+		purged = append(purged, e)
+	}
+	return purged, nil
+}
+
+// FormatSize formats a byte count, e.g. 512 B, 3.4 MB or 1.2 GB
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGT"[exp])
+}
+
+func writeEntry(entryDir string, e Entry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(entryDir, entryFileName), data, 0644)
+}
+
+func readEntry(entryDir string) (Entry, error) {
+	var e Entry
+	data, err := os.ReadFile(filepath.Join(entryDir, entryFileName))
+	if err != nil {
+		return e, err
+	}
+	return e, json.Unmarshal(data, &e)
+}
+
+// moveDir renames src to dst, copying and then deleting src when they are
+// on different volumes. A failed copy is undone; a failed delete after a
+// complete copy is a *PartialMoveError.
+func moveDir(src, dst string) error {
+	if err := rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	if err := removeAll(src); err != nil {
+		return &PartialMoveError{Src: src, Err: err}
+	}
+	return nil
+}
+
+// rename and removeAll are replaced by tests to act out moves between
+// volumes
+var (
+	rename    = os.Rename
+	removeAll = os.RemoveAll
+)
+
+// copyDir copies the directory tree src to dst, which must not exist
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a file, keeping its modification time, which Claude Code
+// data is partly dated by
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// writeDataDir creates a project data folder with one session
+func writeDataDir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "s1"), 0755); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "s1.jsonl"), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write session: %v", err)
+	}
+}
+
+func TestMoveAndRestore(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "projects", "-work-gone")
+	writeDataDir(t, src)
+	tr := Open(filepath.Join(t.TempDir(), DirName))
+
+	e, err := tr.Move(src, Entry{ProjectPath: "/work/gone", Sessions: 1})
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists after Move: %v", err)
+	}
+	if !strings.HasSuffix(e.ID, "-work-gone") || e.OriginalDir != src {
+		t.Errorf("Move() = %+v, want ID ending in the folder name and OriginalDir %s", e, src)
+	}
+
+	entries, err := tr.List()
+	if err != nil || len(entries) != 1 || entries[0].ID != e.ID || entries[0].ProjectPath != "/work/gone" {
+		t.Fatalf("List() = %+v, %v, want the moved folder", entries, err)
+	}
+
+	// Restoring never overwrites a folder created in the meantime
+	os.MkdirAll(src, 0755)
+	if _, err := tr.Restore(e.ID); err == nil {
+		t.Error("Restore() over an existing folder succeeded")
+	}
+	os.Remove(src)
+
+	if _, err := tr.Restore(e.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "s1.jsonl")); err != nil {
+		t.Errorf("session not restored: %v", err)
+	}
+	if entries, _ := tr.List(); len(entries) != 0 {
+		t.Errorf("List() after restore = %+v, want empty", entries)
+	}
+}
+
+func TestMovePartial(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "projects", "-work-gone")
+	writeDataDir(t, src)
+	tr := Open(filepath.Join(t.TempDir(), DirName))
+
+	// A move to another volume whose source can't be deleted afterwards
+	rename = func(string, string) error { return errors.New("cross-device link") }
+	removeAll = func(string) error { return errors.New("access denied") }
+	defer func() { rename, removeAll = os.Rename, os.RemoveAll }()
+
+	e, err := tr.Move(src, Entry{ProjectPath: "/work/gone", Sessions: 1})
+	var partial *PartialMoveError
+	if !errors.As(err, &partial) || partial.Src != src {
+		t.Fatalf("Move() error = %v, want a PartialMoveError for %s", err, src)
+	}
+	// The complete copy is kept
+	if _, err := os.Stat(filepath.Join(tr.dir, e.ID, dataDirName, "s1.jsonl")); err != nil {
+		t.Errorf("trashed copy is gone: %v", err)
+	}
+	if entries, _ := tr.List(); len(entries) != 1 {
+		t.Errorf("List() = %+v, want the copied folder", entries)
+	}
+
+	// A failed copy is undone, and the source left alone
+	os.RemoveAll(filepath.Join(tr.dir, e.ID))
+	removeAll = os.RemoveAll
+	if err := os.Symlink(filepath.Join(src, "missing"), filepath.Join(src, "broken.jsonl")); err != nil {
+		t.Skipf("Can't create a symlink: %v", err)
+	}
+	if _, err := tr.Move(src, Entry{}); err == nil || errors.As(err, &partial) {
+		t.Errorf("Move() with an unreadable file error = %v, want a failed copy", err)
+	}
+	if entries, _ := tr.List(); len(entries) != 0 {
+		t.Errorf("List() after a failed copy = %+v, want empty", entries)
+	}
+	if _, err := os.Stat(filepath.Join(src, "s1.jsonl")); err != nil {
+		t.Errorf("source touched by a failed copy: %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	root := t.TempDir()
+	tr := Open(filepath.Join(root, DirName))
+	now := time.Now()
+	for _, name := range []string{"-old", "-new"} {
+		src := filepath.Join(root, name)
+		writeDataDir(t, src)
+		at := now
+		if name == "-old" {
+			at = now.Add(-40 * 24 * time.Hour)
+		}
+		if _, err := tr.Move(src, Entry{TrashedAt: at}); err != nil {
+			t.Fatalf("Move(%s) error = %v", name, err)
+		}
+	}
+
+	purged, err := tr.Purge(now.Add(-Retention(0)))
+	if err != nil || len(purged) != 1 || !strings.HasSuffix(purged[0].ID, "-old") {
+		t.Fatalf("Purge() = %+v, %v, want the old folder", purged, err)
+	}
+	if entries, _ := tr.List(); len(entries) != 1 || !strings.HasSuffix(entries[0].ID, "-new") {
+		t.Errorf("List() after purge = %+v, want the new folder", entries)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	writeDataDir(t, filepath.Join(root, "projects", "-work-gone"))
+	p := projects.Project{Path: "/work/gone", Root: root, EncodedDir: "-work-gone", PathState: projects.PathMissing}
+
+	orphans := projects.Orphans([]projects.Project{p})
+	if len(orphans) != 1 || orphans[0].Sessions != 1 {
+		t.Fatalf("Orphans() = %+v, want one folder with one session", orphans)
+	}
+	moved, err := Open(filepath.Join(root, DirName)).Prune(orphans)
+	if err != nil || len(moved) != 1 || moved[0].ProjectPath != "/work/gone" || moved[0].Sessions != 1 {
+		t.Fatalf("Prune() = %+v, %v", moved, err)
+	}
+}
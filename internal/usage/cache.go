@@ -0,0 +1,132 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package usage
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/fsutil"
+)
+
+// cacheVersion is bumped whenever the cache format or the way usage is
+// counted changes. Cache files with any other version are discarded.
+const cacheVersion = 2
+
+// Cache remembers the usage of transcript files between runs, keyed by
+// file path. A file that only grew is scanned from where it was left off.
+type Cache struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+
+	path  string
+	mu    sync.Mutex
+	seen  map[string]bool
+	dirty bool
+}
+
+// cacheEntry is the usage of one file at a given size and modification time
+type cacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	fileUsage
+}
+
+// CacheFileName is the name of the usage cache in the switcher's config
+// directory
+const CacheFileName = "usage-cache.json"
+
+// LoadCache reads the cache file at path. A missing, unreadable or outdated
+// file yields an empty cache; an empty path yields one that is never saved.
+func LoadCache(path string) *Cache {
+	c := &Cache{
+		Version: cacheVersion,
+		Entries: make(map[string]cacheEntry),
+		path:    path,
+		seen:    make(map[string]bool),
+	}
+	if path == "" {
+		return c
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var stored Cache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Entries == nil {
+		c.dirty = true
+		return c
+	}
+	c.Entries = stored.Entries
+	return c
+}
+
+// file returns the usage of a transcript, scanning only what is new since
+// it was cached
+func (c *Cache) file(path string) (*fileUsage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.Entries[path]
+	c.seen[path] = true
+	c.mu.Unlock()
+
+	if ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		return &entry.fileUsage, nil
+	}
+
+	// Transcripts are append-only: if the file grew, continue after what was read
+	var prev *fileUsage
+	if ok && info.Size() >= entry.Offset && !info.ModTime().Before(entry.ModTime) {
+		prev = &entry.fileUsage
+	}
+	fu, err := scanFile(path, prev)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.Entries[path] = cacheEntry{Size: info.Size(), ModTime: info.ModTime(), fileUsage: *fu}
+	c.dirty = true
+	c.mu.Unlock()
+	return fu, nil
+}
+
+// Save writes the cache if anything changed, dropping files that weren't
+// looked at since it was loaded when prune is set. Use prune only after a
+// scan of every project, or usage of the others would be forgotten.
+func (c *Cache) Save(prune bool) error {
+	if c.path == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prune {
+		for path := range c.Entries {
+			if !c.seen[path] {
+				delete(c.Entries, path)
+				c.dirty = true
+			}
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(c.path, data); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
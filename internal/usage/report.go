@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// SessionUsage is the usage of one session, including its sub-agents
+type SessionUsage struct {
+	ID    string
+	Usage Usage
+	// LastModel is the model that answered last in the session, at LastUsed.
+	// Sub-agents, which often run on a smaller model, only count for a
+	// session whose own transcript has no responses.
+	LastModel string
+	LastUsed  time.Time
+}
+
+// ProjectUsage is the usage of one project
+type ProjectUsage struct {
+	Project  projects.Project
+	Usage    Usage
+	Sessions []SessionUsage // Most tokens first, sessions without usage omitted
+	// LastModel is the LastModel of the session used last
+	LastModel string
+}
+
+// ForProject totals the usage recorded in every transcript of a project,
+// including sub-agent transcripts
+func ForProject(p projects.Project, cache *Cache, filter Filter) (ProjectUsage, error) {
+	pu := ProjectUsage{Project: p, Usage: make(Usage)}
+	dirs, err := p.DataDirs()
+	if err != nil {
+		return pu, err
+	}
+
+	sessions := make(map[string]*SessionUsage)
+	// Whether a session's LastModel came from its own transcript
+	ownModel := make(map[string]bool)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+				continue
+			}
+			fu, err := cache.file(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			u := fu.usage(filter)
+			if len(u) == 0 {
+				continue
+			}
+			id := fu.Session
+			if id == "" {
+				id = strings.TrimSuffix(name, ".jsonl")
+			}
+			s := sessions[id]
+			if s == nil {
+				s = &SessionUsage{ID: id, Usage: make(Usage)}
+				sessions[id] = s
+			}
+			s.Usage.Add(u)
+			pu.Usage.Add(u)
+
+			own := !strings.HasPrefix(name, "agent-")
+			if fu.LastModel != "" && (own && !ownModel[id] || own == ownModel[id] && fu.LastTime.After(s.LastUsed)) {
+				s.LastModel, s.LastUsed = fu.LastModel, fu.LastTime
+				ownModel[id] = own
+			}
+		}
+	}
+
+	var lastUsed time.Time
+	for _, s := range sessions {
+		pu.Sessions = append(pu.Sessions, *s)
+		if s.LastModel != "" && (pu.LastModel == "" || s.LastUsed.After(lastUsed)) {
+			pu.LastModel, lastUsed = s.LastModel, s.LastUsed
+		}
+	}
+	sort.Slice(pu.Sessions, func(i, j int) bool {
+		return pu.Sessions[i].Usage.Total().Total() > pu.Sessions[j].Usage.Total().Total()
+	})
+	return pu, nil
+}
+
+// ForProjects totals the usage of every project, a few at a time, in the
+// order given. Projects whose data can't be read get empty usage.
+func ForProjects(list []projects.Project, cache *Cache, filter Filter) []ProjectUsage {
+	results := make([]ProjectUsage, len(list))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], _ = ForProject(list[i], cache, filter)
+			}
+		}()
+	}
+	for i := range list {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// SortByTokens orders project usage by total tokens, most first
+func SortByTokens(list []ProjectUsage) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Usage.Total().Total() > list[j].Usage.Total().Total()
+	})
+}
+
+// SortByCost orders project usage by estimated cost, highest first
+func SortByCost(list []ProjectUsage, pricing Pricing) {
+	sort.SliceStable(list, func(i, j int) bool {
+		ci, _ := list[i].Usage.Cost(pricing)
+		cj, _ := list[j].Usage.Cost(pricing)
+		return ci > cj
+	})
+}
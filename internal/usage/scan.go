@@ -0,0 +1,162 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+// dayLayout is the format of the per-day keys, always in UTC
+const dayLayout = "2006-01-02"
+
+// fileUsage is the usage found in one transcript file
+type fileUsage struct {
+	// Session is the session the file belongs to. Sub-agent transcripts
+	// carry the session ID of the conversation that started them.
+	Session string `json:"session"`
+	// Days maps UTC dates to the usage of requests made on that day
+	Days map[string]Usage `json:"days"`
+
+	// LastModel is the model of the last response in the file, made at
+	// LastTime
+	LastModel string    `json:"lastModel"`
+	LastTime  time.Time `json:"lastTime"`
+
+	// Where scanning stopped, so a transcript that was appended to can be
+	// continued instead of read again
+	Offset  int64  `json:"offset"`
+	LastKey string `json:"lastKey"`
+}
+
+// usageLine is the part of a transcript line that carries usage
+type usageLine struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionId"`
+	Timestamp string `json:"timestamp"`
+	RequestID string `json:"requestId"`
+	Message   *struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// usageMarker is present in every line worth decoding. Checking for it
+// first skips the large tool-result lines without parsing them.
+var usageMarker = []byte(`"usage"`)
+
+// scanFile reads usage from a transcript, continuing from prev if the file
+// has only grown since prev was recorded. Only complete lines are consumed,
+// so a transcript that is being written to is picked up where it left off.
+func scanFile(path string, prev *fileUsage) (*fileUsage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fu := &fileUsage{Days: make(map[string]Usage)}
+	if prev != nil {
+		fu.Session = prev.Session
+		fu.Offset = prev.Offset
+		fu.LastKey = prev.LastKey
+		fu.LastModel = prev.LastModel
+		fu.LastTime = prev.LastTime
+		for day, u := range prev.Days {
+			copied := make(Usage, len(u))
+			copied.Add(u)
+			fu.Days[day] = copied
+		}
+		if _, err := f.Seek(fu.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	// Claude Code writes one line per content block of a response, each
+	// repeating the response's usage, so requests are counted once by ID
+	seen := make(map[string]bool)
+	if fu.LastKey != "" {
+		seen[fu.LastKey] = true
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// A partial last line is read again next time
+			if err == io.EOF {
+				break
+			}
+			return fu, err
+		}
+		fu.Offset += int64(len(line))
+
+		if !bytes.Contains(line, usageMarker) {
+			continue
+		}
+		var ul usageLine
+		if json.Unmarshal(line, &ul) != nil || ul.Type != "assistant" || ul.Message == nil || ul.Message.Usage == nil {
+			continue
+		}
+		if fu.Session == "" {
+			fu.Session = ul.SessionID
+		}
+		// Synthetic messages (e.g. API errors) didn't reach a model
+		model := ul.Message.Model
+		if model == "" || model == "<synthetic>" {
+			continue
+		}
+		key := ul.Message.ID + "/" + ul.RequestID
+		if key != "/" {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			fu.LastKey = key
+		}
+
+		day := ""
+		if t, err := time.Parse(time.RFC3339, ul.Timestamp); err == nil {
+			day = t.UTC().Format(dayLayout)
+			fu.LastTime = t
+		}
+		fu.LastModel = model
+		u := fu.Days[day]
+		if u == nil {
+			u = make(Usage)
+			fu.Days[day] = u
+		}
+		tokens := u[model]
+		tokens.Add(Tokens{
+			Input:         ul.Message.Usage.InputTokens,
+			Output:        ul.Message.Usage.OutputTokens,
+			CacheRead:     ul.Message.Usage.CacheReadInputTokens,
+			CacheCreation: ul.Message.Usage.CacheCreationInputTokens,
+			Messages:      1,
+		})
+		u[model] = tokens
+	}
+	return fu, nil
+}
+
+// usage returns the file's usage within the filter
+func (fu *fileUsage) usage(filter Filter) Usage {
+	total := make(Usage)
+	for day, u := range fu.Days {
+		if filter.includes(day) {
+			total.Add(u)
+		}
+	}
+	return total
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package usage totals the tokens recorded in Claude Code session
+// transcripts and estimates what they cost.
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/config"
+)
+
+// Tokens counts the tokens of one or more API requests
+type Tokens struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	CacheRead     int64 `json:"cacheRead"`
+	CacheCreation int64 `json:"cacheCreation"`
+	// Messages is the number of responses the tokens were used by, one per
+	// API request
+	Messages int64 `json:"messages"`
+}
+
+// Add adds o to t
+func (t *Tokens) Add(o Tokens) {
+	t.Input += o.Input
+	t.Output += o.Output
+	t.CacheRead += o.CacheRead
+	t.CacheCreation += o.CacheCreation
+	t.Messages += o.Messages
+}
+
+// Total returns the number of tokens of all kinds, not counting Messages
+func (t Tokens) Total() int64 {
+	return t.Input + t.Output + t.CacheRead + t.CacheCreation
+}
+
+// Usage is token usage broken down by model name
+type Usage map[string]Tokens
+
+// Add adds all of o to u, which must not be nil
+func (u Usage) Add(o Usage) {
+	for model, tokens := range o {
+		sum := u[model]
+		sum.Add(tokens)
+		u[model] = sum
+	}
+}
+
+// Total returns the tokens of all models together
+func (u Usage) Total() Tokens {
+	var total Tokens
+	for _, tokens := range u {
+		total.Add(tokens)
+	}
+	return total
+}
+
+// Cost estimates the cost of u in US dollars. Models without a price
+// contribute nothing and are returned so callers can point them out.
+func (u Usage) Cost(pricing Pricing) (float64, []string) {
+	var total float64
+	var unpriced []string
+	for model, tokens := range u {
+		price, ok := pricing.For(model)
+		if !ok {
+			unpriced = append(unpriced, model)
+			continue
+		}
+		total += cost(price, tokens)
+	}
+	sort.Strings(unpriced)
+	return total, unpriced
+}
+
+// Models returns the model names in u, most tokens first
+func (u Usage) Models() []string {
+	models := make([]string, 0, len(u))
+	for model := range u {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		ti, tj := u[models[i]].Total(), u[models[j]].Total()
+		if ti != tj {
+			return ti > tj
+		}
+		return models[i] < models[j]
+	})
+	return models
+}
+
+// Price is what a model costs, in US dollars per million tokens. It is the
+// type prices are configured with.
+type Price = config.Price
+
+// cost returns the cost of the given tokens at price p
+func cost(p Price, t Tokens) float64 {
+	return (float64(t.Input)*p.Input +
+		float64(t.Output)*p.Output +
+		float64(t.CacheRead)*p.CacheRead +
+		float64(t.CacheCreation)*p.CacheWrite) / 1e6
+}
+
+// Pricing maps model name prefixes to prices. The longest matching prefix
+// wins, so "claude-opus-4-5" can be priced apart from "claude-opus-4".
+type Pricing map[string]Price
+
+// DefaultPricing returns list prices for Claude models at the time of
+// writing. They are estimates only; override them in the config to match
+// your plan.
+func DefaultPricing() Pricing {
+	return Pricing{
+		"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-haiku-4":    {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	}
+}
+
+// With returns a copy of p with the given prices added or replaced
+func (p Pricing) With(overrides map[string]Price) Pricing {
+	merged := make(Pricing, len(p)+len(overrides))
+	for prefix, price := range p {
+		merged[prefix] = price
+	}
+	for prefix, price := range overrides {
+		merged[prefix] = price
+	}
+	return merged
+}
+
+// For returns the price of a model by longest matching prefix
+func (p Pricing) For(model string) (Price, bool) {
+	best := ""
+	found := false
+	for prefix := range p {
+		if strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best)) {
+			best, found = prefix, true
+		}
+	}
+	return p[best], found
+}
+
+// Filter restricts usage to the UTC days that overlap [Since, Until). Usage
+// is kept per UTC day, so a bound within a day takes in the whole day; bounds
+// at midnight UTC, such as those the usage command parses, are exact. Zero
+// times are unbounded.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+}
+
+// includes reports whether a day (UTC, "2006-01-02") overlaps the filter
+func (f Filter) includes(day string) bool {
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+	start, err := time.Parse(dayLayout, day)
+	if err != nil {
+		return false
+	}
+	end := start.Add(24 * time.Hour)
+	if !f.Since.IsZero() && !end.After(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !start.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// FormatTokens formats a token count compactly, e.g. 950, 12.3k or 4.56M
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.2fB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.2fM", float64(n)/1e6)
+	case n >= 1e4:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
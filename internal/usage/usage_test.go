@@ -0,0 +1,150 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package usage
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// assistantLine returns a transcript line for one content block of a response
+func assistantLine(session, msgID, model, timestamp string, in, out, cacheRead, cacheWrite int) string {
+	return fmt.Sprintf(`{"type":"assistant","sessionId":%q,"timestamp":%q,"requestId":"req_%s","message":{"id":%q,"model":%q,"role":"assistant","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":%d,"output_tokens":%d,"cache_read_input_tokens":%d,"cache_creation_input_tokens":%d}}}`+"\n",
+		session, timestamp, msgID, msgID, model, in, out, cacheRead, cacheWrite)
+}
+
+// writeProject creates <root>/projects/<encoded> with the given files
+func writeProject(t *testing.T, files map[string]string) projects.Project {
+	t.Helper()
+	root := t.TempDir()
+	encoded := "-work-app"
+	dir := filepath.Join(root, "projects", encoded)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return projects.Project{Path: "/work/app", Root: root, EncodedDir: encoded, EncodedDirs: []string{encoded}}
+}
+
+func TestForProject(t *testing.T) {
+	sonnet := "claude-sonnet-4-5-20250929"
+	opus := "claude-opus-4-1-20250805"
+	main := `{"type":"user","sessionId":"s1","message":{"role":"user","content":"hello"}}` + "\n" +
+		// Two content blocks of the same response repeat its usage
+		assistantLine("s1", "msg_1", sonnet, "2026-09-30T23:00:00Z", 10, 20, 100, 1000) +
+		assistantLine("s1", "msg_1", sonnet, "2026-09-30T23:00:00Z", 10, 20, 100, 1000) +
+		assistantLine("s1", "msg_2", opus, "2026-10-01T09:00:00Z", 1, 2, 3, 4) +
+		assistantLine("s1", "msg_3", "<synthetic>", "2026-10-01T09:00:00Z", 5, 5, 5, 5)
+	agent := assistantLine("s1", "msg_4", sonnet, "2026-10-02T09:00:00Z", 1, 1, 1, 1)
+	other := assistantLine("s2", "msg_5", sonnet, "2026-10-03T09:00:00Z", 2, 2, 2, 2)
+
+	p := writeProject(t, map[string]string{
+		"s1.jsonl":       main,
+		"agent-a1.jsonl": agent,
+		"s2.jsonl":       other,
+	})
+
+	pu, err := ForProject(p, LoadCache(""), Filter{})
+	if err != nil {
+		t.Fatalf("ForProject() error = %v", err)
+	}
+	if got, want := pu.Usage[sonnet], (Tokens{13, 23, 103, 1003, 3}); got != want {
+		t.Errorf("sonnet usage = %+v, want %+v", got, want)
+	}
+	if got, want := pu.Usage[opus], (Tokens{1, 2, 3, 4, 1}); got != want {
+		t.Errorf("opus usage = %+v, want %+v", got, want)
+	}
+	if _, ok := pu.Usage["<synthetic>"]; ok {
+		t.Error("synthetic messages were counted")
+	}
+	if len(pu.Sessions) != 2 || pu.Sessions[0].ID != "s1" {
+		t.Fatalf("Sessions = %+v, want s1 (with its sub-agent) then s2", pu.Sessions)
+	}
+	if got := pu.Sessions[0].Usage.Total().Total(); got != 1130+10+4 {
+		t.Errorf("s1 total = %d, want %d", got, 1130+10+4)
+	}
+	// The sub-agent answered last, but the session itself last ran on opus
+	if s := pu.Sessions[0]; s.LastModel != opus || !s.LastUsed.Equal(time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("s1 last model = %s at %v, want %s", s.LastModel, s.LastUsed, opus)
+	}
+	if pu.LastModel != sonnet {
+		t.Errorf("project last model = %s, want %s from s2", pu.LastModel, sonnet)
+	}
+
+	october := Filter{
+		Since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+	}
+	pu, _ = ForProject(p, LoadCache(""), october)
+	if got, want := pu.Usage.Total(), (Tokens{2, 3, 4, 5, 2}); got != want {
+		t.Errorf("filtered usage = %+v, want %+v", got, want)
+	}
+}
+
+func TestCacheContinuesAppendedFile(t *testing.T) {
+	model := "claude-sonnet-4-5"
+	first := assistantLine("s1", "msg_1", model, "2026-10-01T09:00:00Z", 1, 1, 0, 0)
+	p := writeProject(t, map[string]string{"s1.jsonl": first})
+	file := filepath.Join(p.Root, "projects", p.EncodedDir, "s1.jsonl")
+	cachePath := filepath.Join(t.TempDir(), "usage-cache.json")
+
+	cache := LoadCache(cachePath)
+	ForProject(p, cache, Filter{})
+	if err := cache.Save(true); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A repeated block of the last response, a new response and a line that
+	// is still being written
+	f, _ := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(first + assistantLine("s1", "msg_2", model, "2026-10-01T10:00:00Z", 2, 2, 0, 0) + `{"type":"assistant"`)
+	f.Close()
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(file, later, later)
+
+	pu, _ := ForProject(p, LoadCache(cachePath), Filter{})
+	if got, want := pu.Usage[model], (Tokens{Input: 3, Output: 3, Messages: 2}); got != want {
+		t.Errorf("usage after append = %+v, want %+v", got, want)
+	}
+	if s := pu.Sessions[0]; s.LastModel != model || s.LastUsed.Hour() != 10 {
+		t.Errorf("last model after append = %s at %v", s.LastModel, s.LastUsed)
+	}
+}
+
+func TestPricing(t *testing.T) {
+	pricing := DefaultPricing().With(map[string]Price{
+		"claude-sonnet-4-5": {Input: 1, Output: 2},
+	})
+
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"claude-sonnet-4-5-20250929", 1 + 2},
+		{"claude-sonnet-4-20250514", 3 + 15},
+		{"claude-opus-4-5-20251101", 5 + 25},
+		{"claude-opus-4-1-20250805", 15 + 75},
+	}
+	for _, tt := range tests {
+		u := Usage{tt.model: {Input: 1e6, Output: 1e6}}
+		cost, unpriced := u.Cost(pricing)
+		if math.Abs(cost-tt.want) > 1e-9 || len(unpriced) != 0 {
+			t.Errorf("Cost(%s) = %v, %v, want %v", tt.model, cost, unpriced, tt.want)
+		}
+	}
+
+	if _, unpriced := (Usage{"gpt-x": {Input: 1}}).Cost(pricing); len(unpriced) != 1 {
+		t.Errorf("unknown model not reported as unpriced")
+	}
+}
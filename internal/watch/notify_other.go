@@ -0,0 +1,12 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+//go:build !windows
+
+package watch
+
+import "context"
+
+// notifyChanges does nothing where change notifications aren't implemented;
+// the rescans on the interval find the changes instead
+func notifyChanges(ctx context.Context, dirs []string, changed chan<- struct{}) {}
@@ -0,0 +1,72 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+//go:build windows
+
+package watch
+
+import (
+	"context"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                         = syscall.NewLazyDLL("kernel32.dll")
+	procFindFirstChangeNotificationW = kernel32.NewProc("FindFirstChangeNotificationW")
+	procFindNextChangeNotification   = kernel32.NewProc("FindNextChangeNotification")
+	procFindCloseChangeNotification  = kernel32.NewProc("FindCloseChangeNotification")
+)
+
+const (
+	FILE_NOTIFY_CHANGE_FILE_NAME  = 0x00000001
+	FILE_NOTIFY_CHANGE_DIR_NAME   = 0x00000002
+	FILE_NOTIFY_CHANGE_LAST_WRITE = 0x00000010
+
+	INVALID_HANDLE_VALUE = ^uintptr(0)
+	WAIT_TIMEOUT         = 0x00000102
+
+	// pollCancel is how often, in milliseconds, a wait checks whether the
+	// watcher was stopped
+	pollCancel = 250
+)
+
+// notifyChanges signals changed whenever a file is created, deleted or
+// written below one of dirs, until ctx is cancelled. Folders that can't be
+// watched, e.g. because they don't exist yet, are left to the rescans on
+// the interval.
+func notifyChanges(ctx context.Context, dirs []string, changed chan<- struct{}) {
+	for _, dir := range dirs {
+		path, err := syscall.UTF16PtrFromString(dir)
+		if err != nil {
+			continue
+		}
+		h, _, _ := procFindFirstChangeNotificationW.Call(
+			uintptr(unsafe.Pointer(path)),
+			1, // Watch the whole subtree
+			FILE_NOTIFY_CHANGE_FILE_NAME|FILE_NOTIFY_CHANGE_DIR_NAME|FILE_NOTIFY_CHANGE_LAST_WRITE,
+		)
+		if h == INVALID_HANDLE_VALUE {
+			continue
+		}
+		go func() {
+			defer procFindCloseChangeNotification.Call(h)
+			for ctx.Err() == nil {
+				event, err := syscall.WaitForSingleObject(syscall.Handle(h), pollCancel)
+				if err != nil {
+					return
+				}
+				if event == WAIT_TIMEOUT {
+					continue
+				}
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+				if ret, _, _ := procFindNextChangeNotification.Call(h); ret == 0 {
+					return
+				}
+			}
+		}()
+	}
+}
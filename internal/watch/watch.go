@@ -0,0 +1,238 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+// Package watch reports Claude Code projects that appear, change or
+// disappear while the switcher is open. The projects folders of the data
+// roots are rescanned when the system reports a change in them, and every
+// so often regardless, which is all that happens where change notifications
+// aren't available.
+package watch
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// Op is the kind of change an Event reports
+type Op int
+
+const (
+	Add    Op = iota // A project got its first session
+	Update           // A project has new sessions, or its directory changed
+	Remove           // A project's data is gone
+)
+
+// String returns the name of the change, e.g. "add"
+func (op Op) String() string {
+	switch op {
+	case Add:
+		return "add"
+	case Update:
+		return "update"
+	case Remove:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// Event is a change to one project. For Remove, Project is the project as
+// it was last seen.
+type Event struct {
+	Op      Op
+	Project projects.Project
+}
+
+// Defaults used when the corresponding Options field is zero
+const (
+	DefaultInterval    = 30 * time.Second
+	DefaultSettle      = 500 * time.Millisecond
+	DefaultMinInterval = 5 * time.Second
+)
+
+// Options controls how a Watcher rescans
+type Options struct {
+	// Load is passed to projects.LoadProjectsContext on every rescan, except
+	// that git metadata and context are only read for projects that are
+	// new or were used since the last scan
+	Load projects.LoadOptions
+	// Interval is the longest time between rescans, and the time between
+	// all of them where change notifications aren't available
+	Interval time.Duration
+	// Settle is how long to wait after a change notification before
+	// rescanning, since Claude Code writes a transcript many times per reply
+	Settle time.Duration
+	// MinInterval is the shortest time between rescans after a change
+	// notification, since a running session keeps writing its transcript
+	MinInterval time.Duration
+}
+
+// Watcher rescans the projects and reports the differences to the last scan
+type Watcher struct {
+	opts  Options
+	known map[string]projects.Project
+}
+
+// New returns a watcher whose first rescan is compared with current, the
+// projects already shown
+func New(opts Options, current []projects.Project) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Settle <= 0 {
+		opts.Settle = DefaultSettle
+	}
+	if opts.MinInterval <= 0 {
+		opts.MinInterval = DefaultMinInterval
+	}
+	if len(opts.Load.Roots) == 0 {
+		opts.Load.Roots = projects.DefaultRoots()
+	}
+	w := &Watcher{opts: opts, known: make(map[string]projects.Project, len(current))}
+	for _, p := range current {
+		w.known[p.Key()] = p
+	}
+	return w
+}
+
+// Run rescans until ctx is cancelled, calling onEvents from its own
+// goroutine with the changes of every rescan that found any. A failed
+// rescan is skipped; the next one reports what it missed.
+func (w *Watcher) Run(ctx context.Context, onEvents func([]Event)) {
+	var dirs []string
+	for _, root := range w.opts.Load.Roots {
+		dirs = append(dirs, filepath.Join(root, "projects"))
+	}
+	changed := make(chan struct{}, 1)
+	// Without notifications the ticker alone drives the rescans
+	notifyChanges(ctx, dirs, changed)
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changed:
+			wait := max(w.opts.Settle, time.Until(last.Add(w.opts.MinInterval)))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+			// Changes made while settling are covered by this rescan
+			select {
+			case <-changed:
+			default:
+			}
+		}
+
+		events, err := w.Rescan(ctx)
+		last = time.Now()
+		if err == nil && len(events) > 0 {
+			onEvents(events)
+		}
+	}
+}
+
+// Rescan loads the projects and returns how they differ from the last scan.
+// Projects that were neither added nor used since keep the git metadata and
+// context read before, so a session being written to doesn't have every
+// repository read again.
+func (w *Watcher) Rescan(ctx context.Context) ([]Event, error) {
+	load := w.opts.Load
+	load.Git, load.Context = false, false
+	list, err := projects.LoadProjectsContext(ctx, load)
+	if err != nil && !errors.Is(err, projects.ErrNoProjects) {
+		return nil, err
+	}
+
+	current := make(map[string]projects.Project, len(list))
+	var events []Event
+	for _, p := range list {
+		k := p.Key()
+		old, ok := w.known[k]
+		switch {
+		case ok && p.PathState == projects.PathUnknown && old.PathState != projects.PathUnknown:
+			// A slow drive is PathUnknown on every rescan until its check
+			// finishes, so keep what was known about the directory
+			p.PathState, p.Path, p.Name = old.PathState, old.Path, old.Name
+			p.Git, p.RepoRoot, p.WorktreeOf = old.Git, old.RepoRoot, old.WorktreeOf
+			p.Context = old.Context
+		case p.PathState != projects.PathFound:
+		case ok && old.PathState == projects.PathFound && old.Path == p.Path && old.LastUsed.Equal(p.LastUsed):
+			p.Git, p.RepoRoot, p.WorktreeOf = old.Git, old.RepoRoot, old.WorktreeOf
+			p.Context = old.Context
+		default:
+			w.opts.Load.Describe(&p)
+		}
+		current[k] = p
+		switch {
+		case !ok:
+			events = append(events, Event{Op: Add, Project: p})
+		case changed(old, p):
+			events = append(events, Event{Op: Update, Project: p})
+		}
+	}
+	for k, p := range w.known {
+		if _, ok := current[k]; !ok {
+			events = append(events, Event{Op: Remove, Project: p})
+		}
+	}
+	w.known = current
+	return events, nil
+}
+
+// changed reports whether p differs from old in a way the list shows
+func changed(old, p projects.Project) bool {
+	return !old.LastUsed.Equal(p.LastUsed) ||
+		old.Path != p.Path ||
+		old.PathState != p.PathState ||
+		len(old.EncodedDirs) != len(p.EncodedDirs) ||
+		old.Git.Label() != p.Git.Label() ||
+		strings.Join(old.Context.Badges(), ",") != strings.Join(p.Context.Badges(), ",")
+}
+
+// Apply applies events to list in place and returns it. Updated projects
+// keep their position, added ones are appended and removed ones dropped, so
+// the caller only has to sort the list again. An Add of a project already
+// listed, such as a new repository found under a scan root, replaces it.
+func Apply(list []projects.Project, events []Event) []projects.Project {
+	index := make(map[string]int, len(list))
+	for i, p := range list {
+		index[p.Key()] = i
+	}
+	removed := make(map[int]bool)
+	for _, e := range events {
+		k := e.Project.Key()
+		i, ok := index[k]
+		switch {
+		case e.Op == Remove:
+			if ok {
+				removed[i] = true
+				delete(index, k)
+			}
+		case ok:
+			list[i] = e.Project
+		default:
+			index[k] = len(list)
+			list = append(list, e.Project)
+		}
+	}
+	if len(removed) == 0 {
+		return list
+	}
+	kept := list[:0]
+	for i, p := range list {
+		if !removed[i] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
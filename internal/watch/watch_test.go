@@ -0,0 +1,165 @@
+// Copyright (c) 2025 Fanis Hatzidakis
+// Licensed under PolyForm Internal Use License 1.0.0 - see LICENCE.md
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fanis/claude-code-switcher/internal/projects"
+)
+
+// writeSession adds a transcript for projectPath to the data root, in the
+// folder Claude Code would name encoded
+func writeSession(t *testing.T, root, encoded, projectPath, id string, modified time.Time) {
+	t.Helper()
+	dir := filepath.Join(root, "projects", encoded)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, id+".jsonl")
+	line := fmt.Sprintf(`{"type":"user","cwd":%q,"sessionId":%q}`+"\n", projectPath, id)
+	if err := os.WriteFile(file, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// summary lists events as "op path", sorted
+func summary(events []Event) []string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Op.String()+" "+e.Project.Path)
+	}
+	sort.Strings(s)
+	return s
+}
+
+func TestRescan(t *testing.T) {
+	root := t.TempDir()
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	writeSession(t, root, "-work-api", "/work/api", "s1", base)
+
+	w := New(Options{Load: projects.LoadOptions{Roots: []string{root}}}, nil)
+	ctx := context.Background()
+
+	steps := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{"first scan", func() {}, []string{"add /work/api"}},
+		{"nothing changed", func() {}, nil},
+		{"new session and new project", func() {
+			writeSession(t, root, "-work-api", "/work/api", "s2", base.Add(time.Hour))
+			writeSession(t, root, "-work-web", "/work/web", "s3", base)
+		}, []string{"add /work/web", "update /work/api"}},
+		{"project data deleted", func() {
+			os.RemoveAll(filepath.Join(root, "projects", "-work-api"))
+		}, []string{"remove /work/api"}},
+		{"last project deleted", func() {
+			os.RemoveAll(filepath.Join(root, "projects", "-work-web"))
+		}, []string{"remove /work/web"}},
+	}
+	for _, step := range steps {
+		step.change()
+		events, err := w.Rescan(ctx)
+		if err != nil {
+			t.Fatalf("%s: Rescan() error = %v", step.name, err)
+		}
+		if got := summary(events); fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Errorf("%s: events = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestRescanReusesDescription(t *testing.T) {
+	root := t.TempDir()
+	dir := t.TempDir()
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	writeSession(t, root, "-work-api", dir, "s1", base)
+
+	w := New(Options{Load: projects.LoadOptions{Roots: []string{root}, Context: true}}, nil)
+	ctx := context.Background()
+	if _, err := w.Rescan(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a new session the context read on the first scan is kept
+	os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte("# API\n"), 0644)
+	if events, _ := w.Rescan(ctx); len(events) != 0 {
+		t.Errorf("events = %v, want none for a project that wasn't used", summary(events))
+	}
+
+	// A new session has the project described again
+	writeSession(t, root, "-work-api", dir, "s2", base.Add(time.Hour))
+	events, _ := w.Rescan(ctx)
+	if len(events) != 1 || events[0].Op != Update || !events[0].Project.Context.ClaudeMD {
+		t.Errorf("events = %+v, want an update with CLAUDE.md", events)
+	}
+}
+
+func TestApply(t *testing.T) {
+	list := []projects.Project{
+		{Path: "/a", Name: "a"},
+		{Path: "/b", Name: "b"},
+		{Path: "/c", Name: "c", New: true},
+	}
+	list = Apply(list, []Event{
+		{Op: Update, Project: projects.Project{Path: "/a", Name: "a2"}},
+		{Op: Remove, Project: projects.Project{Path: "/b"}},
+		{Op: Add, Project: projects.Project{Path: "/c", Name: "c"}},
+		{Op: Add, Project: projects.Project{Path: "/d", Name: "d"}},
+		{Op: Remove, Project: projects.Project{Path: "/missing"}},
+	})
+
+	var got []string
+	for _, p := range list {
+		got = append(got, fmt.Sprintf("%s:%s:%v", p.Path, p.Name, p.New))
+	}
+	want := []string{"/a:a2:false", "/c:c:false", "/d:d:false"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+
+	// The same directory in another data root is another project
+	list = Apply(list, []Event{{Op: Add, Project: projects.Project{Path: "/a", Root: "/other"}}})
+	if len(list) != 4 {
+		t.Errorf("Apply() merged projects from different roots: %+v", list)
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	writeSession(t, root, "-work-api", "/work/api", "s1", time.Now())
+
+	opts := Options{Load: projects.LoadOptions{Roots: []string{root}}, Interval: 20 * time.Millisecond}
+	current, err := projects.LoadProjectsContext(context.Background(), opts.Load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := New(opts, current)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan []Event, 10)
+	go w.Run(ctx, func(events []Event) { got <- events })
+
+	writeSession(t, root, "-work-web", "/work/web", "s2", time.Now())
+	select {
+	case events := <-got:
+		if s := summary(events); len(s) != 1 || s[0] != "add /work/web" {
+			t.Errorf("events = %v, want the new project only", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no events for a new project")
+	}
+}
@@ -4,14 +4,18 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"os"
 	"runtime"
 	"syscall"
 	"unsafe"
 
+	"github.com/fanis/claude-code-switcher/internal/cli"
 	"github.com/fanis/claude-code-switcher/internal/config"
 	"github.com/fanis/claude-code-switcher/internal/gui"
 	"github.com/fanis/claude-code-switcher/internal/projects"
+	"github.com/fanis/claude-code-switcher/internal/watch"
 )
 
 func utf16PtrFromString(s string) uintptr {
@@ -26,8 +30,23 @@ func main() {
 	// Without this, Go may reschedule the goroutine to a different thread
 	// between window creation and message processing, crashing on first interaction.
 	runtime.LockOSThread()
-	// Load projects from Claude Code data
-	projectList, err := projects.LoadProjects()
+
+	// With arguments, run a headless command instead of the window
+	if len(os.Args) > 1 {
+		attachConsole()
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	// Load config (non-fatal if missing)
+	cfg, _ := config.Load()
+
+	// Load projects from Claude Code data. Paths that are slow to check
+	// (e.g. offline network shares) are resolved after the list is shown.
+	opts := projects.DefaultLoadOptions()
+	opts.Roots = projects.DefaultRoots(cfg.ClaudeDirs...)
+	opts.OnUpdate = gui.UpdateProject
+	opts.Pinned = cfg.Pinned
+	projectList, err := projects.LoadProjectsContext(context.Background(), opts)
 	if err != nil {
 		if errors.Is(err, projects.ErrNoProjects) {
 			showError("No Projects Found",
@@ -40,11 +59,9 @@ func main() {
 		return
 	}
 
-	// Load config (non-fatal if missing)
-	cfg, _ := config.Load()
-
-	// Run the GUI
-	gui.Run(projectList, appVersion, cfg)
+	// Run the GUI, picking up sessions started while it is open
+	watcher := watch.New(watch.Options{Load: opts}, projectList)
+	gui.Run(projectList, watcher, appVersion, cfg)
 }
 
 func showError(title, message string) {
@@ -61,3 +78,23 @@ func showError(title, message string) {
 		MB_OK|MB_ICONERROR,
 	)
 }
+
+// attachConsole connects output to the console of the shell that started
+// the switcher. Built as a GUI application it has no console of its own, so
+// without this a headless command would print nothing. Output redirected to
+// a file or pipe is left alone.
+func attachConsole() {
+	if _, err := os.Stdout.Stat(); err == nil {
+		return
+	}
+
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	const ATTACH_PARENT_PROCESS = ^uintptr(0) // (DWORD)-1
+	if ret, _, _ := kernel32.NewProc("AttachConsole").Call(ATTACH_PARENT_PROCESS); ret == 0 {
+		return
+	}
+	if out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = out
+		os.Stderr = out
+	}
+}